@@ -0,0 +1,220 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+// Evaluator evaluates flags against Flipt. It is implemented by the transport Service.
+type Evaluator interface {
+	Evaluate(ctx context.Context, namespaceKey, flagKey string, evalCtx map[string]interface{}) (*evaluation.VariantEvaluationResponse, error)
+	Boolean(ctx context.Context, namespaceKey, flagKey string, evalCtx map[string]interface{}) (*evaluation.BooleanEvaluationResponse, error)
+}
+
+// Target is a named Evaluator to run the workload against.
+type Target struct {
+	Name      string
+	Evaluator Evaluator
+}
+
+// Transports returns targets for the gRPC and HTTP transports of the same
+// Flipt instance, configured with the given options.
+func Transports(grpcAddress, httpAddress string, opts ...transport.Option) []Target {
+	withAddress := func(address string) []transport.Option {
+		return append(append([]transport.Option{}, opts...), transport.WithAddress(address))
+	}
+
+	return []Target{
+		{Name: "grpc", Evaluator: transport.New(withAddress(grpcAddress)...)},
+		{Name: "http", Evaluator: transport.New(withAddress(httpAddress)...)},
+	}
+}
+
+// Workload describes the evaluations performed against each target.
+type Workload struct {
+	// Namespace is the namespace of the evaluated flags.
+	Namespace string
+	// VariantFlags and BooleanFlags are the keys of the flags to evaluate.
+	VariantFlags []string
+	BooleanFlags []string
+	// Contexts are the evaluation contexts used in turn for each evaluation.
+	// Each context must contain a targeting key.
+	Contexts []map[string]interface{}
+	// Iterations is the number of evaluations performed against each target.
+	Iterations int
+	// Concurrency is the number of goroutines performing evaluations.
+	Concurrency int
+	// Warmup is the number of evaluations performed before measuring, e.g.
+	// to establish connections.
+	Warmup int
+}
+
+// Result is the outcome of running a workload against a target.
+type Result struct {
+	Name        string
+	Evaluations int
+	Errors      int
+	Duration    time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	// AllocsPerOp and BytesPerOp are approximations based on the allocations
+	// of the whole process while the workload was running.
+	AllocsPerOp float64
+	BytesPerOp  float64
+}
+
+// Compare runs the workload against each target in turn and returns a
+// result per target.
+func Compare(ctx context.Context, w Workload, targets ...Target) ([]Result, error) {
+	if len(w.VariantFlags)+len(w.BooleanFlags) == 0 {
+		return nil, fmt.Errorf("workload has no flags")
+	}
+
+	if len(w.Contexts) == 0 {
+		return nil, fmt.Errorf("workload has no evaluation contexts")
+	}
+
+	if w.Iterations <= 0 {
+		return nil, fmt.Errorf("workload iterations must be positive")
+	}
+
+	if w.Concurrency <= 0 {
+		w.Concurrency = 1
+	}
+
+	results := make([]Result, 0, len(targets))
+	for _, target := range targets {
+		result, err := run(ctx, w, target)
+		if err != nil {
+			return nil, fmt.Errorf("running workload against %q: %w", target.Name, err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func run(ctx context.Context, w Workload, target Target) (Result, error) {
+	for i := 0; i < w.Warmup; i++ {
+		_ = evaluate(ctx, w, target.Evaluator, i)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	var (
+		latencies = make([]time.Duration, w.Iterations)
+		errs      = make([]bool, w.Iterations)
+		next      = make(chan int)
+		wg        sync.WaitGroup
+		before    runtime.MemStats
+		after     runtime.MemStats
+	)
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+
+	for g := 0; g < w.Concurrency; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range next {
+				began := time.Now()
+				errs[i] = evaluate(ctx, w, target.Evaluator, i) != nil
+				latencies[i] = time.Since(began)
+			}
+		}()
+	}
+
+	for i := 0; i < w.Iterations; i++ {
+		next <- i
+	}
+
+	close(next)
+	wg.Wait()
+
+	duration := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+
+	result := Result{
+		Name:        target.Name,
+		Evaluations: w.Iterations,
+		Duration:    duration,
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / float64(w.Iterations),
+		BytesPerOp:  float64(after.TotalAlloc-before.TotalAlloc) / float64(w.Iterations),
+	}
+
+	var total time.Duration
+	for i, latency := range latencies {
+		total += latency
+		if errs[i] {
+			result.Errors++
+		}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	result.Mean = total / time.Duration(len(latencies))
+	result.P50 = percentile(latencies, 0.50)
+	result.P90 = percentile(latencies, 0.90)
+	result.P99 = percentile(latencies, 0.99)
+	result.Max = latencies[len(latencies)-1]
+
+	return result, nil
+}
+
+// evaluate performs the i-th evaluation of the workload, cycling through its
+// flags and contexts.
+func evaluate(ctx context.Context, w Workload, e Evaluator, i int) error {
+	evalCtx := w.Contexts[i%len(w.Contexts)]
+
+	flag := i % (len(w.VariantFlags) + len(w.BooleanFlags))
+	if flag < len(w.VariantFlags) {
+		_, err := e.Evaluate(ctx, w.Namespace, w.VariantFlags[flag], evalCtx)
+		return err
+	}
+
+	_, err := e.Boolean(ctx, w.Namespace, w.BooleanFlags[flag-len(w.VariantFlags)], evalCtx)
+
+	return err
+}
+
+// percentile returns the p-th percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(sorted)-1) * p)
+
+	return sorted[idx]
+}
+
+// WriteReport writes the results as a table to w.
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TARGET\tEVALS\tERRORS\tMEAN\tP50\tP90\tP99\tMAX\tALLOCS/OP\tB/OP")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%.1f\t%.0f\n",
+			r.Name, r.Evaluations, r.Errors, r.Mean, r.P50, r.P90, r.P99, r.Max, r.AllocsPerOp, r.BytesPerOp)
+	}
+
+	return tw.Flush()
+}
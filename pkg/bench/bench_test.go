@@ -0,0 +1,74 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+type fakeEvaluator struct {
+	delay time.Duration
+	err   error
+}
+
+func (f fakeEvaluator) Evaluate(context.Context, string, string, map[string]interface{}) (*evaluation.VariantEvaluationResponse, error) {
+	time.Sleep(f.delay)
+	return &evaluation.VariantEvaluationResponse{Match: true}, f.err
+}
+
+func (f fakeEvaluator) Boolean(context.Context, string, string, map[string]interface{}) (*evaluation.BooleanEvaluationResponse, error) {
+	time.Sleep(f.delay)
+	return &evaluation.BooleanEvaluationResponse{Enabled: true}, f.err
+}
+
+func TestCompare(t *testing.T) {
+	w := Workload{
+		Namespace:    "default",
+		VariantFlags: []string{"variant"},
+		BooleanFlags: []string{"boolean"},
+		Contexts:     []map[string]interface{}{{of.TargetingKey: "1"}, {of.TargetingKey: "2"}},
+		Iterations:   20,
+		Concurrency:  4,
+		Warmup:       2,
+	}
+
+	results, err := Compare(context.Background(), w,
+		Target{Name: "fast", Evaluator: fakeEvaluator{}},
+		Target{Name: "slow", Evaluator: fakeEvaluator{delay: time.Millisecond, err: errors.New("boom")}},
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "fast", results[0].Name)
+	assert.Equal(t, 20, results[0].Evaluations)
+	assert.Zero(t, results[0].Errors)
+
+	assert.Equal(t, "slow", results[1].Name)
+	assert.Equal(t, 20, results[1].Errors)
+	assert.GreaterOrEqual(t, results[1].P50, time.Millisecond)
+	assert.LessOrEqual(t, results[1].P50, results[1].P99)
+	assert.Less(t, results[0].Mean, results[1].Mean)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, results))
+	assert.Contains(t, buf.String(), "TARGET")
+	assert.Contains(t, buf.String(), "slow")
+}
+
+func TestCompare_InvalidWorkload(t *testing.T) {
+	_, err := Compare(context.Background(), Workload{Iterations: 1, Contexts: []map[string]interface{}{{}}})
+	assert.EqualError(t, err, "workload has no flags")
+
+	_, err = Compare(context.Background(), Workload{Iterations: 1, VariantFlags: []string{"foo"}})
+	assert.EqualError(t, err, "workload has no evaluation contexts")
+
+	_, err = Compare(context.Background(), Workload{VariantFlags: []string{"foo"}, Contexts: []map[string]interface{}{{}}})
+	assert.EqualError(t, err, "workload iterations must be positive")
+}
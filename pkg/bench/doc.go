@@ -0,0 +1,10 @@
+// This package provides a harness for comparing the performance of the Flipt transports.
+//
+// It runs an identical evaluation workload through each of the configured targets (e.g. the gRPC and HTTP
+// transports of the same Flipt instance) and reports their latency distribution and allocations, so that a
+// transport can be chosen based on data rather than assumptions.
+//
+// Soak runs a provider under sustained load, typically for hours, sampling goroutines, heap and connections, and
+// reports a leak when usage grows beyond the configured thresholds. It is intended to gain confidence in background
+// subsystems such as change polling before deploying them to production.
+package bench
@@ -0,0 +1,261 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// ErrLeak is returned by Soak when resource usage grew beyond the configured
+// thresholds over the course of the run.
+var ErrLeak = errors.New("resource leak detected")
+
+// SoakConfig describes a soak test run against a provider.
+type SoakConfig struct {
+	// Provider is the provider under test.
+	Provider of.FeatureProvider
+	// VariantFlags and BooleanFlags are the keys of the flags to evaluate.
+	VariantFlags []string
+	BooleanFlags []string
+	// Contexts are the evaluation contexts used in turn for each evaluation.
+	Contexts []of.FlattenedContext
+	// Concurrency is the number of goroutines performing evaluations.
+	Concurrency int
+	// Duration is how long load is sustained for, e.g. several hours.
+	Duration time.Duration
+	// Warmup is how long load is sustained before the baseline is sampled,
+	// e.g. to establish connections and fill caches.
+	Warmup time.Duration
+	// SampleInterval is how often resource usage is sampled.
+	SampleInterval time.Duration
+	// MaxGoroutineGrowth, MaxHeapGrowth (in bytes) and MaxConnectionGrowth
+	// are the growth in resource usage between the baseline and the end of
+	// the run above which a leak is reported.
+	MaxGoroutineGrowth  int
+	MaxHeapGrowth       uint64
+	MaxConnectionGrowth int
+	// Connections returns the number of open network connections. By
+	// default open sockets of the process are counted, where supported.
+	Connections func() int
+}
+
+// Sample is a snapshot of the resource usage of the process.
+type Sample struct {
+	Time       time.Time
+	Goroutines int
+	HeapAlloc  uint64
+	// Connections is -1 when connections cannot be counted.
+	Connections int
+}
+
+// SoakReport is the outcome of a soak test run.
+type SoakReport struct {
+	Evaluations int64
+	Errors      int64
+	Baseline    Sample
+	Final       Sample
+	Samples     []Sample
+}
+
+// Soak sustains load against the provider for the configured duration while
+// sampling goroutines, heap and connections. It returns ErrLeak, along with
+// the report, when usage after the run exceeds the baseline by more than the
+// configured thresholds.
+func Soak(ctx context.Context, c SoakConfig) (SoakReport, error) {
+	if c.Provider == nil {
+		return SoakReport{}, errors.New("soak config has no provider")
+	}
+
+	if len(c.VariantFlags)+len(c.BooleanFlags) == 0 {
+		return SoakReport{}, errors.New("soak config has no flags")
+	}
+
+	if len(c.Contexts) == 0 {
+		return SoakReport{}, errors.New("soak config has no evaluation contexts")
+	}
+
+	if c.Concurrency < 1 {
+		c.Concurrency = 1
+	}
+
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = time.Minute
+	}
+
+	if c.Connections == nil {
+		c.Connections = openSockets
+	}
+
+	var (
+		report            SoakReport
+		evaluations       atomic.Int64
+		errs              atomic.Int64
+		loadCtx, stopLoad = context.WithCancel(ctx)
+		wg                sync.WaitGroup
+	)
+
+	defer stopLoad()
+
+	for w := 0; w < c.Concurrency; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := w; loadCtx.Err() == nil; i += c.Concurrency {
+				if !soakEvaluate(loadCtx, c, i) {
+					errs.Add(1)
+				}
+
+				evaluations.Add(1)
+			}
+		}(w)
+	}
+
+	if !sleep(ctx, c.Warmup) {
+		stopLoad()
+		wg.Wait()
+
+		return report, ctx.Err()
+	}
+
+	report.Baseline = sample(c.Connections)
+	report.Samples = append(report.Samples, report.Baseline)
+
+	ticker := time.NewTicker(c.SampleInterval)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(c.Duration)
+	defer deadline.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			break loop
+		case <-ticker.C:
+			report.Samples = append(report.Samples, sample(c.Connections))
+		}
+	}
+
+	stopLoad()
+	wg.Wait()
+
+	report.Evaluations = evaluations.Load()
+	report.Errors = errs.Load()
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	// give goroutines serving the final evaluations time to exit
+	sleep(ctx, 100*time.Millisecond)
+
+	report.Final = sample(c.Connections)
+	report.Samples = append(report.Samples, report.Final)
+
+	return report, checkLeaks(c, report.Baseline, report.Final)
+}
+
+func soakEvaluate(ctx context.Context, c SoakConfig, i int) bool {
+	var (
+		nflags  = len(c.VariantFlags) + len(c.BooleanFlags)
+		evalCtx = c.Contexts[i%len(c.Contexts)]
+		flag    = i % nflags
+	)
+
+	if flag < len(c.VariantFlags) {
+		detail := c.Provider.StringEvaluation(ctx, c.VariantFlags[flag], "", evalCtx)
+		return detail.ResolutionDetail().ErrorCode == ""
+	}
+
+	detail := c.Provider.BooleanEvaluation(ctx, c.BooleanFlags[flag-len(c.VariantFlags)], false, evalCtx)
+
+	return detail.ResolutionDetail().ErrorCode == ""
+}
+
+func checkLeaks(c SoakConfig, baseline, final Sample) error {
+	var leaks []string
+
+	if growth := final.Goroutines - baseline.Goroutines; growth > c.MaxGoroutineGrowth {
+		leaks = append(leaks, fmt.Sprintf("goroutines grew by %d", growth))
+	}
+
+	if final.HeapAlloc > baseline.HeapAlloc {
+		if growth := final.HeapAlloc - baseline.HeapAlloc; growth > c.MaxHeapGrowth {
+			leaks = append(leaks, fmt.Sprintf("heap grew by %d bytes", growth))
+		}
+	}
+
+	if baseline.Connections >= 0 && final.Connections >= 0 {
+		if growth := final.Connections - baseline.Connections; growth > c.MaxConnectionGrowth {
+			leaks = append(leaks, fmt.Sprintf("connections grew by %d", growth))
+		}
+	}
+
+	if len(leaks) > 0 {
+		return fmt.Errorf("%w: %s", ErrLeak, strings.Join(leaks, ", "))
+	}
+
+	return nil
+}
+
+// sample collects garbage before sampling so that heap usage reflects live
+// objects only.
+func sample(connections func() int) Sample {
+	runtime.GC()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return Sample{
+		Time:        time.Now(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		Connections: connections(),
+	}
+}
+
+// openSockets returns the number of sockets opened by the process, or -1
+// when they cannot be listed.
+func openSockets() int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	var n int
+
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		if err == nil && strings.HasPrefix(target, "socket:") {
+			n++
+		}
+	}
+
+	return n
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,111 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leakyProvider starts a goroutine for each boolean evaluation which only
+// exits once done is closed.
+type leakyProvider struct {
+	of.NoopProvider
+	done chan struct{}
+}
+
+func (p leakyProvider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	go func() { <-p.done }()
+	time.Sleep(time.Millisecond)
+
+	return p.NoopProvider.BooleanEvaluation(ctx, flag, defaultValue, evalCtx)
+}
+
+func TestSoak(t *testing.T) {
+	report, err := Soak(context.Background(), SoakConfig{
+		Provider:           of.NoopProvider{},
+		VariantFlags:       []string{"variant"},
+		BooleanFlags:       []string{"boolean"},
+		Contexts:           []of.FlattenedContext{{of.TargetingKey: "1"}},
+		Concurrency:        2,
+		Duration:           50 * time.Millisecond,
+		Warmup:             10 * time.Millisecond,
+		SampleInterval:     10 * time.Millisecond,
+		MaxGoroutineGrowth: 2,
+		MaxHeapGrowth:      1 << 20,
+		Connections:        func() int { return 1 },
+	})
+	require.NoError(t, err)
+
+	assert.Positive(t, report.Evaluations)
+	assert.Zero(t, report.Errors)
+	assert.GreaterOrEqual(t, len(report.Samples), 2)
+	assert.Equal(t, report.Baseline, report.Samples[0])
+	assert.Equal(t, report.Final, report.Samples[len(report.Samples)-1])
+}
+
+func TestSoak_Leak(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	report, err := Soak(context.Background(), SoakConfig{
+		Provider:       leakyProvider{done: done},
+		BooleanFlags:   []string{"boolean"},
+		Contexts:       []of.FlattenedContext{{}},
+		Duration:       20 * time.Millisecond,
+		SampleInterval: 10 * time.Millisecond,
+		MaxHeapGrowth:  1 << 30,
+		Connections:    func() int { return 1 },
+	})
+	require.ErrorIs(t, err, ErrLeak)
+	assert.Contains(t, err.Error(), "goroutines grew by")
+	assert.Greater(t, report.Final.Goroutines, report.Baseline.Goroutines)
+}
+
+func TestSoak_Connections(t *testing.T) {
+	var connections int
+
+	_, err := Soak(context.Background(), SoakConfig{
+		Provider:       of.NoopProvider{},
+		BooleanFlags:   []string{"boolean"},
+		Contexts:       []of.FlattenedContext{{}},
+		Duration:       10 * time.Millisecond,
+		SampleInterval: time.Second,
+		MaxHeapGrowth:  1 << 30,
+		Connections: func() int {
+			connections += 5
+			return connections
+		},
+	})
+	require.ErrorIs(t, err, ErrLeak)
+	assert.Contains(t, err.Error(), "connections grew by 5")
+}
+
+func TestSoak_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Soak(ctx, SoakConfig{
+		Provider:     of.NoopProvider{},
+		BooleanFlags: []string{"boolean"},
+		Contexts:     []of.FlattenedContext{{}},
+		Warmup:       time.Second,
+		Duration:     time.Hour,
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestSoak_InvalidConfig(t *testing.T) {
+	_, err := Soak(context.Background(), SoakConfig{})
+	assert.EqualError(t, err, "soak config has no provider")
+
+	_, err = Soak(context.Background(), SoakConfig{Provider: of.NoopProvider{}, Contexts: []of.FlattenedContext{{}}})
+	assert.EqualError(t, err, "soak config has no flags")
+
+	_, err = Soak(context.Background(), SoakConfig{Provider: of.NoopProvider{}, BooleanFlags: []string{"foo"}})
+	assert.EqualError(t, err, "soak config has no evaluation contexts")
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+var _ flipt.Invalidator = (*Invalidator)(nil)
+
+// Invalidator is a flipt.Invalidator broadcasting invalidations over a Redis
+// pub/sub channel.
+type Invalidator struct {
+	client  *Cache
+	channel string
+}
+
+// NewInvalidator returns an Invalidator publishing to channel on the Redis
+// server at addr. WithPrefix has no effect on the channel name.
+func NewInvalidator(addr, channel string, opts ...Option) *Invalidator {
+	return &Invalidator{client: New(addr, opts...), channel: channel}
+}
+
+// Publish implements flipt.Invalidator.
+func (i *Invalidator) Publish(ctx context.Context, inv flipt.Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.client.do(ctx, "PUBLISH", i.channel, string(payload))
+
+	return err
+}
+
+// Subscribe implements flipt.Invalidator. Messages which cannot be decoded
+// are skipped.
+func (i *Invalidator) Subscribe(ctx context.Context, subscribed func(), fn func(flipt.Invalidation)) error {
+	// the subscription holds a connection of its own, outside of the pool
+	cctx, cancel := i.client.commandContext(ctx)
+	defer cancel()
+
+	cn, err := i.client.dial(cctx)
+	if err != nil {
+		return err
+	}
+
+	defer cn.Close()
+
+	// the connection is blocked reading messages until ctx is done
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if _, err := cn.do(cctx, "SUBSCRIBE", i.channel); err != nil {
+		return subscriptionError(ctx, err)
+	}
+
+	if err := cn.SetDeadline(time.Time{}); err != nil {
+		return subscriptionError(ctx, err)
+	}
+
+	subscribed()
+
+	for {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			return subscriptionError(ctx, err)
+		}
+
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 {
+			continue
+		}
+
+		if kind, _ := msg[0].([]byte); string(kind) != "message" {
+			continue
+		}
+
+		payload, _ := msg[2].([]byte)
+
+		var inv flipt.Invalidation
+		if err := json.Unmarshal(payload, &inv); err == nil {
+			fn(inv)
+		}
+	}
+}
+
+// Close closes the idle connections used to publish invalidations.
+func (i *Invalidator) Close() error {
+	return i.client.Close()
+}
+
+// subscriptionError returns nil when the subscription ended because ctx is
+// done, otherwise err.
+func subscriptionError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var rerr replyError
+	if errors.As(err, &rerr) {
+		return err
+	}
+
+	return fmt.Errorf("redis: subscription: %w", err)
+}
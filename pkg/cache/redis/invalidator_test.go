@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+func TestInvalidator(t *testing.T) {
+	var (
+		server        = newFakeServer(t, "secret")
+		publisher     = NewInvalidator(server.addr(), "flipt-invalidations", WithPassword("secret"))
+		subscriber    = NewInvalidator(server.addr(), "flipt-invalidations", WithPassword("secret"))
+		invalidations = make(chan flipt.Invalidation, 1)
+		subscribed    = make(chan struct{})
+		done          = make(chan error)
+	)
+
+	defer publisher.Close()
+	defer subscriber.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		done <- subscriber.Subscribe(ctx, func() { close(subscribed) }, func(inv flipt.Invalidation) {
+			invalidations <- inv
+		})
+	}()
+
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not established")
+	}
+
+	require.NoError(t, publisher.Publish(context.Background(), flipt.Invalidation{Namespace: "default", Flags: []string{"a"}}))
+
+	select {
+	case inv := <-invalidations:
+		assert.Equal(t, flipt.Invalidation{Namespace: "default", Flags: []string{"a"}}, inv)
+	case <-time.After(time.Second):
+		t.Fatal("invalidation was not received")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("subscription did not stop")
+	}
+}
+
+func TestInvalidatorSubscriptionFailure(t *testing.T) {
+	server := newFakeServer(t, "")
+	subscriber := NewInvalidator(server.addr(), "flipt-invalidations")
+
+	errs := make(chan error)
+
+	go func() {
+		errs <- subscriber.Subscribe(context.Background(), func() {}, func(flipt.Invalidation) {})
+	}()
+
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		return len(server.subscribers["flipt-invalidations"]) == 1
+	}, time.Second, time.Millisecond)
+
+	server.mu.Lock()
+	server.subscribers["flipt-invalidations"][0].Close()
+	server.mu.Unlock()
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "redis: subscription")
+	case <-time.After(time.Second):
+		t.Fatal("subscription did not fail")
+	}
+}
@@ -0,0 +1,462 @@
+// Package redis provides Redis backed implementations of the flipt.Cache
+// interface, so that horizontally scaled services share cached evaluations
+// and keep them across restarts, and of the flipt.Invalidator interface, to
+// broadcast flag changes between them.
+//
+// It speaks the Redis protocol directly and does not depend on a Redis client
+// library.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPrefix      = "flipt:"
+	defaultPoolSize    = 8
+	defaultDialTimeout = 5 * time.Second
+	defaultTimeout     = 3 * time.Second
+	scanCount          = 100
+)
+
+// errNil is the reply to commands whose result does not exist.
+var errNil = errors.New("redis: nil")
+
+// Option is a configuration option for the Cache.
+type Option func(*Cache)
+
+// WithPassword authenticates connections with password.
+func WithPassword(password string) Option {
+	return func(c *Cache) {
+		c.password = password
+	}
+}
+
+// WithDB selects the logical database used by connections.
+func WithDB(db int) Option {
+	return func(c *Cache) {
+		c.db = db
+	}
+}
+
+// WithPrefix sets the prefix of all keys stored by the Cache, "flipt:" by
+// default. Flush only removes keys with this prefix.
+func WithPrefix(prefix string) Option {
+	return func(c *Cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithPoolSize sets the maximum number of connections open at once, 8 by
+// default. Commands sent while all of them are busy wait for one to be
+// returned to the pool.
+func WithPoolSize(size int) Option {
+	return func(c *Cache) {
+		c.size = size
+	}
+}
+
+// WithTimeout bounds the commands sent with a context without a deadline,
+// including waiting for a connection, 3s by default. A timeout of zero
+// leaves them unbounded.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Cache) {
+		c.timeout = timeout
+	}
+}
+
+// WithTLSConfig connects to Redis over TLS using config. The server name is
+// derived from the address when config does not set one.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Cache) {
+		c.tlsConfig = config
+	}
+}
+
+// Cache is a flipt.Cache storing values in Redis.
+type Cache struct {
+	addr      string
+	password  string
+	db        int
+	prefix    string
+	size      int
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	dialer    net.Dialer
+
+	// slots holds a token for each open pooled connection, idle or not
+	slots chan struct{}
+	idle  chan *conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New returns a Cache storing values in the Redis server at addr, e.g.
+// "localhost:6379". Connections are established lazily.
+func New(addr string, opts ...Option) *Cache {
+	c := &Cache{
+		addr:    addr,
+		prefix:  defaultPrefix,
+		size:    defaultPoolSize,
+		timeout: defaultTimeout,
+		dialer:  net.Dialer{Timeout: defaultDialTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.size < 1 {
+		c.size = 1
+	}
+
+	c.slots = make(chan struct{}, c.size)
+	c.idle = make(chan *conn, c.size)
+
+	return c
+}
+
+// Get returns the value stored for key, reporting false when it is missing or
+// expired.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.prefix+key)
+	if errors.Is(err, errNil) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+
+	return value, true, nil
+}
+
+// Set stores value for key, expiring it after ttl. Values are never stored
+// without an expiry: a ttl below one millisecond stores nothing.
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return nil
+	}
+
+	_, err := c.do(ctx, "SET", c.prefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+
+	return err
+}
+
+// Delete removes the value stored for key, if any.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", c.prefix+key)
+
+	return err
+}
+
+// Flush removes all values stored with the Cache's prefix.
+func (c *Cache) Flush(ctx context.Context) error {
+	return c.deleteMatching(ctx, escapePattern(c.prefix)+"*")
+}
+
+// DeletePrefix removes all values whose key starts with prefix.
+func (c *Cache) DeletePrefix(ctx context.Context, prefix string) error {
+	return c.deleteMatching(ctx, escapePattern(c.prefix+prefix)+"*")
+}
+
+// deleteMatching removes all keys matching the glob-style pattern.
+func (c *Cache) deleteMatching(ctx context.Context, pattern string) error {
+	cursor := "0"
+
+	for {
+		reply, err := c.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", strconv.Itoa(scanCount))
+		if err != nil {
+			return err
+		}
+
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return fmt.Errorf("redis: unexpected SCAN reply %v", reply)
+		}
+
+		next, _ := page[0].([]byte)
+		keys, _ := page[1].([]interface{})
+
+		if len(keys) > 0 {
+			args := make([]string, 0, len(keys)+1)
+			args = append(args, "DEL")
+
+			for _, k := range keys {
+				if k, ok := k.([]byte); ok {
+					args = append(args, string(k))
+				}
+			}
+
+			if _, err := c.do(ctx, args...); err != nil {
+				return err
+			}
+		}
+
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// escapePattern escapes the characters of s with a special meaning in
+// glob-style patterns.
+func escapePattern(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Close closes the idle connections. Connections in use are closed once
+// returned to the pool.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	for {
+		select {
+		case cn := <-c.idle:
+			c.discard(cn)
+		default:
+			return nil
+		}
+	}
+}
+
+// commandContext bounds ctx by the timeout of the Cache, unless it already
+// has a deadline.
+func (c *Cache) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// do sends a command and returns its reply, which is a []byte, int64,
+// string or []interface{} of those.
+func (c *Cache) do(ctx context.Context, args ...string) (interface{}, error) {
+	ctx, cancel := c.commandContext(ctx)
+	defer cancel()
+
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args...)
+
+	var rerr replyError
+	if err != nil && !errors.Is(err, errNil) && !errors.As(err, &rerr) {
+		// the connection is in an unknown state
+		c.discard(cn)
+		return nil, err
+	}
+
+	c.put(cn)
+
+	return reply, err
+}
+
+// get returns an idle pooled connection, or opens one when the pool is not
+// full, waiting for a connection to be returned otherwise.
+func (c *Cache) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("redis: waiting for a connection: %w", ctx.Err())
+	}
+
+	cn, err := c.dial(ctx)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+
+	return cn, nil
+}
+
+// put returns cn to the pool, or closes it once the Cache is closed.
+func (c *Cache) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		c.discard(cn)
+		return
+	}
+
+	// never blocks, as there are no more open connections than slots
+	c.idle <- cn
+}
+
+// discard closes the pooled connection cn, freeing its slot.
+func (c *Cache) discard(cn *conn) {
+	cn.Close()
+	<-c.slots
+}
+
+// dial opens a connection which is not pooled, authenticating it and
+// selecting the database.
+func (c *Cache) dial(ctx context.Context) (*conn, error) {
+	var (
+		nc  net.Conn
+		err error
+	)
+
+	if c.tlsConfig != nil {
+		d := tls.Dialer{NetDialer: &c.dialer, Config: c.tlsConfig}
+		nc, err = d.DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = c.dialer.DialContext(ctx, "tcp", c.addr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("redis: connecting: %w", err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if c.password != "" {
+		if _, err := cn.do(ctx, "AUTH", c.password); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: authenticating: %w", err)
+		}
+	}
+
+	if c.db != 0 {
+		if _, err := cn.do(ctx, "SELECT", strconv.Itoa(c.db)); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("redis: selecting database: %w", err)
+		}
+	}
+
+	return cn, nil
+}
+
+// replyError is an error reply sent by Redis.
+type replyError string
+
+func (e replyError) Error() string { return "redis: " + string(e) }
+
+// conn is a connection to Redis.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (cn *conn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// unblock the command once ctx is canceled
+	stop := context.AfterFunc(ctx, func() { _ = cn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(cn.r)
+}
+
+// readReply reads a reply encoded using the Redis serialization protocol.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, replyError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, errNil
+		}
+
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, errNil
+		}
+
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil && !errors.Is(err, errNil) {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
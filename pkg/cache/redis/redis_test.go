@@ -0,0 +1,432 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+var _ flipt.Cache = (*Cache)(nil)
+
+// fakeServer is an in-memory server implementing the subset of Redis used by
+// the Cache.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu          sync.Mutex
+	data        map[string]string
+	ttls        map[string]string
+	subscribers map[string][]net.Conn
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	return serveFake(t, ln, password)
+}
+
+// newFakeTLSServer returns a fakeServer accepting TLS connections, along with
+// a configuration trusting its certificate.
+func newFakeTLSServer(t *testing.T) (*fakeServer, *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	require.NoError(t, err)
+
+	return serveFake(t, ln, ""), &tls.Config{RootCAs: pool}
+}
+
+func serveFake(t *testing.T, ln net.Listener, password string) *fakeServer {
+	s := &fakeServer{
+		ln:          ln,
+		password:    password,
+		data:        map[string]string{},
+		ttls:        map[string]string{},
+		subscribers: map[string][]net.Conn{},
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(c)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+
+	var (
+		r      = bufio.NewReader(c)
+		authed = s.password == ""
+	)
+
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		if args[0] == "AUTH" {
+			if args[1] != s.password {
+				c.Write([]byte("-WRONGPASS invalid password\r\n"))
+				continue
+			}
+
+			authed = true
+			c.Write([]byte("+OK\r\n"))
+
+			continue
+		}
+
+		if !authed {
+			c.Write([]byte("-NOAUTH Authentication required.\r\n"))
+			continue
+		}
+
+		s.mu.Lock()
+		c.Write(s.handle(c, args))
+		s.mu.Unlock()
+	}
+}
+
+// handle returns the reply to the command. It must be called with s.mu held,
+// which serializes writes to subscribed connections.
+func (s *fakeServer) handle(c net.Conn, args []string) []byte {
+	switch args[0] {
+	case "SUBSCRIBE":
+		s.subscribers[args[1]] = append(s.subscribers[args[1]], c)
+
+		return append(append([]byte("*3\r\n"), bulk("subscribe")...), append(bulk(args[1]), ":1\r\n"...)...)
+	case "PUBLISH":
+		for _, sub := range s.subscribers[args[1]] {
+			sub.Write(append(append([]byte("*3\r\n"), bulk("message")...), append(bulk(args[1]), bulk(args[2])...)...))
+		}
+
+		return []byte(":" + strconv.Itoa(len(s.subscribers[args[1]])) + "\r\n")
+	case "SELECT":
+		return []byte("+OK\r\n")
+	case "GET":
+		v, ok := s.data[args[1]]
+		if !ok {
+			return []byte("$-1\r\n")
+		}
+
+		return bulk(v)
+	case "SET":
+		s.data[args[1]] = args[2]
+		s.ttls[args[1]] = args[4]
+
+		return []byte("+OK\r\n")
+	case "DEL":
+		var n int
+
+		for _, k := range args[1:] {
+			if _, ok := s.data[k]; ok {
+				delete(s.data, k)
+				n++
+			}
+		}
+
+		return []byte(":" + strconv.Itoa(n) + "\r\n")
+	case "SCAN":
+		// returns all matching keys in a single page
+		var keys []byte
+		var n int
+
+		for k := range s.data {
+			if ok, _ := path.Match(args[3], k); ok {
+				keys = append(keys, bulk(k)...)
+				n++
+			}
+		}
+
+		return append([]byte("*2\r\n$1\r\n0\r\n*"+strconv.Itoa(n)+"\r\n"), keys...)
+	}
+
+	return []byte("-ERR unknown command '" + args[0] + "'\r\n")
+}
+
+func bulk(v string) []byte {
+	return []byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n")
+}
+
+func TestCache(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr())
+	)
+
+	defer cache.Close()
+
+	_, ok, err := cache.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value\r\nwith newline"), time.Minute))
+	assert.Equal(t, "60000", server.ttls["flipt:key"])
+
+	value, ok, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value\r\nwith newline"), value)
+
+	require.NoError(t, cache.Delete(ctx, "key"))
+
+	_, ok, err = cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheZeroTTL(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr())
+	)
+
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value"), 0))
+	assert.Empty(t, server.data)
+}
+
+func TestCacheFlush(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr(), WithPrefix("app:"))
+	)
+
+	defer cache.Close()
+
+	server.data["other:key"] = "untouched"
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), time.Minute))
+
+	require.NoError(t, cache.Flush(ctx))
+
+	assert.Equal(t, map[string]string{"other:key": "untouched"}, server.data)
+}
+
+func TestCacheAuth(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "secret")
+	)
+
+	cache := New(server.addr(), WithPassword("secret"), WithDB(2))
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value"), time.Minute))
+
+	wrong := New(server.addr(), WithPassword("wrong"))
+	defer wrong.Close()
+
+	_, _, err := wrong.Get(ctx, "key")
+	assert.EqualError(t, err, "redis: authenticating: redis: WRONGPASS invalid password")
+}
+
+func TestCacheUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cache := New(addr)
+	defer cache.Close()
+
+	_, _, err = cache.Get(context.Background(), "key")
+	assert.ErrorContains(t, err, "redis: connecting")
+}
+
+func TestCacheReusesConnections(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr(), WithPoolSize(1))
+	)
+
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value"), time.Minute))
+	assert.Len(t, cache.idle, 1)
+
+	_, _, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Len(t, cache.idle, 1)
+}
+
+func TestCacheDeletePrefix(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr())
+	)
+
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "boolean/default/flag/abc", []byte("1"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "boolean/default/flag/def", []byte("2"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "boolean/default/flag-other/abc", []byte("3"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "boolean/default/fla*/abc", []byte("4"), time.Minute))
+
+	require.NoError(t, cache.DeletePrefix(ctx, "boolean/default/flag/"))
+	require.NoError(t, cache.DeletePrefix(ctx, "boolean/default/fla*/"))
+
+	assert.Equal(t, map[string]string{"flipt:boolean/default/flag-other/abc": "3"}, server.data)
+}
+
+func TestCacheTLS(t *testing.T) {
+	var (
+		ctx               = context.Background()
+		server, tlsConfig = newFakeTLSServer(t)
+		cache             = New(server.addr(), WithTLSConfig(tlsConfig))
+	)
+
+	defer cache.Close()
+
+	require.NoError(t, cache.Set(ctx, "key", []byte("value"), time.Minute))
+
+	value, ok, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+
+	untrusted := New(server.addr(), WithTLSConfig(&tls.Config{}))
+	defer untrusted.Close()
+
+	_, _, err = untrusted.Get(ctx, "key")
+	assert.ErrorContains(t, err, "redis: connecting")
+}
+
+func TestCacheTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { ln.Close() })
+
+	// the server accepts connections but never replies
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			t.Cleanup(func() { c.Close() })
+		}
+	}()
+
+	cache := New(ln.Addr().String(), WithTimeout(50*time.Millisecond))
+	defer cache.Close()
+
+	_, _, err = cache.Get(context.Background(), "key")
+
+	var nerr net.Error
+	require.ErrorAs(t, err, &nerr)
+	assert.True(t, nerr.Timeout())
+	assert.Empty(t, cache.slots, "the connection should be discarded")
+}
+
+func TestCachePoolSize(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr(), WithPoolSize(1), WithTimeout(50*time.Millisecond))
+	)
+
+	defer cache.Close()
+
+	// the only connection is busy
+	cn, err := cache.get(ctx)
+	require.NoError(t, err)
+
+	_, _, err = cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	cache.put(cn)
+
+	_, _, err = cache.Get(ctx, "key")
+	assert.NoError(t, err)
+}
+
+func TestCacheCloseInUse(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		server = newFakeServer(t, "")
+		cache  = New(server.addr())
+	)
+
+	cn, err := cache.get(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Close())
+	require.NoError(t, cache.Close())
+
+	// connections returned after Close are closed rather than pooled
+	cache.put(cn)
+	assert.Empty(t, cache.idle)
+	assert.Empty(t, cache.slots)
+
+	_, _, err = cache.Get(ctx, "key")
+	assert.NoError(t, err)
+}
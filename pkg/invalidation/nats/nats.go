@@ -0,0 +1,284 @@
+// Package nats provides a flipt.Invalidator broadcasting invalidations over a
+// NATS subject, so that a fleet of provider instances invalidates its caches
+// whenever one of them detects a flag change.
+//
+// It speaks the NATS client protocol directly and does not depend on a NATS
+// client library.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+var _ flipt.Invalidator = (*Invalidator)(nil)
+
+// Option is a configuration option for the Invalidator.
+type Option func(*Invalidator)
+
+// WithCredentials authenticates with the NATS server using user and password.
+func WithCredentials(user, password string) Option {
+	return func(i *Invalidator) {
+		i.user = user
+		i.password = password
+	}
+}
+
+// WithToken authenticates with the NATS server using token.
+func WithToken(token string) Option {
+	return func(i *Invalidator) {
+		i.token = token
+	}
+}
+
+// Invalidator is a flipt.Invalidator broadcasting invalidations over a NATS
+// subject.
+type Invalidator struct {
+	addr     string
+	subject  string
+	user     string
+	password string
+	token    string
+	dialer   net.Dialer
+}
+
+// NewInvalidator returns an Invalidator publishing to subject on the NATS
+// server at addr, e.g. "localhost:4222".
+func NewInvalidator(addr, subject string, opts ...Option) *Invalidator {
+	i := &Invalidator{
+		addr:    addr,
+		subject: subject,
+		dialer:  net.Dialer{Timeout: defaultDialTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Publish implements flipt.Invalidator. Invalidations are rare, so each is
+// published over a connection of its own which is closed once the server
+// acknowledged it.
+func (i *Invalidator) Publish(ctx context.Context, inv flipt.Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+
+	cn, err := i.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer cn.Close()
+
+	// the server processes commands in order, so its reply to PING confirms
+	// the message was published
+	if _, err := fmt.Fprintf(cn, "PUB %s %d\r\n%s\r\nPING\r\n", i.subject, len(payload), payload); err != nil {
+		return fmt.Errorf("nats: publishing: %w", err)
+	}
+
+	if err := cn.awaitPong(); err != nil {
+		return fmt.Errorf("nats: publishing: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe implements flipt.Invalidator. Messages which cannot be decoded
+// are skipped.
+func (i *Invalidator) Subscribe(ctx context.Context, subscribed func(), fn func(flipt.Invalidation)) error {
+	cn, err := i.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer cn.Close()
+
+	// the connection is blocked reading messages until ctx is done
+	stop := context.AfterFunc(ctx, func() { cn.Close() })
+	defer stop()
+
+	if err := cn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	// the server processes commands in order, so its reply to PING confirms
+	// the subscription
+	if _, err := fmt.Fprintf(cn, "SUB %s 1\r\nPING\r\n", i.subject); err != nil {
+		return subscriptionError(ctx, err)
+	}
+
+	for pending := true; ; {
+		line, err := cn.readLine()
+		if err != nil {
+			return subscriptionError(ctx, err)
+		}
+
+		switch op, args, _ := strings.Cut(line, " "); strings.ToUpper(op) {
+		case "PING":
+			if _, err := io.WriteString(cn, "PONG\r\n"); err != nil {
+				return subscriptionError(ctx, err)
+			}
+		case "PONG":
+			if pending {
+				pending = false
+				subscribed()
+			}
+		case "-ERR":
+			return fmt.Errorf("nats: %s", args)
+		case "MSG":
+			payload, err := cn.readPayload(args)
+			if err != nil {
+				return subscriptionError(ctx, err)
+			}
+
+			var inv flipt.Invalidation
+			if err := json.Unmarshal(payload, &inv); err == nil {
+				fn(inv)
+			}
+		}
+	}
+}
+
+// connect dials the server and completes the protocol handshake.
+func (i *Invalidator) connect(ctx context.Context) (*conn, error) {
+	nc, err := i.dialer.DialContext(ctx, "tcp", i.addr)
+	if err != nil {
+		return nil, fmt.Errorf("nats: connecting: %w", err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	deadline, _ := ctx.Deadline()
+	if err := cn.SetDeadline(deadline); err != nil {
+		cn.Close()
+		return nil, err
+	}
+
+	if err := i.handshake(cn); err != nil {
+		cn.Close()
+		return nil, fmt.Errorf("nats: connecting: %w", err)
+	}
+
+	return cn, nil
+}
+
+func (i *Invalidator) handshake(cn *conn) error {
+	info, err := cn.readLine()
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(strings.ToUpper(info), "INFO ") {
+		return fmt.Errorf("unexpected greeting %q", info)
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "flipt-openfeature-provider",
+		"lang":     "go",
+	}
+
+	if i.user != "" {
+		options["user"] = i.user
+		options["pass"] = i.password
+	}
+
+	if i.token != "" {
+		options["auth_token"] = i.token
+	}
+
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+
+	return cn.awaitPong()
+}
+
+// subscriptionError returns nil when the subscription ended because ctx is
+// done, otherwise err.
+func subscriptionError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return fmt.Errorf("nats: subscription: %w", err)
+}
+
+// conn is a connection to a NATS server.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (cn *conn) readLine() (string, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// awaitPong reads until the server replies to a PING, failing on errors.
+func (cn *conn) awaitPong() error {
+	for {
+		line, err := cn.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch op, args, _ := strings.Cut(line, " "); strings.ToUpper(op) {
+		case "PONG":
+			return nil
+		case "-ERR":
+			return errors.New(args)
+		case "PING":
+			if _, err := io.WriteString(cn, "PONG\r\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readPayload reads the payload of a message given the arguments of its MSG
+// line: subject, sid, an optional reply subject and the payload size.
+func (cn *conn) readPayload(args string) ([]byte, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("malformed message %q", args)
+	}
+
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("malformed message %q", args)
+	}
+
+	payload := make([]byte, size+2)
+	if _, err := io.ReadFull(cn.r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload[:size], nil
+}
@@ -0,0 +1,178 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+// fakeServer is a NATS server supporting the subset of the protocol used by
+// the Invalidator.
+type fakeServer struct {
+	ln    net.Listener
+	token string
+
+	mu          sync.Mutex
+	subscribers map[string][]net.Conn
+}
+
+func newFakeServer(t *testing.T, token string) *fakeServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeServer{ln: ln, token: token, subscribers: map[string][]net.Conn{}}
+
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(c)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeServer) write(c net.Conn, format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(c, format, args...)
+}
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer c.Close()
+
+	s.write(c, "INFO {\"server_id\":\"fake\",\"auth_required\":%t}\r\n", s.token != "")
+
+	r := bufio.NewReader(c)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		op, args, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		switch op {
+		case "CONNECT":
+			var options struct {
+				Token string `json:"auth_token"`
+			}
+
+			if json.Unmarshal([]byte(args), &options) != nil || options.Token != s.token {
+				s.write(c, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+		case "PING":
+			s.write(c, "PONG\r\n")
+		case "SUB":
+			subject := strings.Fields(args)[0]
+
+			s.mu.Lock()
+			s.subscribers[subject] = append(s.subscribers[subject], c)
+			s.mu.Unlock()
+		case "PUB":
+			fields := strings.Fields(args)
+			size, _ := strconv.Atoi(fields[1])
+
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			for _, sub := range s.subscribers[fields[0]] {
+				fmt.Fprintf(sub, "MSG %s 1 %d\r\n%s", fields[0], size, payload)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *fakeServer) subscribed(subject string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers[subject])
+}
+
+func TestInvalidator(t *testing.T) {
+	var (
+		server        = newFakeServer(t, "secret")
+		invalidator   = NewInvalidator(server.addr(), "flipt.invalidations", WithToken("secret"))
+		invalidations = make(chan flipt.Invalidation, 1)
+		subscribed    = make(chan struct{})
+		done          = make(chan error)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		done <- invalidator.Subscribe(ctx, func() { close(subscribed) }, func(inv flipt.Invalidation) {
+			invalidations <- inv
+		})
+	}()
+
+	select {
+	case <-subscribed:
+		assert.Equal(t, 1, server.subscribed("flipt.invalidations"))
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not established")
+	}
+
+	// the server checks the connection is alive
+	server.mu.Lock()
+	fmt.Fprint(server.subscribers["flipt.invalidations"][0], "PING\r\n")
+	server.mu.Unlock()
+
+	require.NoError(t, invalidator.Publish(context.Background(), flipt.Invalidation{Namespace: "default", Flags: []string{"a", "b"}}))
+
+	select {
+	case inv := <-invalidations:
+		assert.Equal(t, flipt.Invalidation{Namespace: "default", Flags: []string{"a", "b"}}, inv)
+	case <-time.After(time.Second):
+		t.Fatal("invalidation was not received")
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("subscription did not stop")
+	}
+}
+
+func TestInvalidatorUnauthorized(t *testing.T) {
+	server := newFakeServer(t, "secret")
+
+	err := NewInvalidator(server.addr(), "flipt.invalidations", WithToken("wrong")).Publish(context.Background(), flipt.Invalidation{Namespace: "default"})
+	assert.EqualError(t, err, "nats: connecting: 'Authorization Violation'")
+
+	err = NewInvalidator(server.addr(), "flipt.invalidations").Subscribe(context.Background(), func() {}, func(flipt.Invalidation) {})
+	assert.EqualError(t, err, "nats: connecting: 'Authorization Violation'")
+}
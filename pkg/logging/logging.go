@@ -0,0 +1,108 @@
+// Package logging defines the Logger interface through which the provider and
+// transports log, along with adapters for common logging libraries.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Logger is a structured, leveled logger. Messages are accompanied by
+// alternating keys and values. *slog.Logger implements Logger.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// Nop returns a Logger which discards all messages.
+func Nop() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Debug(string, ...interface{}) {}
+func (nop) Info(string, ...interface{})  {}
+func (nop) Warn(string, ...interface{})  {}
+func (nop) Error(string, ...interface{}) {}
+
+// Logr adapts a logr.Logger. Debug messages are logged at verbosity 1 and
+// warnings at verbosity 0.
+func Logr(l logr.Logger) Logger {
+	return logrLogger{l: l}
+}
+
+type logrLogger struct {
+	l logr.Logger
+}
+
+func (l logrLogger) Debug(msg string, kv ...interface{}) { l.l.V(1).Info(msg, kv...) }
+func (l logrLogger) Info(msg string, kv ...interface{})  { l.l.Info(msg, kv...) }
+func (l logrLogger) Warn(msg string, kv ...interface{})  { l.l.Info(msg, kv...) }
+func (l logrLogger) Error(msg string, kv ...interface{}) { l.l.Error(nil, msg, kv...) }
+
+// SugaredLogger is implemented by *zap.SugaredLogger.
+type SugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// Zap adapts a zap sugared logger, e.g. zap.L().Sugar().
+func Zap(l SugaredLogger) Logger {
+	return zapLogger{l: l}
+}
+
+type zapLogger struct {
+	l SugaredLogger
+}
+
+func (l zapLogger) Debug(msg string, kv ...interface{}) { l.l.Debugw(msg, kv...) }
+func (l zapLogger) Info(msg string, kv ...interface{})  { l.l.Infow(msg, kv...) }
+func (l zapLogger) Warn(msg string, kv ...interface{})  { l.l.Warnw(msg, kv...) }
+func (l zapLogger) Error(msg string, kv ...interface{}) { l.l.Errorw(msg, kv...) }
+
+// Std adapts a standard library logger. Messages are written as
+// "LEVEL msg key=value ...". Debug messages are discarded unless debug is set.
+func Std(l *log.Logger, debug bool) Logger {
+	return stdLogger{l: l, debug: debug}
+}
+
+type stdLogger struct {
+	l     *log.Logger
+	debug bool
+}
+
+func (l stdLogger) Debug(msg string, kv ...interface{}) {
+	if l.debug {
+		l.print("DEBUG", msg, kv)
+	}
+}
+
+func (l stdLogger) Info(msg string, kv ...interface{})  { l.print("INFO", msg, kv) }
+func (l stdLogger) Warn(msg string, kv ...interface{})  { l.print("WARN", msg, kv) }
+func (l stdLogger) Error(msg string, kv ...interface{}) { l.print("ERROR", msg, kv) }
+
+func (l stdLogger) print(level, msg string, kv []interface{}) {
+	var b strings.Builder
+
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", kv[i])
+		}
+	}
+
+	l.l.Print(b.String())
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+func TestStd(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := Std(log.New(&buf, "", 0), false)
+	l.Debug("hidden")
+	l.Warn("flipt is unreachable", "address", "localhost:9000", "dangling")
+
+	assert.Equal(t, "WARN flipt is unreachable address=localhost:9000 dangling\n", buf.String())
+}
+
+func TestLogr(t *testing.T) {
+	var lines []string
+
+	l := Logr(funcr.New(func(prefix, args string) {
+		lines = append(lines, args)
+	}, funcr.Options{Verbosity: 1}))
+
+	l.Debug("debug", "flag", "foo")
+	l.Error("error")
+
+	assert.Equal(t, []string{`"level"=1 "msg"="debug" "flag"="foo"`, `"msg"="error" "error"=null`}, lines)
+}
+
+type sugared struct {
+	lines []string
+}
+
+func (s *sugared) Debugw(msg string, kv ...interface{}) { s.log("debug", msg, kv) }
+func (s *sugared) Infow(msg string, kv ...interface{})  { s.log("info", msg, kv) }
+func (s *sugared) Warnw(msg string, kv ...interface{})  { s.log("warn", msg, kv) }
+func (s *sugared) Errorw(msg string, kv ...interface{}) { s.log("error", msg, kv) }
+
+func (s *sugared) log(level, msg string, kv []interface{}) {
+	s.lines = append(s.lines, fmt.Sprint(level, " ", msg, " ", kv))
+}
+
+func TestZap(t *testing.T) {
+	s := &sugared{}
+
+	l := Zap(s)
+	l.Debug("debug", "flag", "foo")
+	l.Warn("warn")
+
+	assert.Equal(t, []string{"debug debug [flag foo]", "warn warn []"}, s.lines)
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxRateLimitedMessages bounds the number of distinct messages being rate
+// limited at once. Further messages are logged without rate limiting.
+const maxRateLimitedMessages = 1024
+
+// RateLimited returns a Logger logging each distinct warning or error, i.e.
+// with identical message, keys and values, at most once per interval, e.g.
+// the same transport error reported by every evaluation while Flipt is down.
+// Repeats within the interval are suppressed, and a summary of the form
+// "suppressed N similar messages: msg" is logged at the same level once it
+// elapses. Debug and info messages are not rate limited.
+func RateLimited(l Logger, interval time.Duration) Logger {
+	return &rateLimited{l: l, interval: interval, windows: map[string]*int{}}
+}
+
+type rateLimited struct {
+	l        Logger
+	interval time.Duration
+
+	mu sync.Mutex
+	// windows counts the suppressed repeats of the messages logged during
+	// the current interval, keyed by level, message, keys and values.
+	windows map[string]*int
+}
+
+func (r *rateLimited) Debug(msg string, kv ...interface{}) { r.l.Debug(msg, kv...) }
+func (r *rateLimited) Info(msg string, kv ...interface{})  { r.l.Info(msg, kv...) }
+func (r *rateLimited) Warn(msg string, kv ...interface{})  { r.log("WARN", r.l.Warn, msg, kv) }
+func (r *rateLimited) Error(msg string, kv ...interface{}) { r.log("ERROR", r.l.Error, msg, kv) }
+
+func (r *rateLimited) log(level string, logf func(string, ...interface{}), msg string, kv []interface{}) {
+	key := fmt.Sprint(level, "\x00", msg, "\x00", kv)
+
+	r.mu.Lock()
+	if suppressed, ok := r.windows[key]; ok {
+		*suppressed++
+		r.mu.Unlock()
+
+		return
+	}
+
+	if len(r.windows) < maxRateLimitedMessages {
+		r.windows[key] = new(int)
+		time.AfterFunc(r.interval, func() { r.summarize(key, logf, msg, kv) })
+	}
+	r.mu.Unlock()
+
+	logf(msg, kv...)
+}
+
+// summarize ends the interval of the message identified by key, logging how
+// many repeats were suppressed, if any.
+func (r *rateLimited) summarize(key string, logf func(string, ...interface{}), msg string, kv []interface{}) {
+	r.mu.Lock()
+	suppressed := *r.windows[key]
+	delete(r.windows, key)
+	r.mu.Unlock()
+
+	if suppressed > 0 {
+		logf(fmt.Sprintf("suppressed %d similar messages: %s", suppressed, msg), kv...)
+	}
+}
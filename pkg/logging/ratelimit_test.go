@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestRateLimited(t *testing.T) {
+	var buf syncBuffer
+
+	l := RateLimited(Std(log.New(&buf, "", 0), true), 50*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		l.Warn("flipt call failed", "error", "connection refused")
+		l.Debug("retrying")
+	}
+
+	l.Warn("flipt call failed", "error", "deadline exceeded")
+	l.Error("flipt call failed", "error", "connection refused")
+
+	assert.Equal(t, `WARN flipt call failed error=connection refused
+DEBUG retrying
+DEBUG retrying
+DEBUG retrying
+DEBUG retrying
+DEBUG retrying
+WARN flipt call failed error=deadline exceeded
+ERROR flipt call failed error=connection refused
+`, buf.String())
+
+	// the suppressed repeats are summarized once the interval elapses
+	assert.Eventually(t, func() bool {
+		return strings.HasSuffix(buf.String(), "WARN suppressed 4 similar messages: flipt call failed error=connection refused\n")
+	}, time.Second, 10*time.Millisecond)
+
+	// a new interval starts with the next repeat
+	l.Warn("flipt call failed", "error", "connection refused")
+
+	assert.Eventually(t, func() bool {
+		return strings.HasSuffix(buf.String(), "WARN flipt call failed error=connection refused\n")
+	}, time.Second, 10*time.Millisecond)
+}
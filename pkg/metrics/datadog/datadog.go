@@ -0,0 +1,189 @@
+// Package datadog provides a Hook sending evaluation counts, error counts and
+// backend latency of the Flipt provider to the Datadog Agent as DogStatsD
+// metrics, for teams standardized on Datadog rather than OpenTelemetry.
+//
+// It speaks the DogStatsD protocol over UDP directly and does not depend on
+// the Datadog client libraries. Evaluation spans are recorded by passing the
+// OpenTelemetry compatible tracer provider of dd-trace-go to the provider,
+// and the evaluated flags recorded on the spans of the application with the
+// span event hook:
+//
+//	tp := ddotel.NewTracerProvider()
+//	defer tp.Shutdown()
+//
+//	provider := flipt.NewProvider(
+//		flipt.WithTracerProvider(tp),
+//		flipt.WithSpanEventHook(),
+//		flipt.WithHooks(hook),
+//	)
+//
+// where ddotel is gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentelemetry.
+package datadog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+const (
+	defaultNamespace = "flipt.provider"
+	defaultAgentHost = "localhost"
+	defaultAgentPort = "8125"
+)
+
+var _ of.Hook = (*Hook)(nil)
+
+// Option is a configuration option for the Hook.
+type Option func(*Hook)
+
+// WithNamespace sets the prefix of the metric names, "flipt.provider" by
+// default.
+func WithNamespace(namespace string) Option {
+	return func(h *Hook) {
+		h.namespace = namespace
+	}
+}
+
+// WithTags adds tags of the form "key:value", e.g. "env:prod", to every
+// metric, in addition to the flag and reason or error code tags.
+func WithTags(tags ...string) Option {
+	return func(h *Hook) {
+		for _, tag := range tags {
+			h.tags = append(h.tags, sanitize(tag))
+		}
+	}
+}
+
+// Hook is an OpenFeature hook sending metrics about evaluations to the
+// Datadog Agent. Register it with flipt.WithHooks:
+//
+//	hook, err := datadog.New("", datadog.WithTags("env:prod"))
+//	if err != nil {
+//		return err
+//	}
+//	defer hook.Close()
+//
+//	provider := flipt.NewProvider(flipt.WithHooks(hook))
+//
+// It sends the following metrics:
+//
+//   - evaluations (count), tagged by flag and reason
+//   - evaluation_errors (count), tagged by flag and code
+//   - backend_latency (timing in milliseconds), tagged by flag, for the
+//     evaluations which called Flipt
+type Hook struct {
+	of.UnimplementedHook
+
+	namespace string
+	tags      []string
+	conn      net.Conn
+}
+
+// New returns a Hook sending metrics to the DogStatsD server listening on
+// addr, a UDP "host:port" address. When addr is empty, the address is read
+// from the DD_AGENT_HOST and DD_DOGSTATSD_PORT environment variables, set by
+// the Datadog integrations, and defaults to localhost:8125.
+func New(addr string, opts ...Option) (*Hook, error) {
+	if addr == "" {
+		addr = agentAddress()
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to dogstatsd: %w", err)
+	}
+
+	h := &Hook{namespace: defaultNamespace, conn: conn}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h, nil
+}
+
+// agentAddress returns the address of the DogStatsD server of the local
+// Datadog Agent.
+func agentAddress() string {
+	host, port := os.Getenv("DD_AGENT_HOST"), os.Getenv("DD_DOGSTATSD_PORT")
+	if host == "" {
+		host = defaultAgentHost
+	}
+
+	if port == "" {
+		port = defaultAgentPort
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// Close closes the connection to the DogStatsD server.
+func (h *Hook) Close() error {
+	return h.conn.Close()
+}
+
+// After implements of.Hook, counting successful evaluations by flag and
+// reason and timing the time spent waiting on Flipt.
+func (h *Hook) After(_ context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, _ of.HookHints) error {
+	flag := "flag:" + sanitize(hookContext.FlagKey())
+
+	h.send("evaluations", "1", "c", flag, "reason:"+sanitize(string(details.Reason)))
+
+	// the latency is only known when Flipt was called
+	if latency, ok := details.FlagMetadata["backendLatencyMillis"].(float64); ok {
+		h.send("backend_latency", strconv.FormatFloat(latency, 'f', -1, 64), "ms", flag)
+	}
+
+	return nil
+}
+
+// Error implements of.Hook, counting failed evaluations by flag and error
+// code.
+func (h *Hook) Error(_ context.Context, hookContext of.HookContext, err error, _ of.HookHints) {
+	h.send("evaluation_errors", "1", "c", "flag:"+sanitize(hookContext.FlagKey()), "code:"+errorCode(err))
+}
+
+// send sends a single metric. Metrics are sent on a best effort basis: write
+// errors, e.g. while the agent is restarting, are ignored.
+func (h *Hook) send(name, value, typ string, tags ...string) {
+	var b strings.Builder
+
+	b.WriteString(h.namespace)
+	b.WriteByte('.')
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(typ)
+	b.WriteString("|#")
+	b.WriteString(strings.Join(append(tags, h.tags...), ","))
+
+	_, _ = h.conn.Write([]byte(b.String()))
+}
+
+// errorCode returns the OpenFeature error code of err.
+func errorCode(err error) string {
+	var rerr of.ResolutionError
+	if errors.As(err, &rerr) {
+		if code, _, ok := strings.Cut(rerr.Error(), ":"); ok {
+			return code
+		}
+	}
+
+	return string(of.GeneralCode)
+}
+
+// tagEscaper replaces the characters delimiting tags and metrics in the
+// DogStatsD protocol.
+var tagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func sanitize(tag string) string {
+	return tagEscaper.Replace(tag)
+}
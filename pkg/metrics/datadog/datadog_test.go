@@ -0,0 +1,174 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+	rpcflipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func hookContext(flag string) of.HookContext {
+	return of.NewHookContext(flag, of.String, "default", of.NewClientMetadata("test"), of.Metadata{Name: "flipt-provider"}, of.EvaluationContext{})
+}
+
+func TestHook(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = agent.Close() })
+
+	h, err := New(agent.LocalAddr().String(), WithTags("env:prod"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+
+	details := func(reason of.Reason, metadata of.FlagMetadata) of.InterfaceEvaluationDetails {
+		return of.InterfaceEvaluationDetails{EvaluationDetails: of.EvaluationDetails{
+			ResolutionDetail: of.ResolutionDetail{Reason: reason, FlagMetadata: metadata},
+		}}
+	}
+
+	ctx := context.Background()
+	require.NoError(t, h.After(ctx, hookContext("color"), details(of.TargetingMatchReason, of.FlagMetadata{"backendLatencyMillis": 20.5}), of.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext("a,b|c"), details(of.CachedReason, nil), of.HookHints{}))
+	h.Error(ctx, hookContext("missing"), fmt.Errorf("error code: %w", of.NewFlagNotFoundResolutionError("flag not found")), of.HookHints{})
+	h.Error(ctx, hookContext("missing"), context.Canceled, of.HookHints{})
+
+	var packets []string
+
+	buf := make([]byte, 1024)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+
+		n, _, err := agent.ReadFrom(buf)
+		require.NoError(t, err)
+
+		packets = append(packets, string(buf[:n]))
+	}
+
+	assert.Equal(t, []string{
+		"flipt.provider.evaluations:1|c|#flag:color,reason:TARGETING_MATCH,env:prod",
+		"flipt.provider.backend_latency:20.5|ms|#flag:color,env:prod",
+		"flipt.provider.evaluations:1|c|#flag:a_b_c,reason:CACHED,env:prod",
+		"flipt.provider.evaluation_errors:1|c|#flag:missing,code:FLAG_NOT_FOUND,env:prod",
+		"flipt.provider.evaluation_errors:1|c|#flag:missing,code:GENERAL,env:prod",
+	}, packets)
+}
+
+func TestNew_AgentAddress(t *testing.T) {
+	t.Setenv("DD_AGENT_HOST", "127.0.0.1")
+	t.Setenv("DD_DOGSTATSD_PORT", "9125")
+
+	h, err := New("", WithNamespace("checkout.flags"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+
+	assert.Equal(t, "127.0.0.1:9125", h.conn.RemoteAddr().String())
+	assert.Equal(t, "checkout.flags", h.namespace)
+}
+
+// enabledService serves every boolean flag as enabled.
+type enabledService struct{}
+
+func (enabledService) GetFlag(context.Context, string, string) (*rpcflipt.Flag, error) {
+	return nil, of.NewFlagNotFoundResolutionError("flag not found")
+}
+
+func (enabledService) Evaluate(context.Context, string, string, map[string]interface{}) (*evaluation.VariantEvaluationResponse, error) {
+	return nil, of.NewFlagNotFoundResolutionError("flag not found")
+}
+
+func (enabledService) Boolean(context.Context, string, string, map[string]interface{}) (*evaluation.BooleanEvaluationResponse, error) {
+	return &evaluation.BooleanEvaluationResponse{Enabled: true, Reason: evaluation.EvaluationReason_MATCH_EVALUATION_REASON}, nil
+}
+
+type recordedSpan struct {
+	trace.Span
+
+	name   string
+	events []string
+}
+
+func (s *recordedSpan) IsRecording() bool { return true }
+
+func (s *recordedSpan) AddEvent(name string, _ ...trace.EventOption) {
+	s.events = append(s.events, name)
+}
+
+type recordingTracerProvider struct {
+	trace.TracerProvider
+
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tp *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{tp: tp}
+}
+
+type recordingTracer struct {
+	trace.Tracer
+
+	tp *recordingTracerProvider
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordedSpan{Span: trace.SpanFromContext(context.Background()), name: name}
+
+	t.tp.mu.Lock()
+	t.tp.spans = append(t.tp.spans, span)
+	t.tp.mu.Unlock()
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// TestTracing checks the wiring of Example_tracing: spans are recorded with
+// the tracer provider, which is ddotel's in production, and metrics are
+// sent by the hook.
+func TestTracing(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = agent.Close() })
+
+	h, err := New(agent.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = h.Close() })
+
+	tp := &recordingTracerProvider{}
+
+	p := flipt.NewProvider(
+		flipt.WithService(enabledService{}),
+		flipt.WithTracerProvider(tp),
+		flipt.WithSpanEventHook(),
+		flipt.WithHooks(h),
+	)
+
+	ctx, request := tp.Tracer("test").Start(context.Background(), "request")
+
+	res := <-p.EvaluateAsync(ctx, "beta", false, of.FlattenedContext{})
+	require.Equal(t, true, res.Value)
+
+	var names []string
+	for _, span := range tp.spans {
+		names = append(names, span.name)
+	}
+
+	assert.Contains(t, names, "flipt.evaluation")
+	assert.Equal(t, []string{"feature_flag"}, request.(*recordedSpan).events)
+
+	require.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+
+	buf := make([]byte, 1024)
+	n, _, err := agent.ReadFrom(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, "flipt.provider.evaluations:1|c|#flag:beta,reason:TARGETING_MATCH", string(buf[:n]))
+}
@@ -0,0 +1,28 @@
+package datadog_test
+
+import (
+	"go.flipt.io/flipt-openfeature-provider/pkg/metrics/datadog"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The tracer provider stands in for ddotel.NewTracerProvider(), from
+// gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentelemetry, which sends the
+// spans of the provider to the Datadog Agent alongside the spans of the
+// application.
+func Example_tracing() {
+	tp := trace.NewNoopTracerProvider()
+
+	hook, err := datadog.New("")
+	if err != nil {
+		panic(err)
+	}
+	defer hook.Close()
+
+	_ = flipt.NewProvider(
+		flipt.WithAddress("localhost:9000"),
+		flipt.WithTracerProvider(tp),
+		flipt.WithSpanEventHook(),
+		flipt.WithHooks(hook),
+	)
+}
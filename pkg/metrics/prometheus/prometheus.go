@@ -0,0 +1,243 @@
+// Package prometheus provides a Handler recording evaluation totals, error
+// totals, backend latency and cache statistics of the Flipt provider, served
+// in the Prometheus text exposition format for teams not using OpenTelemetry.
+//
+// It writes the exposition format directly and does not depend on the
+// Prometheus client library: the Handler is an http.Handler to be scraped
+// alongside, or mounted next to, the handler of an existing registry. It
+// cannot be registered on a prometheus.Registry.
+package prometheus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+const (
+	defaultNamespace = "flipt_provider"
+	contentType      = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	_ of.Hook      = (*Handler)(nil)
+	_ http.Handler = (*Handler)(nil)
+)
+
+// Option is a configuration option for the Handler.
+type Option func(*Handler)
+
+// WithNamespace sets the prefix of the metric names, "flipt_provider" by
+// default.
+func WithNamespace(namespace string) Option {
+	return func(h *Handler) {
+		h.namespace = namespace
+	}
+}
+
+// Handler is an OpenFeature hook recording metrics about evaluations,
+// which it serves over HTTP. Register it with flipt.WithHooks:
+//
+//	metrics := prometheus.New()
+//	provider := flipt.NewProvider(flipt.WithHooks(metrics))
+//	metrics.ObserveCache(provider.CacheStats)
+//	http.Handle("/metrics/flipt", metrics)
+type Handler struct {
+	of.UnimplementedHook
+
+	namespace string
+
+	mu          sync.Mutex
+	evaluations map[[2]string]uint64
+	errors      map[[2]string]uint64
+	latency     map[string]*summary
+	cacheStats  func() flipt.CacheStats
+}
+
+// summary accumulates the observations of a summary without quantiles.
+type summary struct {
+	sum   float64
+	count uint64
+}
+
+// New returns a Handler configured by opts.
+func New(opts ...Option) *Handler {
+	h := &Handler{
+		namespace:   defaultNamespace,
+		evaluations: map[[2]string]uint64{},
+		errors:      map[[2]string]uint64{},
+		latency:     map[string]*summary{},
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ObserveCache reports the cache counters returned by stats, e.g.
+// Provider.CacheStats, whenever metrics are collected.
+func (h *Handler) ObserveCache(stats func() flipt.CacheStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cacheStats = stats
+}
+
+// After implements of.Hook, counting successful evaluations by flag and
+// reason and observing the time spent waiting on Flipt.
+func (h *Handler) After(_ context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, _ of.HookHints) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.evaluations[[2]string{hookContext.FlagKey(), string(details.Reason)}]++
+
+	// the latency is only known when Flipt was called
+	if latency, ok := details.FlagMetadata["backendLatencyMillis"].(float64); ok {
+		s, ok := h.latency[hookContext.FlagKey()]
+		if !ok {
+			s = &summary{}
+			h.latency[hookContext.FlagKey()] = s
+		}
+
+		s.sum += latency / 1000
+		s.count++
+	}
+
+	return nil
+}
+
+// Error implements of.Hook, counting failed evaluations by flag and error
+// code.
+func (h *Handler) Error(_ context.Context, hookContext of.HookContext, err error, _ of.HookHints) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.errors[[2]string{hookContext.FlagKey(), errorCode(err)}]++
+}
+
+// errorCode returns the OpenFeature error code of err.
+func errorCode(err error) string {
+	var rerr of.ResolutionError
+	if errors.As(err, &rerr) {
+		if code, _, ok := strings.Cut(rerr.Error(), ":"); ok {
+			return code
+		}
+	}
+
+	return string(of.GeneralCode)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+
+	_ = h.Write(w)
+}
+
+// Write writes the metrics to w in the Prometheus text exposition format.
+func (h *Handler) Write(w io.Writer) error {
+	h.mu.Lock()
+	var (
+		evaluations = sortedSamples(h.evaluations)
+		errs        = sortedSamples(h.errors)
+		latency     = make(map[string]summary, len(h.latency))
+		cacheStats  = h.cacheStats
+	)
+
+	for flag, s := range h.latency {
+		latency[flag] = *s
+	}
+	h.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	h.header(bw, "evaluations_total", "counter", "Number of successful flag evaluations.")
+	for _, s := range evaluations {
+		fmt.Fprintf(bw, "%s_evaluations_total{flag=%s,reason=%s} %d\n", h.namespace, quote(s.labels[0]), quote(s.labels[1]), s.value)
+	}
+
+	h.header(bw, "evaluation_errors_total", "counter", "Number of failed flag evaluations.")
+	for _, s := range errs {
+		fmt.Fprintf(bw, "%s_evaluation_errors_total{flag=%s,code=%s} %d\n", h.namespace, quote(s.labels[0]), quote(s.labels[1]), s.value)
+	}
+
+	h.header(bw, "backend_latency_seconds", "summary", "Time spent waiting on Flipt per flag evaluation.")
+	for _, flag := range sortedKeys(latency) {
+		fmt.Fprintf(bw, "%s_backend_latency_seconds_sum{flag=%s} %g\n", h.namespace, quote(flag), latency[flag].sum)
+		fmt.Fprintf(bw, "%s_backend_latency_seconds_count{flag=%s} %d\n", h.namespace, quote(flag), latency[flag].count)
+	}
+
+	if cacheStats != nil {
+		stats := cacheStats()
+
+		h.header(bw, "cache_lookups_total", "counter", "Number of flag evaluations looked up in the cache by result.")
+		fmt.Fprintf(bw, "%s_cache_lookups_total{result=\"hit\"} %d\n", h.namespace, stats.Hits)
+		fmt.Fprintf(bw, "%s_cache_lookups_total{result=\"miss\"} %d\n", h.namespace, stats.Misses)
+		fmt.Fprintf(bw, "%s_cache_lookups_total{result=\"stale\"} %d\n", h.namespace, stats.StaleServes)
+
+		h.header(bw, "cache_evictions_total", "counter", "Number of cached flag evaluations evicted before they expired.")
+		fmt.Fprintf(bw, "%s_cache_evictions_total %d\n", h.namespace, stats.Evictions)
+
+		h.header(bw, "cache_evicted_bytes_total", "counter", "Approximate size in bytes of the cached flag evaluations evicted before they expired.")
+		fmt.Fprintf(bw, "%s_cache_evicted_bytes_total %d\n", h.namespace, stats.EvictedBytes)
+	}
+
+	return bw.Flush()
+}
+
+func (h *Handler) header(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", h.namespace, name, help, h.namespace, name, typ)
+}
+
+type sample struct {
+	labels [2]string
+	value  uint64
+}
+
+// sortedSamples returns the samples of counters ordered by labels, so that
+// the output is stable across scrapes.
+func sortedSamples(counters map[[2]string]uint64) []sample {
+	samples := make([]sample, 0, len(counters))
+	for labels, value := range counters {
+		samples = append(samples, sample{labels: labels, value: value})
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].labels[0] != samples[j].labels[0] {
+			return samples[i].labels[0] < samples[j].labels[0]
+		}
+
+		return samples[i].labels[1] < samples[j].labels[1]
+	})
+
+	return samples
+}
+
+func sortedKeys(m map[string]summary) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote returns value as a quoted label value.
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
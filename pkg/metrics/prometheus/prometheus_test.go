@@ -0,0 +1,93 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/provider/flipt"
+)
+
+func hookContext(flag string) of.HookContext {
+	return of.NewHookContext(flag, of.String, "default", of.NewClientMetadata("test"), of.Metadata{Name: "flipt-provider"}, of.EvaluationContext{})
+}
+
+func TestHandler(t *testing.T) {
+	h := New()
+
+	details := func(reason of.Reason, metadata of.FlagMetadata) of.InterfaceEvaluationDetails {
+		return of.InterfaceEvaluationDetails{EvaluationDetails: of.EvaluationDetails{
+			ResolutionDetail: of.ResolutionDetail{Reason: reason, FlagMetadata: metadata},
+		}}
+	}
+
+	ctx := context.Background()
+	require.NoError(t, h.After(ctx, hookContext("color"), details(of.TargetingMatchReason, of.FlagMetadata{"backendLatencyMillis": 20.0}), of.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext("color"), details(of.TargetingMatchReason, of.FlagMetadata{"backendLatencyMillis": 30.0}), of.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext("color"), details(of.CachedReason, nil), of.HookHints{}))
+	require.NoError(t, h.After(ctx, hookContext(`say "hi"`), details(of.DefaultReason, nil), of.HookHints{}))
+
+	h.Error(ctx, hookContext("missing"), fmt.Errorf("error code: %w", of.NewFlagNotFoundResolutionError("flag not found")), of.HookHints{})
+	h.Error(ctx, hookContext("missing"), fmt.Errorf("before hook: %w", context.Canceled), of.HookHints{})
+
+	h.ObserveCache(func() flipt.CacheStats {
+		return flipt.CacheStats{Hits: 4, Misses: 2, StaleServes: 1, Evictions: 3, EvictedBytes: 512}
+	})
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	var body bytes.Buffer
+	_, err = body.ReadFrom(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# HELP flipt_provider_evaluations_total Number of successful flag evaluations.
+# TYPE flipt_provider_evaluations_total counter
+flipt_provider_evaluations_total{flag="color",reason="CACHED"} 1
+flipt_provider_evaluations_total{flag="color",reason="TARGETING_MATCH"} 2
+flipt_provider_evaluations_total{flag="say \"hi\"",reason="DEFAULT"} 1
+# HELP flipt_provider_evaluation_errors_total Number of failed flag evaluations.
+# TYPE flipt_provider_evaluation_errors_total counter
+flipt_provider_evaluation_errors_total{flag="missing",code="FLAG_NOT_FOUND"} 1
+flipt_provider_evaluation_errors_total{flag="missing",code="GENERAL"} 1
+# HELP flipt_provider_backend_latency_seconds Time spent waiting on Flipt per flag evaluation.
+# TYPE flipt_provider_backend_latency_seconds summary
+flipt_provider_backend_latency_seconds_sum{flag="color"} 0.05
+flipt_provider_backend_latency_seconds_count{flag="color"} 2
+# HELP flipt_provider_cache_lookups_total Number of flag evaluations looked up in the cache by result.
+# TYPE flipt_provider_cache_lookups_total counter
+flipt_provider_cache_lookups_total{result="hit"} 4
+flipt_provider_cache_lookups_total{result="miss"} 2
+flipt_provider_cache_lookups_total{result="stale"} 1
+# HELP flipt_provider_cache_evictions_total Number of cached flag evaluations evicted before they expired.
+# TYPE flipt_provider_cache_evictions_total counter
+flipt_provider_cache_evictions_total 3
+# HELP flipt_provider_cache_evicted_bytes_total Approximate size in bytes of the cached flag evaluations evicted before they expired.
+# TYPE flipt_provider_cache_evicted_bytes_total counter
+flipt_provider_cache_evicted_bytes_total 512
+`, body.String())
+}
+
+func TestHandler_WithNamespace(t *testing.T) {
+	h := New(WithNamespace("flags"))
+
+	require.NoError(t, h.After(context.Background(), hookContext("color"), of.InterfaceEvaluationDetails{}, of.HookHints{}))
+
+	var body bytes.Buffer
+	require.NoError(t, h.Write(&body))
+
+	assert.Contains(t, body.String(), "\nflags_evaluations_total{flag=\"color\",reason=\"\"} 1\n")
+	assert.NotContains(t, body.String(), "cache_lookups_total")
+}
@@ -0,0 +1,205 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// Result is the outcome of an asynchronous flag evaluation.
+type Result struct {
+	Value interface{}
+	of.ProviderResolutionDetail
+}
+
+// EvaluateAsync starts resolving a flag in the background and returns a
+// channel which receives exactly one Result before being closed.
+//
+// The type of the evaluation is chosen based on the type of defaultValue:
+// bool, string, float64 and int64 (or int) values are evaluated as their
+// respective flag types, anything else is evaluated as an object. Result
+// values have the type of defaultValue for these flag types.
+//
+// The hooks returned from Hooks run around the evaluation as they would for
+// an evaluation made through an OpenFeature client, the evaluation context
+// returned from Before hooks being merged into evalCtx.
+func (p *Provider) EvaluateAsync(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) <-chan Result {
+	ch := make(chan Result, 1)
+
+	go func() {
+		defer close(ch)
+
+		ch <- p.evaluate(ctx, flag, defaultValue, evalCtx)
+	}()
+
+	return ch
+}
+
+func (p *Provider) evaluate(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) Result {
+	hooks := p.Hooks()
+	hints := of.NewHookHints(nil)
+	hookContext := newHookContext(flag, defaultValue, p.Metadata(), evaluationContext(evalCtx))
+
+	defer func() {
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i].Finally(ctx, hookContext, hints)
+		}
+	}()
+
+	for _, hook := range hooks {
+		merged, err := hook.Before(ctx, hookContext, hints)
+		if err != nil {
+			return hookError(ctx, hooks, hookContext, hints, err)
+		}
+
+		if merged != nil {
+			hookContext = newHookContext(flag, defaultValue, p.Metadata(), mergeEvaluationContexts(hookContext.EvaluationContext(), *merged))
+		}
+	}
+
+	result := resolve(ctx, p, flag, defaultValue, flattenedContext(hookContext.EvaluationContext()))
+	if err := result.Error(); err != nil {
+		runErrorHooks(ctx, hooks, hookContext, hints, err)
+		return result
+	}
+
+	details := of.InterfaceEvaluationDetails{
+		Value: result.Value,
+		EvaluationDetails: of.EvaluationDetails{
+			FlagKey:          flag,
+			FlagType:         hookContext.FlagType(),
+			ResolutionDetail: result.ResolutionDetail(),
+		},
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].After(ctx, hookContext, details, hints); err != nil {
+			return hookError(ctx, hooks, hookContext, hints, err)
+		}
+	}
+
+	return result
+}
+
+// hookError runs the error hooks for a hook failing the evaluation and
+// returns the default value of the evaluation with err as its resolution
+// error.
+func hookError(ctx context.Context, hooks []of.Hook, hookContext of.HookContext, hints of.HookHints, err error) Result {
+	runErrorHooks(ctx, hooks, hookContext, hints, err)
+
+	var resErr of.ResolutionError
+	if !errors.As(err, &resErr) {
+		resErr = of.NewGeneralResolutionError(err.Error())
+	}
+
+	return Result{
+		Value: hookContext.DefaultValue(),
+		ProviderResolutionDetail: of.ProviderResolutionDetail{
+			ResolutionError: resErr,
+			Reason:          of.ErrorReason,
+		},
+	}
+}
+
+func runErrorHooks(ctx context.Context, hooks []of.Hook, hookContext of.HookContext, hints of.HookHints, err error) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].Error(ctx, hookContext, err, hints)
+	}
+}
+
+// newHookContext returns the context of the hooks run for an evaluation of
+// flag with the type of defaultValue.
+func newHookContext(flag string, defaultValue interface{}, provider of.Metadata, evalCtx of.EvaluationContext) of.HookContext {
+	flagType := of.Object
+
+	switch defaultValue.(type) {
+	case bool:
+		flagType = of.Boolean
+	case string:
+		flagType = of.String
+	case float64:
+		flagType = of.Float
+	case int64, int:
+		flagType = of.Int
+	}
+
+	return of.NewHookContext(flag, flagType, defaultValue, of.NewClientMetadata(""), provider, evalCtx)
+}
+
+// evaluationContext converts a flattened evaluation context, as passed to
+// providers, back into the evaluation context hooks are given.
+func evaluationContext(evalCtx of.FlattenedContext) of.EvaluationContext {
+	var targetingKey string
+
+	attributes := make(map[string]interface{}, len(evalCtx))
+	for k, v := range evalCtx {
+		if k == of.TargetingKey {
+			targetingKey, _ = v.(string)
+			continue
+		}
+
+		attributes[k] = v
+	}
+
+	return of.NewEvaluationContext(targetingKey, attributes)
+}
+
+// flattenedContext is the inverse of evaluationContext.
+func flattenedContext(evalCtx of.EvaluationContext) of.FlattenedContext {
+	flat := of.FlattenedContext{}
+	for k, v := range evalCtx.Attributes() {
+		flat[k] = v
+	}
+
+	if targetingKey := evalCtx.TargetingKey(); targetingKey != "" {
+		flat[of.TargetingKey] = targetingKey
+	}
+
+	return flat
+}
+
+// mergeEvaluationContexts returns base overridden by the targeting key, when
+// set, and the attributes of override.
+func mergeEvaluationContexts(base, override of.EvaluationContext) of.EvaluationContext {
+	targetingKey := base.TargetingKey()
+	if override.TargetingKey() != "" {
+		targetingKey = override.TargetingKey()
+	}
+
+	attributes := map[string]interface{}{}
+	for k, v := range base.Attributes() {
+		attributes[k] = v
+	}
+
+	for k, v := range override.Attributes() {
+		attributes[k] = v
+	}
+
+	return of.NewEvaluationContext(targetingKey, attributes)
+}
+
+// resolve evaluates flag against provider using the evaluation type matching
+// the type of defaultValue.
+func resolve(ctx context.Context, provider of.FeatureProvider, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) Result {
+	switch v := defaultValue.(type) {
+	case bool:
+		detail := provider.BooleanEvaluation(ctx, flag, v, evalCtx)
+		return Result{Value: detail.Value, ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	case string:
+		detail := provider.StringEvaluation(ctx, flag, v, evalCtx)
+		return Result{Value: detail.Value, ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	case float64:
+		detail := provider.FloatEvaluation(ctx, flag, v, evalCtx)
+		return Result{Value: detail.Value, ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	case int64:
+		detail := provider.IntEvaluation(ctx, flag, v, evalCtx)
+		return Result{Value: detail.Value, ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	case int:
+		detail := provider.IntEvaluation(ctx, flag, int64(v), evalCtx)
+		return Result{Value: int(detail.Value), ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	default:
+		detail := provider.ObjectEvaluation(ctx, flag, v, evalCtx)
+		return Result{Value: detail.Value, ProviderResolutionDetail: detail.ProviderResolutionDetail}
+	}
+}
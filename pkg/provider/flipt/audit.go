@@ -0,0 +1,180 @@
+package flipt
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// EvaluationEvent records the outcome of a single flag evaluation.
+type EvaluationEvent struct {
+	Time      time.Time
+	Namespace string
+	Flag      string
+	// Entity is the targeting key of the evaluation context, redacted when
+	// sensitive (see WithRedactedKeys and WithRedactFunc).
+	Entity string
+	// Value is the value returned; Variant is only set for object flags, as
+	// the value of string flags is the variant key.
+	Value     interface{}
+	Variant   string
+	Reason    of.Reason
+	ErrorCode of.ErrorCode
+	// Error is the resolution error of failed evaluations.
+	Error error
+	// Duration is the time the provider took to evaluate the flag.
+	Duration time.Duration
+}
+
+// AuditSink receives an event for every flag evaluation, e.g. to persist
+// which entity was served which variant. Record is called synchronously on
+// the evaluation path; slow sinks should be wrapped with NewAsyncAuditSink.
+type AuditSink interface {
+	Record(EvaluationEvent)
+}
+
+// WithAuditSink sets the sink receiving an event for every flag evaluation.
+func WithAuditSink(sink AuditSink) Option {
+	return func(p *Provider) {
+		p.config.AuditSink = sink
+	}
+}
+
+// evaluationCallbackBufferSize is the number of events buffered for the
+// evaluation callback.
+const evaluationCallbackBufferSize = 1024
+
+// WithEvaluationCallback sets a function called with an event for every flag
+// evaluation, a lighter-weight alternative to an OpenFeature hook or an
+// AuditSink. The function is called from a background goroutine, so that
+// evaluations never wait on it; events are dropped when it falls behind by
+// more than 1024 events. Buffered events are flushed by Close.
+func WithEvaluationCallback(callback func(EvaluationEvent)) Option {
+	return func(p *Provider) {
+		p.config.EvaluationCallback = callback
+	}
+}
+
+// auditSinkFunc adapts a function to an AuditSink.
+type auditSinkFunc func(EvaluationEvent)
+
+func (f auditSinkFunc) Record(e EvaluationEvent) { f(e) }
+
+// audit records the outcome of evaluating flag, which started at start, to
+// the audit sink and evaluation callback, if any.
+func (p *Provider) audit(flag string, value interface{}, detail of.ProviderResolutionDetail, evalCtx of.FlattenedContext, start time.Time) {
+	if p.config.AuditSink == nil && p.callbacks == nil {
+		return
+	}
+
+	code := detail.ResolutionDetail().ErrorCode
+	if code == "" && !p.sampleSuccess() {
+		return
+	}
+
+	entity, _ := evalCtx[of.TargetingKey].(string)
+	if entity != "" {
+		entity = fmt.Sprint(p.redactValue(of.TargetingKey, entity))
+	}
+
+	event := EvaluationEvent{
+		Time:      time.Now(),
+		Namespace: p.config.Namespace,
+		Flag:      flag,
+		Entity:    entity,
+		Value:     value,
+		Variant:   detail.Variant,
+		Reason:    detail.Reason,
+		ErrorCode: code,
+		Duration:  time.Since(start),
+	}
+
+	if code != "" {
+		event.Error = detail.ResolutionError
+	}
+
+	if p.config.AuditSink != nil {
+		p.config.AuditSink.Record(event)
+	}
+
+	if p.callbacks != nil {
+		p.callbacks.Record(event)
+	}
+}
+
+// AsyncAuditSink is an AuditSink buffering events and dispatching them to
+// another sink from a background goroutine, so that evaluations never wait
+// on it. Events are dropped when the buffer is full.
+type AsyncAuditSink struct {
+	sink    AuditSink
+	events  chan EvaluationEvent
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncAuditSink returns an AsyncAuditSink dispatching to sink, buffering
+// up to size events. It must be closed to flush buffered events once the
+// provider has been shut down.
+func NewAsyncAuditSink(sink AuditSink, size int) *AsyncAuditSink {
+	s := &AsyncAuditSink{
+		sink:   sink,
+		events: make(chan EvaluationEvent, size),
+		done:   make(chan struct{}),
+	}
+
+	go s.dispatch()
+
+	return s
+}
+
+// Record buffers the event, dropping it when the buffer is full or the sink
+// is closed.
+func (s *AsyncAuditSink) Record(e EvaluationEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+
+	select {
+	case s.events <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of events which were dropped.
+func (s *AsyncAuditSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting events and waits for buffered events to be
+// dispatched. It is safe to call more than once.
+func (s *AsyncAuditSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+
+	return nil
+}
+
+func (s *AsyncAuditSink) dispatch() {
+	defer close(s.done)
+
+	for e := range s.events {
+		s.sink.Record(e)
+	}
+}
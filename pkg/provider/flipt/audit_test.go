@@ -0,0 +1,126 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	events  []EvaluationEvent
+	release chan struct{}
+}
+
+func (s *recordingSink) Record(e EvaluationEvent) {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, e)
+}
+
+func TestAuditSink(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+
+	sink := &recordingSink{}
+	p := NewProvider(WithService(mockSvc), WithAuditSink(sink), WithForcedDefaults("default/forced"))
+
+	p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user-1"})
+	p.BooleanEvaluation(context.Background(), "forced", false, of.FlattenedContext{of.TargetingKey: "user-2"})
+
+	require.Len(t, sink.events, 2)
+
+	assert.False(t, sink.events[0].Time.IsZero())
+	assert.Positive(t, sink.events[0].Duration)
+	sink.events[0].Time, sink.events[0].Duration = sink.events[1].Time, 0
+	assert.Equal(t, EvaluationEvent{
+		Time:      sink.events[1].Time,
+		Namespace: "default",
+		Flag:      "flag",
+		Entity:    "user-1",
+		Value:     "blue",
+		Reason:    of.TargetingMatchReason,
+	}, sink.events[0])
+
+	assert.Equal(t, "forced", sink.events[1].Flag)
+	assert.Equal(t, "user-2", sink.events[1].Entity)
+	assert.Equal(t, false, sink.events[1].Value)
+	assert.Equal(t, of.DisabledReason, sink.events[1].Reason)
+}
+
+func TestWithEvaluationCallback(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+	mockSvc.On("Boolean", mock.Anything, "default", "missing", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found"))
+
+	var (
+		mu     sync.Mutex
+		events []EvaluationEvent
+	)
+
+	p := NewProvider(WithService(mockSvc), WithEvaluationCallback(func(e EvaluationEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events = append(events, e)
+	}))
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user-1"})
+	p.WithStaticContext(map[string]interface{}{"service": "checkout"}).BooleanEvaluation(context.Background(), "missing", false, of.FlattenedContext{of.TargetingKey: "user-2"})
+
+	// Close flushes the buffered events
+	require.NoError(t, p.Close())
+
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "flag", events[0].Flag)
+	assert.Equal(t, "user-1", events[0].Entity)
+	assert.Equal(t, true, events[0].Value)
+	assert.Equal(t, of.TargetingMatchReason, events[0].Reason)
+	assert.NoError(t, events[0].Error)
+	assert.Positive(t, events[0].Duration)
+
+	assert.Equal(t, "missing", events[1].Flag)
+	assert.Equal(t, of.FlagNotFoundCode, events[1].ErrorCode)
+	assert.EqualError(t, events[1].Error, "FLAG_NOT_FOUND: flag not found")
+}
+
+func TestAsyncAuditSink(t *testing.T) {
+	sink := &recordingSink{release: make(chan struct{})}
+	async := NewAsyncAuditSink(sink, 1)
+
+	// the first event is held by the dispatcher, the second is buffered and
+	// the third is dropped
+	async.Record(EvaluationEvent{Flag: "1"})
+	assert.Eventually(t, func() bool { return len(async.events) == 0 }, time.Second, time.Millisecond)
+	async.Record(EvaluationEvent{Flag: "2"})
+	async.Record(EvaluationEvent{Flag: "3"})
+
+	close(sink.release)
+	require.NoError(t, async.Close())
+	require.NoError(t, async.Close())
+
+	async.Record(EvaluationEvent{Flag: "4"})
+
+	assert.Equal(t, []EvaluationEvent{{Flag: "1"}, {Flag: "2"}}, sink.events)
+	assert.Equal(t, uint64(2), async.Dropped())
+}
@@ -0,0 +1,204 @@
+package flipt
+
+import (
+	"sync"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// errBreakerOpen is returned for evaluations short-circuited by an open
+// circuit breaker.
+var errBreakerOpen = of.NewProviderNotReadyResolutionError("flipt circuit breaker is open")
+
+// WithCircuitBreaker stops calling Flipt for openDuration after threshold
+// consecutive calls failed because Flipt is unreachable or erroring, so that
+// evaluations return their default value immediately instead of waiting on a
+// network attempt. Once openDuration has elapsed a single probe call is let
+// through; the breaker closes when it succeeds and opens again otherwise.
+// The provider transitions to ERROR and emits PROVIDER_ERROR when the breaker
+// opens, and PROVIDER_READY when it closes. Calls canceled by their caller
+// are not counted.
+func WithCircuitBreaker(threshold int, openDuration time.Duration) Option {
+	return func(p *Provider) {
+		p.config.BreakerThreshold = threshold
+		p.config.BreakerOpenDuration = openDuration
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+// breaker is a circuit breaker guarding the calls made to Flipt.
+type breaker struct {
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// newBreaker returns a breaker for the configuration, or nil when disabled.
+func newBreaker(config Config) *breaker {
+	if config.BreakerThreshold <= 0 {
+		return nil
+	}
+
+	return &breaker{
+		threshold:    config.BreakerThreshold,
+		openDuration: config.BreakerOpenDuration,
+		now:          time.Now,
+	}
+}
+
+// String returns the state of the breaker, or "disabled" when b is nil.
+func (b *breaker) String() string {
+	if b == nil {
+		return "disabled"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state.String()
+}
+
+// allow reports whether a call may be made, moving an open breaker to
+// half-open once the open duration has elapsed.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+
+		// let a single probe through
+		b.state = breakerHalfOpen
+
+		return true
+	case breakerHalfOpen:
+		return false
+	}
+
+	return true
+}
+
+// done records the outcome of an allowed call, returning the state the
+// breaker transitioned to, if it changed.
+func (b *breaker) done(failure bool) (breakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failure {
+		b.failures = 0
+		if b.state == breakerHalfOpen {
+			b.state = breakerClosed
+			return breakerClosed, true
+		}
+
+		return b.state, false
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		opened := b.state == breakerClosed
+		b.state = breakerOpen
+		b.openedAt = b.now()
+
+		return breakerOpen, opened
+	}
+
+	return b.state, false
+}
+
+// cancel records an allowed call canceled by its caller as neither a success
+// nor a failure, letting another probe through if it was one.
+func (b *breaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// guard calls fn unless the circuit breaker is open, recording its outcome
+// and transitioning the provider between READY and ERROR accordingly.
+func (p *Provider) guard(fn func() error) error {
+	if p.breaker == nil {
+		err := fn()
+		p.observeAuth(err)
+
+		return err
+	}
+
+	if !p.breaker.allow() {
+		return errBreakerOpen
+	}
+
+	err := fn()
+	p.observeAuth(err)
+
+	// a call canceled by its caller says nothing about the health of Flipt
+	if isCanceled(err) {
+		p.breaker.cancel()
+		return err
+	}
+
+	state, changed := p.breaker.done(err != nil && isBackendError(err))
+	if !changed {
+		return err
+	}
+
+	// the provider may already have transitioned after consecutive failed
+	// evaluations, in which case no event is emitted again
+	switch state {
+	case breakerOpen:
+		p.config.Logger.Warn("flipt circuit breaker opened", "openDuration", p.config.BreakerOpenDuration, "error", err)
+
+		if p.transition(of.ErrorState) {
+			p.emit(of.ProviderError, of.ProviderEventDetails{Message: "flipt circuit breaker opened: " + err.Error()})
+		}
+	case breakerClosed:
+		p.config.Logger.Info("flipt circuit breaker closed")
+
+		if p.transition(of.ReadyState) {
+			p.emit(of.ProviderReady, of.ProviderEventDetails{Message: "flipt is reachable again"})
+		}
+	}
+
+	return err
+}
+
+// transition sets the status of the provider, reporting whether it changed.
+func (p *Provider) transition(status of.State) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := p.status != status
+	p.status = status
+
+	return changed
+}
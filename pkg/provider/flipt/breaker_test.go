@@ -0,0 +1,113 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("connection refused")).Times(3)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "on"}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCircuitBreaker(2, time.Minute), WithErrorEventThreshold(0))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	now := time.Now()
+	p.breaker.now = func() time.Time { return now }
+
+	p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	assert.Equal(t, of.ErrorState, p.Status())
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderError, event.EventType)
+	assert.Equal(t, "flipt circuit breaker opened: PROVIDER_NOT_READY: connection refused", event.Message)
+
+	// flipt is not called while the breaker is open
+	detail := p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	assert.Equal(t, "off", detail.Value)
+	assert.Equal(t, of.ProviderNotReadyCode, detail.ResolutionDetail().ErrorCode)
+	assert.Nil(t, detail.FlagMetadata["attempts"])
+
+	// a failed probe opens the breaker again without emitting an event
+	now = now.Add(time.Minute)
+	p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	assert.Empty(t, p.EventChannel())
+
+	// a successful probe closes the breaker
+	now = now.Add(time.Minute)
+	detail = p.StringEvaluation(context.Background(), "flag", "off", of.FlattenedContext{})
+	assert.Equal(t, "on", detail.Value)
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	event = <-p.EventChannel()
+	assert.Equal(t, of.ProviderReady, event.EventType)
+
+	mockSvc.AssertNumberOfCalls(t, "Evaluate", 4)
+}
+
+func TestCircuitBreaker_Canceled(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(nil, canceledError()).Times(3)
+
+	p := NewProvider(WithService(mockSvc), WithCircuitBreaker(2, time.Minute), WithErrorEventThreshold(0))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// evaluations aborted by their caller do not open the breaker
+	for i := 0; i < 3; i++ {
+		p.StringEvaluation(ctx, "flag", "off", of.FlattenedContext{})
+	}
+
+	assert.Equal(t, "closed", p.breaker.String())
+	assert.Equal(t, of.ReadyState, p.Status())
+	assert.Empty(t, p.EventChannel())
+}
+
+func TestBreaker(t *testing.T) {
+	assert.Nil(t, newBreaker(Config{}))
+
+	now := time.Now()
+	b := newBreaker(Config{BreakerThreshold: 1, BreakerOpenDuration: time.Second})
+	b.now = func() time.Time { return now }
+
+	// successful calls leave a closed breaker closed
+	assert.True(t, b.allow())
+	state, changed := b.done(false)
+	assert.Equal(t, breakerClosed, state)
+	assert.False(t, changed)
+
+	assert.True(t, b.allow())
+	state, changed = b.done(true)
+	assert.Equal(t, breakerOpen, state)
+	assert.True(t, changed)
+	assert.False(t, b.allow())
+
+	// only a single probe is let through at a time
+	now = now.Add(time.Second)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	// a canceled probe lets another one through
+	b.cancel()
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	state, changed = b.done(false)
+	assert.Equal(t, breakerClosed, state)
+	assert.True(t, changed)
+	assert.True(t, b.allow())
+}
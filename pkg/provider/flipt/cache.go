@@ -0,0 +1,562 @@
+package flipt
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Cache stores encoded evaluation results. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, reporting false when it is
+	// missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key, expiring it after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value stored for key, if any.
+	Delete(ctx context.Context, key string) error
+	// Flush removes all values.
+	Flush(ctx context.Context) error
+}
+
+// WithCache caches the results of evaluations in cache for ttl, keyed by
+// namespace, flag, evaluation context and the headers set by
+// ContextWithRequestHeaders, e.g. using NewLRUCache or, to keep
+// values across restarts, NewDiskCache. The results of flags are invalidated
+// whenever changes are detected (see WithChangePollInterval and
+// InvalidationHandler). Evaluations served from the cache have the "cached"
+// flag metadata set. Concurrent evaluations missing the cache for the same
+// result share a single call to Flipt, bound to the context of the first.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.config.Cache = cache
+		p.config.CacheTTL = ttl
+	}
+}
+
+// WithCacheTTLJitter shortens the TTL of each cached result by a random
+// fraction of up to jitter (e.g. 0.1 for up to 10%), spreading out the expiry
+// of results cached at the same time.
+func WithCacheTTLJitter(jitter float64) Option {
+	return func(p *Provider) {
+		p.config.CacheTTLJitter = jitter
+	}
+}
+
+// WithStaleWhileRevalidate keeps cached results for window after they expire.
+// Expired results are returned immediately while a background evaluation
+// refreshes them, trading freshness for consistently low latency. Results
+// served while being revalidated have the "stale" flag metadata set. It has
+// no effect unless a cache is set using WithCache.
+func WithStaleWhileRevalidate(window time.Duration) Option {
+	return func(p *Provider) {
+		p.config.CacheStaleWindow = window
+	}
+}
+
+// WithServeStaleOnError serves the last cached result of an evaluation, for
+// up to maxStale after it expired, when Flipt is unreachable or erroring
+// rather than the default value. Such results have the STALE reason and the
+// provider transitions to STALE, emitting a PROVIDER_STALE event, until Flipt
+// serves an evaluation again. It has no effect unless a cache is set using
+// WithCache.
+func WithServeStaleOnError(maxStale time.Duration) Option {
+	return func(p *Provider) {
+		p.config.CacheStaleOnError = maxStale
+	}
+}
+
+// StaleReason is the reason of evaluations served from an expired cached
+// result because Flipt failed to evaluate the flag.
+const StaleReason of.Reason = "STALE"
+
+// CacheStats are counters describing how evaluations were served from the
+// cache, e.g. to tune the TTL.
+type CacheStats struct {
+	// Hits is the number of evaluations served from a fresh cached result.
+	Hits uint64
+	// Misses is the number of evaluations which called Flipt as no fresh
+	// result was cached.
+	Misses uint64
+	// StaleServes is the number of evaluations served from an expired
+	// cached result, see WithStaleWhileRevalidate and WithServeStaleOnError.
+	StaleServes uint64
+	// Evictions is the number of results evicted from the cache before they
+	// expired, when reported by the cache (e.g. LRUCache) through an
+	// Evictions() uint64 method.
+	Evictions uint64
+	// EvictedBytes is the approximate size of the evicted results, when
+	// reported by the cache through an EvictedBytes() uint64 method.
+	EvictedBytes uint64
+}
+
+// cacheCounters counts cache lookups, shared by providers derived from the
+// same provider.
+type cacheCounters struct {
+	hits, misses, staleServes atomic.Uint64
+}
+
+// CacheStats returns the cache counters of the provider since it was created.
+// They are zero unless a cache is set using WithCache.
+func (p *Provider) CacheStats() CacheStats {
+	stats := CacheStats{
+		Hits:        p.cacheCounters.hits.Load(),
+		Misses:      p.cacheCounters.misses.Load(),
+		StaleServes: p.cacheCounters.staleServes.Load(),
+	}
+
+	if c, ok := p.config.Cache.(interface{ Evictions() uint64 }); ok {
+		stats.Evictions = c.Evictions()
+	}
+
+	if c, ok := p.config.Cache.(interface{ EvictedBytes() uint64 }); ok {
+		stats.EvictedBytes = c.EvictedBytes()
+	}
+
+	return stats
+}
+
+// cacheEntryVersion is the version of the encoding of cache entries. It is
+// only incremented for changes which older versions of the provider cannot
+// decode, entries of other versions being treated as misses so that external
+// caches can be shared between provider versions, e.g. during upgrades.
+const cacheEntryVersion = 1
+
+// cacheEntry is an evaluation result stored in the cache.
+type cacheEntry struct {
+	Version int `json:"version"`
+	// FreshUntil is when the result expires. It is kept in the cache for
+	// longer when stale results may be served.
+	FreshUntil time.Time `json:"freshUntil"`
+	// Response is the response of Flipt, encoded using the canonical JSON
+	// mapping of protocol buffers so that it can be decoded by versions of
+	// the provider built against other versions of the Flipt API.
+	Response json.RawMessage `json:"response"`
+}
+
+// decodeCacheEntry decodes data into entry and its response into resp,
+// reporting false when it is malformed or of another version.
+func decodeCacheEntry(data []byte, entry *cacheEntry, resp interface{}) bool {
+	if json.Unmarshal(data, entry) != nil || entry.Version != cacheEntryVersion {
+		return false
+	}
+
+	if msg, ok := resp.(proto.Message); ok {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(entry.Response, msg) == nil
+	}
+
+	return json.Unmarshal(entry.Response, resp) == nil
+}
+
+// encodeCacheEntry encodes resp, fresh until freshUntil, as a cache entry.
+func encodeCacheEntry(resp interface{}, freshUntil time.Time) ([]byte, error) {
+	var (
+		response []byte
+		err      error
+	)
+
+	if msg, ok := resp.(proto.Message); ok {
+		response, err = protojson.Marshal(msg)
+	} else {
+		response, err = json.Marshal(resp)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(cacheEntry{
+		Version:    cacheEntryVersion,
+		FreshUntil: freshUntil,
+		Response:   response,
+	})
+}
+
+// cached returns the response for the evaluation from the cache, decoding it
+// into resp, or otherwise calls fn and caches its response.
+func (p *Provider) cached(ctx context.Context, kind, flag string, evalCtx map[string]interface{}, resp interface{}, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	if p.config.Cache == nil {
+		return fn(ctx)
+	}
+
+	key, ok := p.evaluationKey(ctx, kind, flag, evalCtx)
+	if !ok {
+		return fn(ctx)
+	}
+
+	data, hit, err := p.config.Cache.Get(ctx, key)
+	if err != nil {
+		p.config.Logger.Debug("reading from cache failed", "key", key, "error", err)
+	}
+
+	var (
+		info  = transport.CallInfoFromContext(ctx)
+		entry cacheEntry
+	)
+
+	serve := func(stale, onError bool) {
+		if stale {
+			p.cacheCounters.staleServes.Add(1)
+		} else {
+			p.cacheCounters.hits.Add(1)
+		}
+
+		if info != nil {
+			info.Cached = true
+			info.Stale = stale
+			info.StaleOnError = onError
+		}
+	}
+
+	hit = hit && decodeCacheEntry(data, &entry, resp)
+
+	if hit {
+		now := time.Now()
+
+		if now.Before(entry.FreshUntil) {
+			serve(false, false)
+			return resp, nil
+		}
+
+		if now.Before(entry.FreshUntil.Add(p.config.CacheStaleWindow)) {
+			serve(true, false)
+			p.revalidate(ctx, key, fn)
+
+			return resp, nil
+		}
+	}
+
+	p.cacheCounters.misses.Add(1)
+
+	// concurrent misses share a single refresh so that the expiry of a
+	// popular result does not stampede Flipt
+	fresh, err := p.refreshes.do(ctx, key, func() (interface{}, error) {
+		return p.refresh(ctx, key, fn)
+	})
+	if err != nil && hit && isBackendError(err) && time.Now().Before(entry.FreshUntil.Add(p.config.CacheStaleOnError)) {
+		serve(true, true)
+		p.serveStale(err)
+
+		return resp, nil
+	}
+
+	return fresh, err
+}
+
+// refresh calls fn and caches its response when successful.
+func (p *Provider) refresh(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	fresh, err := fn(ctx)
+	if err != nil {
+		return fresh, err
+	}
+
+	p.leaveStale()
+
+	ttl := p.config.CacheTTL
+	if p.config.CacheTTLJitter > 0 {
+		ttl -= time.Duration(rand.Float64() * p.config.CacheTTLJitter * float64(ttl))
+	}
+
+	data, err := encodeCacheEntry(fresh, time.Now().Add(ttl))
+	if err != nil {
+		return fresh, nil
+	}
+
+	// keep results for as long as they may be served stale
+	retention := p.config.CacheStaleWindow
+	if p.config.CacheStaleOnError > retention {
+		retention = p.config.CacheStaleOnError
+	}
+
+	if err := p.config.Cache.Set(ctx, key, data, ttl+retention); err != nil {
+		p.config.Logger.Debug("writing to cache failed", "key", key, "error", err)
+	}
+
+	return fresh, nil
+}
+
+// serveStale transitions the provider to STALE after a stale result was
+// served because of err.
+func (p *Provider) serveStale(err error) {
+	if p.transition(of.StaleState) {
+		p.config.Logger.Warn("serving stale cached evaluations", "error", err)
+		p.emit(of.ProviderStale, of.ProviderEventDetails{Message: "serving stale cached evaluations: " + err.Error()})
+	}
+}
+
+// leaveStale transitions the provider from STALE back to READY once Flipt
+// serves an evaluation again, unless Flipt is over its latency budget.
+func (p *Provider) leaveStale() {
+	if p.latency.isSlow() {
+		return
+	}
+
+	p.mu.Lock()
+	stale := p.status == of.StaleState
+	if stale {
+		p.status = of.ReadyState
+	}
+	p.mu.Unlock()
+
+	if stale {
+		p.config.Logger.Info("flipt provider recovered")
+		p.emit(of.ProviderReady, of.ProviderEventDetails{Message: "flipt is reachable again"})
+	}
+}
+
+// revalidate refreshes the cached response for key in the background, unless
+// it is already being refreshed. The refresh outlives the evaluation's
+// context and records its calls into a CallInfo of its own.
+func (p *Provider) revalidate(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) {
+	if _, inflight := p.revalidating.LoadOrStore(key, struct{}{}); inflight {
+		return
+	}
+
+	ctx, _ = transport.ContextWithCallInfo(context.WithoutCancel(ctx))
+
+	go func() {
+		defer p.revalidating.Delete(key)
+
+		if _, err := p.refresh(ctx, key, fn); err != nil {
+			p.config.Logger.Debug("revalidating cached evaluation failed", "key", key, "error", err)
+		}
+	}()
+}
+
+// FlushNamespace removes the cached results of all flags of namespace, e.g.
+// after changing segments. Caches without a DeletePrefix(ctx, prefix) method,
+// such as DiskCache, are flushed entirely.
+func (p *Provider) FlushNamespace(ctx context.Context, namespace string) error {
+	return p.flushPrefix(ctx, namespace+"/")
+}
+
+// FlushFlag removes the cached results of flag in namespace. Caches without a
+// DeletePrefix(ctx, prefix) method, such as DiskCache, are flushed entirely.
+func (p *Provider) FlushFlag(ctx context.Context, namespace, flag string) error {
+	return p.flushPrefix(ctx, namespace+"/"+flag+"/")
+}
+
+// flushPrefix removes the cached results whose key starts with prefix.
+func (p *Provider) flushPrefix(ctx context.Context, prefix string) error {
+	if p.config.Cache == nil {
+		return nil
+	}
+
+	deleter, ok := p.config.Cache.(interface {
+		DeletePrefix(ctx context.Context, prefix string) error
+	})
+	if !ok {
+		return p.config.Cache.Flush(ctx)
+	}
+
+	return deleter.DeletePrefix(ctx, prefix)
+}
+
+// LRUCache is an in-memory Cache evicting the least recently used values
+// once full.
+type LRUCache struct {
+	size         int
+	maxBytes     int64
+	now          func() time.Time
+	evictions    atomic.Uint64
+	evictedBytes atomic.Uint64
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	bytes   int64
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// lruEntryOverhead approximates the memory used by an entry besides its key
+// and value.
+const lruEntryOverhead = 128
+
+// footprint returns the approximate memory used by the entry.
+func (e *lruEntry) footprint() int64 {
+	return int64(len(e.key) + len(e.value) + lruEntryOverhead)
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// LRUCacheOption is a configuration option for an LRUCache.
+type LRUCacheOption func(*LRUCache)
+
+// WithMaxBytes bounds the approximate memory used by the cache's keys and
+// values, which matters when flags have large attachments. Values larger than
+// the bound are not cached.
+func WithMaxBytes(maxBytes int64) LRUCacheOption {
+	return func(c *LRUCache) {
+		c.maxBytes = maxBytes
+	}
+}
+
+// NewLRUCache returns an LRUCache holding up to size values. A size of zero
+// does not bound the number of values.
+func NewLRUCache(size int, opts ...LRUCacheOption) *LRUCache {
+	c := &LRUCache{
+		size:    size,
+		now:     time.Now,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Get implements Cache.
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(el)
+
+	return entry.value, true, nil
+}
+
+// Set implements Cache.
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	entry := &lruEntry{key: key, value: value, expiresAt: c.now().Add(ttl)}
+	if c.maxBytes > 0 && entry.footprint() > c.maxBytes {
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += entry.footprint()
+
+	for (c.size > 0 && c.order.Len() > c.size) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		evicted := c.order.Back()
+		c.remove(evicted)
+		c.evictions.Add(1)
+		c.evictedBytes.Add(uint64(evicted.Value.(*lruEntry).footprint()))
+	}
+
+	return nil
+}
+
+// Delete implements Cache.
+func (c *LRUCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	return nil
+}
+
+// Flush implements Cache.
+func (c *LRUCache) Flush(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+	c.bytes = 0
+
+	return nil
+}
+
+// Items returns the unexpired values from the most to the least recently
+// used. Values which are not valid JSON are skipped.
+func (c *LRUCache) Items() []CacheItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		now   = c.now()
+		items = make([]CacheItem, 0, c.order.Len())
+	)
+
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		entry := el.Value.(*lruEntry)
+		if !now.Before(entry.expiresAt) || !json.Valid(entry.value) {
+			continue
+		}
+
+		items = append(items, CacheItem{Key: entry.key, Value: entry.value, ExpiresAt: entry.expiresAt})
+	}
+
+	return items
+}
+
+// DeletePrefix removes all values whose key starts with prefix.
+func (c *LRUCache) DeletePrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of values held by the cache, including expired
+// values not evicted yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Evictions returns the number of values evicted to make room for others.
+func (c *LRUCache) Evictions() uint64 {
+	return c.evictions.Load()
+}
+
+// EvictedBytes returns the approximate memory used by the values evicted to
+// make room for others.
+func (c *LRUCache) EvictedBytes() uint64 {
+	return c.evictedBytes.Load()
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	entry := el.Value.(*lruEntry)
+
+	c.order.Remove(el)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.footprint()
+}
@@ -0,0 +1,460 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestLRUCache(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+		c   = NewLRUCache(2)
+	)
+
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Second))
+
+	v, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	// b is the least recently used value and is evicted
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+
+	// values expire after their ttl
+	now = now.Add(time.Minute)
+
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "d", []byte("4"), time.Minute))
+	require.NoError(t, c.Delete(ctx, "d"))
+
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "e", []byte("5"), time.Minute))
+	require.NoError(t, c.Flush(ctx))
+
+	_, ok, _ = c.Get(ctx, "e")
+	assert.False(t, ok)
+}
+
+func TestWithCache(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "user"}).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "other"}).Return(nil, of.NewProviderNotReadyResolutionError("unavailable")).Twice()
+
+	cache := NewLRUCache(10)
+	p := NewProvider(WithService(mockSvc), WithCache(cache, time.Minute))
+
+	detail := p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Nil(t, detail.FlagMetadata["cached"])
+
+	detail = p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+
+	// failed evaluations are not cached
+	p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "other"})
+	p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "other"})
+
+	require.NoError(t, p.FlushNamespace(context.Background(), "default"))
+	assert.Empty(t, cache.entries)
+}
+
+func TestWithCache_EntryVersion(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		evalCtx = map[string]interface{}{of.TargetingKey: "user"}
+	)
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+
+	cache := NewLRUCache(10)
+	p := NewProvider(WithService(mockSvc), WithCache(cache, time.Minute))
+
+	key, ok := p.evaluationKey(ctx, "variant", "flag", evalCtx)
+	require.True(t, ok)
+
+	// entries written by an incompatible version of the provider are misses
+	freshUntil := time.Now().Add(time.Minute).Format(time.RFC3339)
+	require.NoError(t, cache.Set(ctx, key, []byte(`{"version":2,"freshUntil":"`+freshUntil+`","response":{"variantKey":"green"}}`), time.Minute))
+
+	detail := p.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Nil(t, detail.FlagMetadata["cached"])
+
+	// and are replaced by entries of the current version
+	data, ok, err := cache.Get(ctx, key)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Contains(t, string(data), `"version":1`)
+	assert.Contains(t, string(data), `"variantKey":"blue"`)
+
+	detail = p.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+}
+
+func TestWithCache_RequestHeaders(t *testing.T) {
+	tenant := func(name string) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			return transport.RequestHeadersFromContext(ctx)["X-Tenant"] == name
+		})
+	}
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", tenant("a"), "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "blue"}, nil).Once()
+	mockSvc.On("Evaluate", tenant("b"), "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "green"}, nil).Once()
+	mockSvc.On("Evaluate", tenant(""), "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "red"}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Minute))
+
+	evaluate := func(ctx context.Context) string {
+		return p.StringEvaluation(ctx, "flag", "default", of.FlattenedContext{of.TargetingKey: "user"}).Value
+	}
+
+	a := ContextWithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "a"})
+	b := ContextWithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "b"})
+
+	// each tenant gets its own cached result
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "blue", evaluate(a))
+		assert.Equal(t, "green", evaluate(b))
+		assert.Equal(t, "red", evaluate(context.Background()))
+	}
+}
+
+func TestWithStaleWhileRevalidate(t *testing.T) {
+	var (
+		evalCtx     = map[string]interface{}{of.TargetingKey: "user"}
+		revalidated = make(chan struct{})
+	)
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "green",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Run(func(mock.Arguments) { close(revalidated) }).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), 50*time.Millisecond), WithStaleWhileRevalidate(time.Minute))
+
+	detail := p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+
+	time.Sleep(60 * time.Millisecond)
+
+	// the expired result is served while it is refreshed in the background
+	detail = p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+	assert.Equal(t, true, detail.FlagMetadata["stale"])
+
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatal("cached result was not revalidated")
+	}
+
+	require.Eventually(t, func() bool {
+		detail = p.StringEvaluation(context.Background(), "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+		return detail.Value == "green"
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+	assert.Nil(t, detail.FlagMetadata["stale"])
+}
+
+func TestWithServeStaleOnError(t *testing.T) {
+	evalCtx := map[string]interface{}{of.TargetingKey: "user"}
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(nil, of.NewGeneralResolutionError("unavailable")).Twice()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(nil, of.NewFlagNotFoundResolutionError("not found")).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Millisecond), WithServeStaleOnError(time.Minute))
+
+	detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.True(t, detail.Value)
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+
+	time.Sleep(5 * time.Millisecond)
+
+	// the last known value is served while flipt is failing
+	for i := 0; i < 2; i++ {
+		detail = p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+		assert.True(t, detail.Value)
+		assert.Equal(t, StaleReason, detail.Reason)
+		assert.Empty(t, detail.ResolutionDetail().ErrorCode)
+		assert.Equal(t, true, detail.FlagMetadata["stale"])
+	}
+
+	assert.Equal(t, of.StaleState, p.Status())
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderStale, event.EventType)
+	assert.Empty(t, p.EventChannel(), "stale event must only be emitted once")
+
+	// errors caused by the flag itself are not hidden
+	detail = p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.False(t, detail.Value)
+	assert.Equal(t, of.FlagNotFoundCode, detail.ResolutionDetail().ErrorCode)
+
+	detail = p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.True(t, detail.Value)
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+	assert.Nil(t, detail.FlagMetadata["cached"])
+
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	event = <-p.EventChannel()
+	assert.Equal(t, of.ProviderReady, event.EventType)
+}
+
+func TestWithServeStaleOnErrorExpired(t *testing.T) {
+	evalCtx := map[string]interface{}{of.TargetingKey: "user"}
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(nil, of.NewGeneralResolutionError("unavailable")).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Millisecond), WithServeStaleOnError(time.Millisecond))
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+
+	time.Sleep(5 * time.Millisecond)
+
+	detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.False(t, detail.Value)
+	assert.Equal(t, of.GeneralCode, detail.ResolutionDetail().ErrorCode)
+}
+
+func TestCacheStats(t *testing.T) {
+	evalCtx := map[string]interface{}{of.TargetingKey: "user"}
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "other", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(1), time.Minute))
+	assert.Equal(t, CacheStats{}, p.CacheStats())
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+
+	// evaluations of derived providers are counted too
+	p.WithStaticContext(nil).BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+
+	// evicts the result of the first flag
+	p.BooleanEvaluation(context.Background(), "other", false, of.FlattenedContext{of.TargetingKey: "user"})
+
+	stats := p.CacheStats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.NotZero(t, stats.EvictedBytes)
+}
+
+func TestCacheStampedeProtection(t *testing.T) {
+	var (
+		evalCtx = map[string]interface{}{of.TargetingKey: "user"}
+		release = make(chan struct{})
+		calls   atomic.Int32
+	)
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Run(func(mock.Arguments) {
+		calls.Add(1)
+		<-release
+	})
+
+	p := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Minute))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+			assert.True(t, detail.Value)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return p.CacheStats().Misses == 50 }, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithCacheTTLJitter(t *testing.T) {
+	evalCtx := map[string]interface{}{of.TargetingKey: "user"}
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+
+	cache := NewLRUCache(10)
+	p := NewProvider(WithService(mockSvc), WithCache(cache, time.Hour), WithCacheTTLJitter(0.5))
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+
+	require.Len(t, cache.entries, 1)
+
+	for _, el := range cache.entries {
+		ttl := time.Until(el.Value.(*lruEntry).expiresAt)
+		assert.LessOrEqual(t, ttl, time.Hour)
+		assert.Greater(t, ttl, 30*time.Minute-time.Second)
+	}
+}
+
+func TestLRUCacheMaxBytes(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		c     = NewLRUCache(0, WithMaxBytes(2*(lruEntryOverhead+101)))
+		value = make([]byte, 100)
+	)
+
+	require.NoError(t, c.Set(ctx, "a", value, time.Minute))
+	require.NoError(t, c.Set(ctx, "b", value, time.Minute))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// b is the least recently used value and is evicted to make room
+	require.NoError(t, c.Set(ctx, "c", value, time.Minute))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), c.Evictions())
+	assert.Equal(t, uint64(lruEntryOverhead+101), c.EvictedBytes())
+
+	// values larger than the bound are not cached
+	require.NoError(t, c.Set(ctx, "d", make([]byte, 1000), time.Minute))
+
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok)
+
+	_, ok, _ = c.Get(ctx, "c")
+	assert.True(t, ok)
+
+	require.NoError(t, c.Flush(ctx))
+	assert.Zero(t, c.bytes)
+}
+
+func TestFlushNamespaceAndFlag(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name      string
+		flush     func(p *Provider) error
+		remaining []string
+	}{
+		{
+			name:      "flag",
+			flush:     func(p *Provider) error { return p.FlushFlag(ctx, "default", "a") },
+			remaining: []string{"default/b/boolean/1", "staging/a/boolean/1"},
+		},
+		{
+			name:      "namespace",
+			flush:     func(p *Provider) error { return p.FlushNamespace(ctx, "default") },
+			remaining: []string{"staging/a/boolean/1"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewLRUCache(10)
+			for _, key := range []string{"default/a/boolean/1", "default/a/variant/1", "default/b/boolean/1", "staging/a/boolean/1"} {
+				require.NoError(t, cache.Set(ctx, key, []byte("{}"), time.Minute))
+			}
+
+			p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute))
+			require.NoError(t, tt.flush(p))
+
+			var remaining []string
+			for key := range cache.entries {
+				remaining = append(remaining, key)
+			}
+
+			assert.ElementsMatch(t, tt.remaining, remaining)
+		})
+	}
+}
+
+func TestFlushFlagWithoutDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Set(ctx, "default/b/boolean/1", []byte("{}"), time.Minute))
+
+	p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute))
+
+	// the whole cache is flushed
+	require.NoError(t, p.FlushFlag(ctx, "default", "a"))
+
+	_, ok, err := cache.Get(ctx, "default/b/boolean/1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,216 @@
+package flipt
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"google.golang.org/protobuf/proto"
+)
+
+// WithChangePollInterval enables polling Flipt for flag changes in the
+// provider's namespace at the given interval once the provider has been
+// initialized. A PROVIDER_CONFIGURATION_CHANGED event listing the keys of the
+// added, updated and deleted flags is emitted whenever a change is detected.
+// Changes to the rules, distributions and rollouts of a flag are detected as
+// changes of the flag, and changes to segments as changes of every flag of
+// the namespace, at the cost of listing the rules or rollouts of every flag
+// on each poll. An interval of zero disables polling.
+func WithChangePollInterval(interval time.Duration) Option {
+	return func(p *Provider) {
+		p.config.ChangePollInterval = interval
+	}
+}
+
+// targetingLister is implemented by services able to list the targeting of
+// flags, so that its changes are detected along with those of the flags.
+type targetingLister interface {
+	ListRules(ctx context.Context, namespaceKey, flagKey string) ([]*flipt.Rule, error)
+	ListRollouts(ctx context.Context, namespaceKey, flagKey string) ([]*flipt.Rollout, error)
+	ListSegments(ctx context.Context, namespaceKey string) ([]*flipt.Segment, error)
+}
+
+// startPolling starts polling for flag changes, unless polling is disabled,
+// already running or the service does not support listing flags.
+func (p *Provider) startPolling() {
+	lister, ok := p.svc.(interface {
+		ListFlags(ctx context.Context, namespaceKey string) ([]*flipt.Flag, error)
+	})
+	if !ok || p.config.ChangePollInterval <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopPolling != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopPolling = cancel
+
+	go p.poll(ctx, lister.ListFlags)
+}
+
+// stopPollingChanges stops polling for flag changes, if running.
+func (p *Provider) stopPollingChanges() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopPolling != nil {
+		p.stopPolling()
+		p.stopPolling = nil
+	}
+}
+
+func (p *Provider) poll(ctx context.Context, list func(context.Context, string) ([]*flipt.Flag, error)) {
+	ticker := time.NewTicker(p.config.ChangePollInterval)
+	defer ticker.Stop()
+
+	// the first successful listing establishes the baseline
+	var (
+		versions map[string]string
+		segments string
+	)
+
+	for {
+		if current, currentSegments, err := p.namespaceVersions(ctx, list); err != nil {
+			p.config.Logger.Debug("polling flipt for flag changes failed", "namespace", p.config.Namespace, "error", err)
+		} else {
+			if versions != nil {
+				if currentSegments != segments {
+					// segments may be used by any flag of the namespace
+					p.broadcast(ctx, Invalidation{Namespace: p.config.Namespace})
+				} else if changed := changedFlags(versions, current); len(changed) > 0 {
+					p.broadcast(ctx, Invalidation{Namespace: p.config.Namespace, Flags: changed})
+				}
+			}
+
+			versions, segments = current, currentSegments
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// namespaceVersions returns a version for each flag of the namespace, keyed
+// by flag key, along with a version of its segments. The targeting of flags
+// and segments are only versioned when the service can list them.
+func (p *Provider) namespaceVersions(ctx context.Context, list func(context.Context, string) ([]*flipt.Flag, error)) (map[string]string, string, error) {
+	flags, err := list(ctx, p.config.Namespace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	targeting, ok := p.svc.(targetingLister)
+	if !ok {
+		return flagVersions(flags, nil), "", nil
+	}
+
+	rules := make(map[string][]proto.Message, len(flags))
+
+	for _, flag := range flags {
+		// boolean flags are targeted by rollouts, variant flags by rules
+		if flag.Type == flipt.FlagType_BOOLEAN_FLAG_TYPE {
+			rollouts, err := targeting.ListRollouts(ctx, p.config.Namespace, flag.Key)
+			if err != nil {
+				return nil, "", err
+			}
+
+			for _, rollout := range rollouts {
+				rules[flag.Key] = append(rules[flag.Key], rollout)
+			}
+
+			continue
+		}
+
+		flagRules, err := targeting.ListRules(ctx, p.config.Namespace, flag.Key)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, rule := range flagRules {
+			rules[flag.Key] = append(rules[flag.Key], rule)
+		}
+	}
+
+	segments, err := targeting.ListSegments(ctx, p.config.Namespace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	h := sha256.New()
+	for _, segment := range segments {
+		writeMessage(h, segment)
+	}
+
+	return flagVersions(flags, rules), fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// flagVersions returns a version for each flag, keyed by flag key, covering
+// the rules or rollouts of the flags keyed by flag key.
+func flagVersions(flags []*flipt.Flag, rules map[string][]proto.Message) map[string]string {
+	versions := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		versions[flag.Key] = flagVersion(flag, rules[flag.Key]...)
+	}
+
+	return versions
+}
+
+// flagVersion returns a digest of the parts of a flag which affect its
+// evaluation and are returned when listing flags, and of its rules or
+// rollouts.
+func flagVersion(flag *flipt.Flag, rules ...proto.Message) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%d\x00%t\x00%d\x00", flag.GetUpdatedAt().AsTime().UnixNano(), flag.Enabled, flag.Type)
+	for _, v := range flag.Variants {
+		fmt.Fprintf(h, "%s\x00%s\x00", v.Key, v.Attachment)
+	}
+
+	for _, rule := range rules {
+		writeMessage(h, rule)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// writeMessage writes the deterministic encoding of msg to w, prefixed by its
+// length so that consecutive messages cannot be confused.
+func writeMessage(w io.Writer, msg proto.Message) {
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	fmt.Fprintf(w, "%d\x00", len(data))
+	_, _ = w.Write(data)
+}
+
+// changedFlags returns the sorted keys of the flags which were added, updated
+// or deleted between the previous and current versions.
+func changedFlags(previous, current map[string]string) []string {
+	var changed []string
+
+	for key, version := range current {
+		if previous[key] != version {
+			changed = append(changed, key)
+		}
+	}
+
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
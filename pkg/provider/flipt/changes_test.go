@@ -0,0 +1,186 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type listingService struct {
+	*mockService
+
+	mu    sync.Mutex
+	flags []*flipt.Flag
+}
+
+func (s *listingService) ListFlags(context.Context, string) ([]*flipt.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flags, nil
+}
+
+func (s *listingService) setFlags(flags ...*flipt.Flag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags = flags
+}
+
+func TestChangePolling(t *testing.T) {
+	created := timestamppb.New(time.Unix(1, 0))
+
+	svc := &listingService{mockService: newMockService(t)}
+	svc.setFlags(&flipt.Flag{Key: "foo", UpdatedAt: created}, &flipt.Flag{Key: "bar", UpdatedAt: created})
+
+	p := NewProvider(WithService(svc), WithChangePollInterval(10*time.Millisecond))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+	defer p.Shutdown()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, p.EventChannel())
+
+	svc.setFlags(&flipt.Flag{Key: "foo", UpdatedAt: timestamppb.New(time.Unix(2, 0))}, &flipt.Flag{Key: "baz", UpdatedAt: created})
+
+	select {
+	case event := <-p.EventChannel():
+		assert.Equal(t, of.ProviderConfigChange, event.EventType)
+		assert.Equal(t, []string{"bar", "baz", "foo"}, event.FlagChanges)
+	case <-time.After(time.Second):
+		t.Fatal("expected configuration changed event")
+	}
+
+	p.Shutdown()
+	svc.setFlags()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, p.EventChannel())
+}
+
+type targetingService struct {
+	*listingService
+
+	rules    []*flipt.Rule
+	rollouts []*flipt.Rollout
+	segments []*flipt.Segment
+}
+
+func (s *targetingService) ListRules(_ context.Context, _, flagKey string) ([]*flipt.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rules []*flipt.Rule
+	for _, rule := range s.rules {
+		if rule.FlagKey == flagKey {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func (s *targetingService) ListRollouts(context.Context, string, string) ([]*flipt.Rollout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rollouts, nil
+}
+
+func (s *targetingService) ListSegments(context.Context, string) ([]*flipt.Segment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.segments, nil
+}
+
+func (s *targetingService) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn()
+}
+
+func TestChangePolling_Targeting(t *testing.T) {
+	svc := &targetingService{
+		listingService: &listingService{mockService: newMockService(t)},
+		rules:          []*flipt.Rule{{Id: "1", FlagKey: "variant", SegmentKey: "users", Rank: 1}},
+		rollouts:       []*flipt.Rollout{{Id: "2", FlagKey: "bool", Rank: 1}},
+		segments:       []*flipt.Segment{{Key: "users"}},
+	}
+	svc.setFlags(
+		&flipt.Flag{Key: "variant", Type: flipt.FlagType_VARIANT_FLAG_TYPE},
+		&flipt.Flag{Key: "bool", Type: flipt.FlagType_BOOLEAN_FLAG_TYPE},
+	)
+
+	p := NewProvider(WithService(svc), WithChangePollInterval(10*time.Millisecond))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+	defer p.Shutdown()
+
+	next := func() of.Event {
+		select {
+		case event := <-p.EventChannel():
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("expected configuration changed event")
+		}
+
+		return of.Event{}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, p.EventChannel())
+
+	// edits to rules and rollouts leave the flags untouched
+	svc.update(func() { svc.rules[0] = &flipt.Rule{Id: "1", FlagKey: "variant", SegmentKey: "admins", Rank: 1} })
+	assert.Equal(t, []string{"variant"}, next().FlagChanges)
+
+	svc.update(func() { svc.rollouts = nil })
+	assert.Equal(t, []string{"bool"}, next().FlagChanges)
+
+	// segments may be used by any flag
+	svc.update(func() { svc.segments = append(svc.segments, &flipt.Segment{Key: "admins"}) })
+
+	event := next()
+	assert.Equal(t, of.ProviderConfigChange, event.EventType)
+	assert.Empty(t, event.FlagChanges)
+}
+
+func TestChangedFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous map[string]string
+		current  map[string]string
+		expected []string
+	}{
+		{
+			name:     "unchanged",
+			previous: map[string]string{"foo": "1"},
+			current:  map[string]string{"foo": "1"},
+		},
+		{
+			name:     "updated",
+			previous: map[string]string{"foo": "1", "bar": "1"},
+			current:  map[string]string{"foo": "2", "bar": "1"},
+			expected: []string{"foo"},
+		},
+		{
+			name:     "added and deleted",
+			previous: map[string]string{"foo": "1"},
+			current:  map[string]string{"bar": "1"},
+			expected: []string{"bar", "foo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, changedFlags(tt.previous, tt.current))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package flipt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+)
+
+// WithRequestCoalescing collapses concurrent evaluations of the same flag
+// with identical evaluation contexts and request headers (see
+// ContextWithRequestHeaders) into a single call to Flipt, whose
+// result is shared by all of them. The call is bound to the context of the
+// evaluation which made it, while the evaluations awaiting its result stop
+// waiting when their own context is done.
+func WithRequestCoalescing() Option {
+	return func(p *Provider) {
+		p.config.RequestCoalescing = true
+	}
+}
+
+// flight is a call to Flipt in progress.
+type flight struct {
+	done chan struct{}
+	resp interface{}
+	err  error
+}
+
+// coalescer deduplicates concurrent calls with the same key.
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// do calls fn, unless a call with the same key is in progress in which case
+// its result is awaited, until ctx is done, and returned instead.
+func (c *coalescer) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-f.done:
+			return f.resp, f.err
+		case <-ctx.Done():
+			return nil, transport.ContextError(ctx)
+		}
+	}
+
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, key)
+		c.mu.Unlock()
+
+		close(f.done)
+	}()
+
+	f.resp, f.err = fn()
+
+	return f.resp, f.err
+}
+
+// coalesce calls fn to evaluate flag, sharing the call with concurrent
+// evaluations of the same kind, flag, evaluation context and request headers
+// when request coalescing is enabled.
+func (p *Provider) coalesce(ctx context.Context, kind, flag string, evalCtx map[string]interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	if p.coalescer == nil {
+		return fn()
+	}
+
+	key, ok := p.evaluationKey(ctx, kind, flag, evalCtx)
+	if !ok {
+		return fn()
+	}
+
+	return p.coalescer.do(ctx, key, fn)
+}
+
+// evaluationKey returns a key identifying the evaluation of flag of the given
+// kind with evalCtx and the request headers carried by ctx, reporting false
+// when evalCtx cannot be encoded.
+func (p *Provider) evaluationKey(ctx context.Context, kind, flag string, evalCtx map[string]interface{}) (string, bool) {
+	// maps are encoded with sorted keys, so equal contexts have equal digests
+	encoded, err := json.Marshal(evalCtx)
+	if err != nil {
+		return "", false
+	}
+
+	// per-request headers may route the call to another tenant or carry its
+	// credentials, so results are only shared between evaluations sending
+	// the same ones
+	if headers := transport.RequestHeadersFromContext(ctx); len(headers) > 0 {
+		encodedHeaders, err := json.Marshal(headers)
+		if err != nil {
+			return "", false
+		}
+
+		encoded = append(encoded, encodedHeaders...)
+	}
+
+	// keys are prefixed by namespace and flag so that their cached results
+	// can be flushed selectively
+	return fmt.Sprintf("%s/%s/%s/%x", p.config.Namespace, flag, kind, sha256.Sum256(encoded)), true
+}
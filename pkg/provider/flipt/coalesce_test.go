@@ -0,0 +1,110 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.MatchedBy(func(ctx context.Context) bool {
+		return transport.RequestHeadersFromContext(ctx) == nil
+	}), "default", "flag", map[string]interface{}{of.TargetingKey: "user"}).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "other"}).
+		Return(&evaluation.BooleanEvaluationResponse{}, nil).Once()
+	mockSvc.On("Boolean", mock.MatchedBy(func(ctx context.Context) bool {
+		return transport.RequestHeadersFromContext(ctx)["X-Tenant"] == "other"
+	}), "default", "flag", map[string]interface{}{of.TargetingKey: "user"}).
+		Return(&evaluation.BooleanEvaluationResponse{}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithRequestCoalescing())
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]bool, 5)
+	)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			results[i] = p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"}).Value
+		}(i)
+
+		if i == 0 {
+			<-started
+		}
+	}
+
+	// evaluations with a different context are not coalesced
+	assert.False(t, p.BooleanEvaluation(context.Background(), "flag", true, of.FlattenedContext{of.TargetingKey: "other"}).Value)
+
+	// nor are evaluations sending different request headers
+	tenant := ContextWithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "other"})
+	assert.False(t, p.BooleanEvaluation(tenant, "flag", true, of.FlattenedContext{of.TargetingKey: "user"}).Value)
+
+	// give the remaining evaluations time to join the call in progress
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []bool{true, true, true, true, true}, results)
+	mockSvc.AssertNumberOfCalls(t, "Boolean", 3)
+}
+
+func TestRequestCoalescing_ContextDone(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithRequestCoalescing())
+
+	done := make(chan bool)
+	go func() {
+		done <- p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"}).Value
+	}()
+
+	<-started
+
+	// an evaluation awaiting the call in progress stops at its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	detail := p.BooleanEvaluation(ctx, "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.False(t, detail.Value)
+	assert.Equal(t, of.ErrorReason, detail.Reason)
+	assert.Equal(t, of.GeneralCode, detail.ErrorCode)
+	assert.Contains(t, detail.ErrorMessage, "timeout: ")
+
+	close(release)
+	assert.True(t, <-done)
+}
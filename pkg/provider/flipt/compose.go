@@ -0,0 +1,278 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+var (
+	_ of.FeatureProvider = (*Composite)(nil)
+	_ of.StateHandler    = (*Composite)(nil)
+)
+
+type composeMode int
+
+const (
+	chainMode composeMode = iota
+	raceMode
+	firstSuccessMode
+)
+
+// LegStats describes the evaluations served by one provider of a Composite.
+type LegStats struct {
+	// Name is the name of the provider from its metadata.
+	Name string
+	// Evaluations is the number of evaluations sent to the provider.
+	Evaluations uint64
+	// Errors is the number of evaluations which failed.
+	Errors uint64
+	// Wins is the number of evaluations whose result was returned.
+	Wins uint64
+	// Latency is the total time spent waiting on the provider.
+	Latency time.Duration
+}
+
+// Composite is a FeatureProvider which composes several providers, e.g. a
+// local snapshot raced against a remote Flipt instance or chained fallbacks.
+type Composite struct {
+	name  string
+	mode  composeMode
+	legs  []of.FeatureProvider
+	mu    sync.Mutex
+	stats []LegStats
+}
+
+// Chain returns a provider evaluating flags against providers in order,
+// falling back to the next provider whenever one fails. The result of the
+// last provider is returned when all of them fail.
+func Chain(providers ...of.FeatureProvider) *Composite {
+	return newComposite("flipt-chain", chainMode, providers)
+}
+
+// Race returns a provider evaluating flags against all providers
+// concurrently and returning the first result, successful or not.
+func Race(providers ...of.FeatureProvider) *Composite {
+	return newComposite("flipt-race", raceMode, providers)
+}
+
+// FirstSuccess returns a provider evaluating flags against all providers
+// concurrently and returning the first successful result. The result of the
+// last provider to complete is returned when all of them fail.
+func FirstSuccess(providers ...of.FeatureProvider) *Composite {
+	return newComposite("flipt-first-success", firstSuccessMode, providers)
+}
+
+func newComposite(name string, mode composeMode, providers []of.FeatureProvider) *Composite {
+	c := &Composite{
+		name:  name,
+		mode:  mode,
+		legs:  providers,
+		stats: make([]LegStats, len(providers)),
+	}
+
+	for i, p := range providers {
+		c.stats[i].Name = p.Metadata().Name
+	}
+
+	return c
+}
+
+// Metadata returns the metadata of the provider.
+func (c *Composite) Metadata() of.Metadata {
+	return of.Metadata{Name: c.name}
+}
+
+// Hooks returns no hooks; the hooks of the composed providers are not run.
+func (c *Composite) Hooks() []of.Hook {
+	return []of.Hook{}
+}
+
+// Stats returns the statistics of each composed provider, in order.
+func (c *Composite) Stats() []LegStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]LegStats(nil), c.stats...)
+}
+
+// Init initializes the composed providers implementing of.StateHandler. It
+// only fails when all of them fail.
+func (c *Composite) Init(evalCtx of.EvaluationContext) error {
+	var errs []error
+
+	for _, leg := range c.legs {
+		if h, ok := leg.(of.StateHandler); ok {
+			if err := h.Init(evalCtx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) > 0 && len(errs) == len(c.legs) {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// Shutdown shuts down the composed providers implementing of.StateHandler.
+func (c *Composite) Shutdown() {
+	for _, leg := range c.legs {
+		if h, ok := leg.(of.StateHandler); ok {
+			h.Shutdown()
+		}
+	}
+}
+
+// Status returns READY when any composed provider is ready, or does not
+// report its state.
+func (c *Composite) Status() of.State {
+	status := of.NotReadyState
+
+	for _, leg := range c.legs {
+		h, ok := leg.(of.StateHandler)
+		if !ok || h.Status() == of.ReadyState {
+			return of.ReadyState
+		}
+
+		if h.Status() == of.ErrorState {
+			status = of.ErrorState
+		}
+	}
+
+	return status
+}
+
+// BooleanEvaluation returns a boolean flag.
+func (c *Composite) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	r := c.resolve(ctx, flag, defaultValue, evalCtx)
+
+	v, _ := r.Value.(bool)
+
+	return of.BoolResolutionDetail{Value: v, ProviderResolutionDetail: r.ProviderResolutionDetail}
+}
+
+// StringEvaluation returns a string flag.
+func (c *Composite) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	r := c.resolve(ctx, flag, defaultValue, evalCtx)
+
+	v, _ := r.Value.(string)
+
+	return of.StringResolutionDetail{Value: v, ProviderResolutionDetail: r.ProviderResolutionDetail}
+}
+
+// FloatEvaluation returns a float flag.
+func (c *Composite) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	r := c.resolve(ctx, flag, defaultValue, evalCtx)
+
+	v, _ := r.Value.(float64)
+
+	return of.FloatResolutionDetail{Value: v, ProviderResolutionDetail: r.ProviderResolutionDetail}
+}
+
+// IntEvaluation returns an int flag.
+func (c *Composite) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	r := c.resolve(ctx, flag, defaultValue, evalCtx)
+
+	v, _ := r.Value.(int64)
+
+	return of.IntResolutionDetail{Value: v, ProviderResolutionDetail: r.ProviderResolutionDetail}
+}
+
+// ObjectEvaluation returns an object flag.
+func (c *Composite) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	r := c.resolve(ctx, flag, defaultValue, evalCtx)
+
+	return of.InterfaceResolutionDetail{Value: r.Value, ProviderResolutionDetail: r.ProviderResolutionDetail}
+}
+
+func (c *Composite) resolve(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) Result {
+	if len(c.legs) == 0 {
+		return Result{
+			Value: defaultValue,
+			ProviderResolutionDetail: of.ProviderResolutionDetail{
+				ResolutionError: of.NewProviderNotReadyResolutionError("no providers composed"),
+				Reason:          of.ErrorReason,
+			},
+		}
+	}
+
+	if c.mode == chainMode {
+		var (
+			r Result
+			i int
+		)
+
+		for i = range c.legs {
+			if r = c.evaluateLeg(ctx, i, flag, defaultValue, evalCtx); !failed(r) {
+				break
+			}
+		}
+
+		c.win(i)
+
+		return r
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type legResult struct {
+		leg int
+		Result
+	}
+
+	results := make(chan legResult, len(c.legs))
+	for i := range c.legs {
+		go func(i int) {
+			results <- legResult{leg: i, Result: c.evaluateLeg(ctx, i, flag, defaultValue, evalCtx)}
+		}(i)
+	}
+
+	var r legResult
+	for range c.legs {
+		r = <-results
+		if c.mode == raceMode || !failed(r.Result) {
+			break
+		}
+	}
+
+	c.win(r.leg)
+
+	return r.Result
+}
+
+// evaluateLeg evaluates flag against the i-th provider, recording its stats.
+func (c *Composite) evaluateLeg(ctx context.Context, i int, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) Result {
+	start := time.Now()
+	r := resolve(ctx, c.legs[i], flag, defaultValue, evalCtx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats[i].Evaluations++
+	c.stats[i].Latency += latency
+
+	if failed(r) {
+		c.stats[i].Errors++
+	}
+
+	return r
+}
+
+// win records that the result of the i-th provider was returned.
+func (c *Composite) win(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats[i].Wins++
+}
+
+func failed(r Result) bool {
+	return r.ResolutionDetail().ErrorCode != ""
+}
@@ -0,0 +1,94 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestComposite(t *testing.T) {
+	newLeg := func(t *testing.T, delay time.Duration, err error) *Provider {
+		mockSvc := newMockService(t)
+		call := mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).After(delay).Maybe()
+		if err != nil {
+			call.Return(nil, err)
+		} else {
+			call.Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: delay.String()}, nil)
+		}
+
+		return NewProvider(WithService(mockSvc))
+	}
+
+	unavailable := of.NewProviderNotReadyResolutionError("unavailable")
+
+	tests := []struct {
+		name        string
+		compose     func(...of.FeatureProvider) *Composite
+		legs        func(t *testing.T) []of.FeatureProvider
+		expected    string
+		expectedErr bool
+		wins        []uint64
+	}{
+		{
+			name:    "chain falls back",
+			compose: Chain,
+			legs: func(t *testing.T) []of.FeatureProvider {
+				return []of.FeatureProvider{newLeg(t, 0, unavailable), newLeg(t, time.Millisecond, nil), newLeg(t, 0, nil)}
+			},
+			expected: "1ms",
+			wins:     []uint64{0, 1, 0},
+		},
+		{
+			name:    "chain all fail",
+			compose: Chain,
+			legs: func(t *testing.T) []of.FeatureProvider {
+				return []of.FeatureProvider{newLeg(t, 0, unavailable), newLeg(t, 0, unavailable)}
+			},
+			expected:    "default",
+			expectedErr: true,
+			wins:        []uint64{0, 1},
+		},
+		{
+			name:    "race returns fastest",
+			compose: Race,
+			legs: func(t *testing.T) []of.FeatureProvider {
+				return []of.FeatureProvider{newLeg(t, 50*time.Millisecond, nil), newLeg(t, 0, unavailable)}
+			},
+			expected:    "default",
+			expectedErr: true,
+			wins:        []uint64{0, 1},
+		},
+		{
+			name:    "first success skips failures",
+			compose: FirstSuccess,
+			legs: func(t *testing.T) []of.FeatureProvider {
+				return []of.FeatureProvider{newLeg(t, 20*time.Millisecond, nil), newLeg(t, 0, unavailable)}
+			},
+			expected: "20ms",
+			wins:     []uint64{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.compose(tt.legs(t)...)
+
+			detail := c.StringEvaluation(context.Background(), "flag", "default", of.FlattenedContext{})
+			assert.Equal(t, tt.expected, detail.Value)
+			assert.Equal(t, tt.expectedErr, detail.ResolutionDetail().ErrorCode != "")
+
+			var wins []uint64
+			for _, s := range c.Stats() {
+				assert.Equal(t, "flipt-provider", s.Name)
+				wins = append(wins, s.Wins)
+			}
+
+			assert.Equal(t, tt.wins, wins)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package flipt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+// DecisionsHeader is the HTTP header conventionally used by edge proxies to
+// forward pre-resolved flag decisions to the application.
+const DecisionsHeader = "X-Flipt-Decisions"
+
+// Decision is a flag decision resolved upstream of the provider, e.g. by a
+// CDN or edge proxy.
+type Decision struct {
+	// Match reports whether a variant flag matched; Variant and Attachment
+	// hold the matched variant.
+	Match      bool   `json:"match,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	Attachment string `json:"attachment,omitempty"`
+	// Enabled is the value of a boolean flag.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// Decisions is a set of pre-resolved flag decisions for a namespace and an
+// entity.
+type Decisions struct {
+	Namespace string `json:"namespace"`
+	// Entity binds the decisions to the entity they were resolved for, so
+	// that they cannot be replayed for another one: it is the hex-encoded
+	// SHA-256 hash of its targeting key, as returned by DecisionsEntity.
+	Entity    string              `json:"entity"`
+	ExpiresAt int64               `json:"exp"`
+	Flags     map[string]Decision `json:"flags"`
+}
+
+// DecisionsEntity returns the value of Decisions.Entity for the entity
+// identified by targetingKey.
+func DecisionsEntity(targetingKey string) string {
+	sum := sha256.Sum256([]byte(targetingKey))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// WithDecisionsKey enables serving pre-resolved decisions attached to the
+// evaluation's context with ContextWithDecisions, without calling Flipt.
+// Decisions are only served when signed with key, not expired and resolved
+// for the targeting key of the evaluation; otherwise flags are evaluated by
+// Flipt as usual.
+func WithDecisionsKey(key []byte) Option {
+	return func(p *Provider) {
+		p.config.DecisionsKey = key
+	}
+}
+
+// SignDecisions encodes and signs decisions with key, returning a value
+// suitable for DecisionsHeader.
+func SignDecisions(key []byte, decisions Decisions) (string, error) {
+	payload, err := json.Marshal(decisions)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(key, encoded)), nil
+}
+
+type decisionsKey struct{}
+
+// ContextWithDecisions returns a context carrying the signed decisions blob,
+// typically read from the DecisionsHeader of an incoming request:
+//
+//	ctx := flipt.ContextWithDecisions(r.Context(), r.Header.Get(flipt.DecisionsHeader))
+func ContextWithDecisions(ctx context.Context, signed string) context.Context {
+	if signed == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, decisionsKey{}, signed)
+}
+
+// decision returns the verified pre-resolved decision for flag carried by
+// ctx, if any, provided it was resolved for the entity of evalCtx.
+func (p *Provider) decision(ctx context.Context, flag string, evalCtx map[string]interface{}) (Decision, bool) {
+	if len(p.config.DecisionsKey) == 0 {
+		return Decision{}, false
+	}
+
+	signed, _ := ctx.Value(decisionsKey{}).(string)
+	if signed == "" {
+		return Decision{}, false
+	}
+
+	decisions, ok := verifyDecisions(p.config.DecisionsKey, signed)
+	if !ok || decisions.Namespace != p.config.Namespace || time.Now().Unix() >= decisions.ExpiresAt {
+		return Decision{}, false
+	}
+
+	targetingKey, _ := evalCtx[of.TargetingKey].(string)
+	if targetingKey == "" || decisions.Entity != DecisionsEntity(targetingKey) {
+		return Decision{}, false
+	}
+
+	d, ok := decisions.Flags[flag]
+
+	return d, ok
+}
+
+// boolean resolves a boolean flag from pre-resolved decisions or Flipt.
+func (p *Provider) boolean(ctx context.Context, flag string, evalCtx map[string]interface{}) (*evaluation.BooleanEvaluationResponse, error) {
+	if d, ok := p.decision(ctx, flag, evalCtx); ok {
+		return &evaluation.BooleanEvaluationResponse{
+			Enabled: d.Enabled,
+			Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+		}, nil
+	}
+
+	resp, err := p.cached(ctx, "boolean", flag, evalCtx, &evaluation.BooleanEvaluationResponse{}, func(ctx context.Context) (interface{}, error) {
+		return p.coalesce(ctx, "boolean", flag, evalCtx, func() (interface{}, error) {
+			var resp *evaluation.BooleanEvaluationResponse
+
+			err := p.guard(func() error {
+				return p.traced(ctx, "Boolean", flag, func(ctx context.Context) (err error) {
+					resp, err = p.svc.Boolean(ctx, p.config.Namespace, flag, evalCtx)
+					return err
+				})
+			})
+
+			return resp, err
+		})
+	})
+
+	ber, _ := resp.(*evaluation.BooleanEvaluationResponse)
+
+	// sensitive values are redacted before the error is reported anywhere
+	err = p.redactError(err, evalCtx)
+	recordError(ctx, err)
+
+	return ber, err
+}
+
+// variant resolves a variant flag from pre-resolved decisions or Flipt.
+func (p *Provider) variant(ctx context.Context, flag string, evalCtx map[string]interface{}) (*evaluation.VariantEvaluationResponse, error) {
+	if d, ok := p.decision(ctx, flag, evalCtx); ok {
+		reason := evaluation.EvaluationReason_DEFAULT_EVALUATION_REASON
+		if d.Match {
+			reason = evaluation.EvaluationReason_MATCH_EVALUATION_REASON
+		}
+
+		resp := &evaluation.VariantEvaluationResponse{
+			Match:             d.Match,
+			VariantKey:        d.Variant,
+			VariantAttachment: d.Attachment,
+			Reason:            reason,
+		}
+
+		p.traceVariant(ctx, resp)
+
+		return resp, nil
+	}
+
+	resp, err := p.cached(ctx, "variant", flag, evalCtx, &evaluation.VariantEvaluationResponse{}, func(ctx context.Context) (interface{}, error) {
+		return p.coalesce(ctx, "variant", flag, evalCtx, func() (interface{}, error) {
+			var resp *evaluation.VariantEvaluationResponse
+
+			err := p.guard(func() error {
+				return p.traced(ctx, "Evaluate", flag, func(ctx context.Context) (err error) {
+					resp, err = p.svc.Evaluate(ctx, p.config.Namespace, flag, evalCtx)
+					return err
+				})
+			})
+
+			return resp, err
+		})
+	})
+
+	ver, _ := resp.(*evaluation.VariantEvaluationResponse)
+	p.traceVariant(ctx, ver)
+
+	// sensitive values are redacted before the error is reported anywhere
+	err = p.redactError(err, evalCtx)
+	recordError(ctx, err)
+
+	return ver, err
+}
+
+// recordError records err, the error an evaluation failed with, so that the
+// failure can be told apart by its cause once reported as a resolution
+// error, which does not carry the errors it wraps.
+func recordError(ctx context.Context, err error) {
+	if info := transport.CallInfoFromContext(ctx); info != nil {
+		info.Err = err
+	}
+
+	if target, ok := ctx.Value(evaluationErrorKey{}).(*error); ok {
+		*target = err
+	}
+}
+
+// verifyDecisions decodes signed, reporting false when it is malformed or
+// its signature does not match key.
+func verifyDecisions(key []byte, signed string) (Decisions, bool) {
+	encoded, signature, ok := strings.Cut(signed, ".")
+	if !ok {
+		return Decisions{}, false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(key, encoded)) {
+		return Decisions{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Decisions{}, false
+	}
+
+	var decisions Decisions
+	if err := json.Unmarshal(payload, &decisions); err != nil {
+		return Decisions{}, false
+	}
+
+	return decisions, true
+}
+
+func sign(key []byte, encoded string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(encoded))
+
+	return h.Sum(nil)
+}
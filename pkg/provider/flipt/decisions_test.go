@@ -0,0 +1,102 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestPreResolvedDecisions(t *testing.T) {
+	key := []byte("secret")
+
+	signed, err := SignDecisions(key, Decisions{
+		Namespace: "default",
+		Entity:    DecisionsEntity("user-1"),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Flags: map[string]Decision{
+			"boolean": {Enabled: true},
+			"variant": {Match: true, Variant: "blue"},
+		},
+	})
+	require.NoError(t, err)
+
+	expired, err := SignDecisions(key, Decisions{
+		Namespace: "default",
+		Entity:    DecisionsEntity("user-1"),
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		Flags:     map[string]Decision{"variant": {Match: true, Variant: "blue"}},
+	})
+	require.NoError(t, err)
+
+	forged, err := SignDecisions([]byte("other"), Decisions{
+		Namespace: "default",
+		Entity:    DecisionsEntity("user-1"),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Flags:     map[string]Decision{"variant": {Match: true, Variant: "blue"}},
+	})
+	require.NoError(t, err)
+
+	unbound, err := SignDecisions(key, Decisions{
+		Namespace: "default",
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		Flags:     map[string]Decision{"variant": {Match: true, Variant: "blue"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		signed   string
+		flag     string
+		entity   string
+		backend  bool
+		expected string
+	}{
+		{name: "pre-resolved", signed: signed, flag: "variant", entity: "user-1", expected: "blue"},
+		{name: "not pre-resolved", signed: signed, flag: "other", entity: "user-1", backend: true, expected: "red"},
+		{name: "expired", signed: expired, flag: "variant", entity: "user-1", backend: true, expected: "red"},
+		{name: "invalid signature", signed: forged, flag: "variant", entity: "user-1", backend: true, expected: "red"},
+		{name: "malformed", signed: "garbage", flag: "variant", entity: "user-1", backend: true, expected: "red"},
+		{name: "other entity", signed: signed, flag: "variant", entity: "user-2", backend: true, expected: "red"},
+		{name: "no entity", signed: unbound, flag: "variant", entity: "user-1", backend: true, expected: "red"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := newMockService(t)
+			if tt.backend {
+				mockSvc.On("Evaluate", mock.Anything, "default", tt.flag, mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "red"}, nil)
+			}
+
+			p := NewProvider(WithService(mockSvc), WithDecisionsKey(key))
+
+			ctx := ContextWithDecisions(context.Background(), tt.signed)
+			detail := p.StringEvaluation(ctx, tt.flag, "default", of.FlattenedContext{of.TargetingKey: tt.entity})
+
+			assert.Equal(t, tt.expected, detail.Value)
+			assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+		})
+	}
+
+	t.Run("boolean", func(t *testing.T) {
+		p := NewProvider(WithService(newMockService(t)), WithDecisionsKey(key))
+
+		detail := p.BooleanEvaluation(ContextWithDecisions(context.Background(), signed), "boolean", false, of.FlattenedContext{of.TargetingKey: "user-1"})
+		assert.True(t, detail.Value)
+	})
+
+	t.Run("other namespace", func(t *testing.T) {
+		mockSvc := newMockService(t)
+		mockSvc.On("Boolean", mock.Anything, "other", "boolean", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{}, nil)
+
+		p := NewProvider(WithService(mockSvc), WithDecisionsKey(key), ForNamespace("other"))
+
+		detail := p.BooleanEvaluation(ContextWithDecisions(context.Background(), signed), "boolean", true, of.FlattenedContext{of.TargetingKey: "user-1"})
+		assert.False(t, detail.Value)
+	})
+}
@@ -0,0 +1,74 @@
+package flipt
+
+import (
+	"strings"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// Causes for which the caller's default value is returned, reported in the
+// "defaultCause" flag metadata and counted by DefaultCounts. Errors are
+// reported as "error_" followed by the lower-cased error code, e.g.
+// "error_flag_not_found".
+const (
+	DefaultCauseDisabled     = "disabled"
+	DefaultCauseNoMatch      = "no_match"
+	DefaultCauseNoAttachment = "no_attachment"
+	DefaultCauseForced       = "forced"
+)
+
+// defaultCause returns why the caller's default value was returned for the
+// resolution detail, or an empty string if the flag resolved to a value. The
+// evaluations returning the caller's default value for any other reason than
+// an error record why in the "defaultCause" flag metadata, values served by
+// the flag's own default in Flipt having the DEFAULT reason too.
+func defaultCause(detail of.ProviderResolutionDetail) string {
+	if code := detail.ResolutionDetail().ErrorCode; code != "" {
+		return "error_" + strings.ToLower(string(code))
+	}
+
+	cause, _ := detail.FlagMetadata["defaultCause"].(string)
+
+	return cause
+}
+
+// recordDefault records why the default value was returned, if it was, in
+// the flag metadata of the resolution detail and the provider's counters.
+func (p *Provider) recordDefault(detail *of.ProviderResolutionDetail) {
+	if cause := defaultCause(*detail); cause != "" {
+		p.countDefault(detail, cause)
+	}
+}
+
+// countDefault records cause in the flag metadata of the resolution detail
+// and the provider's counters.
+func (p *Provider) countDefault(detail *of.ProviderResolutionDetail, cause string) {
+	if detail.FlagMetadata == nil {
+		detail.FlagMetadata = of.FlagMetadata{}
+	}
+
+	detail.FlagMetadata["defaultCause"] = cause
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.defaults == nil {
+		p.defaults = map[string]uint64{}
+	}
+
+	p.defaults[cause]++
+}
+
+// DefaultCounts returns the number of evaluations for which the caller's
+// default value was returned, keyed by cause.
+func (p *Provider) DefaultCounts() map[string]uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	counts := make(map[string]uint64, len(p.defaults))
+	for cause, n := range p.defaults {
+		counts[cause] = n
+	}
+
+	return counts
+}
@@ -0,0 +1,70 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestDefaultCause(t *testing.T) {
+	tests := []struct {
+		name     string
+		detail   of.ProviderResolutionDetail
+		expected string
+	}{
+		{
+			name:   "match",
+			detail: of.ProviderResolutionDetail{Reason: of.TargetingMatchReason, Variant: "foo"},
+		},
+		{
+			name:   "flag default",
+			detail: of.ProviderResolutionDetail{Reason: of.DefaultReason},
+		},
+		{
+			name:     "disabled",
+			detail:   of.ProviderResolutionDetail{Reason: of.DisabledReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled}},
+			expected: DefaultCauseDisabled,
+		},
+		{
+			name:     "no match",
+			detail:   of.ProviderResolutionDetail{Reason: of.DefaultReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch}},
+			expected: DefaultCauseNoMatch,
+		},
+		{
+			name:     "error",
+			detail:   of.ProviderResolutionDetail{Reason: of.DefaultReason, ResolutionError: of.NewFlagNotFoundResolutionError("not found")},
+			expected: "error_flag_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, defaultCause(tt.detail))
+		})
+	}
+}
+
+func TestDefaultCounts(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "disabled", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Reason: evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON}, nil)
+	mockSvc.On("Evaluate", mock.Anything, "default", "missing", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("not found"))
+	mockSvc.On("Evaluate", mock.Anything, "default", "match", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "foo"}, nil)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag-default", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "bar", Reason: evaluation.EvaluationReason_DEFAULT_EVALUATION_REASON}, nil)
+	mockSvc.On("Evaluate", mock.Anything, "default", "no-match", mock.Anything).Return(&evaluation.VariantEvaluationResponse{}, nil)
+
+	p := NewProvider(WithService(mockSvc))
+
+	for _, flag := range []string{"disabled", "missing", "missing", "match", "flag-default", "no-match"} {
+		p.StringEvaluation(context.Background(), flag, "default", of.FlattenedContext{})
+	}
+
+	assert.Equal(t, map[string]uint64{
+		DefaultCauseDisabled:   1,
+		DefaultCauseNoMatch:    1,
+		"error_flag_not_found": 2,
+	}, p.DefaultCounts())
+}
@@ -0,0 +1,300 @@
+package flipt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	diskCacheExt                  = ".cache"
+	defaultDiskCacheMaxEntries    = 10000
+	defaultDiskCacheSweepInterval = time.Minute
+)
+
+// DiskCacheOption is a configuration option for a DiskCache.
+type DiskCacheOption func(*DiskCache)
+
+// WithDiskCacheMaxEntries sets the maximum number of entries stored, 10000 by
+// default. Once exceeded, expired entries are removed, followed by the least
+// recently written ones. A maximum of zero leaves the cache unbounded.
+func WithDiskCacheMaxEntries(entries int) DiskCacheOption {
+	return func(c *DiskCache) {
+		c.maxEntries = entries
+	}
+}
+
+// WithDiskCacheSweepInterval sets how often expired entries are removed, one
+// minute by default. Sweeps are run by Set, so that entries which are never
+// read again do not accumulate.
+func WithDiskCacheSweepInterval(interval time.Duration) DiskCacheOption {
+	return func(c *DiskCache) {
+		c.sweepInterval = interval
+	}
+}
+
+// DiskCache is a Cache persisting values as files in a directory, so that
+// they survive restarts: a service restarting while Flipt is unavailable
+// serves the last known values, until they expire, rather than defaults.
+type DiskCache struct {
+	dir           string
+	now           func() time.Time
+	maxEntries    int
+	sweepInterval time.Duration
+	evictions     atomic.Uint64
+
+	mu        sync.Mutex
+	entries   int
+	lastSweep time.Time
+}
+
+var _ Cache = (*DiskCache)(nil)
+
+// NewDiskCache returns a DiskCache storing values in dir, creating it if
+// necessary. The directory should not be shared with other caches.
+func NewDiskCache(dir string, opts ...DiskCacheOption) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	c := &DiskCache{
+		dir:           dir,
+		now:           time.Now,
+		maxEntries:    defaultDiskCacheMaxEntries,
+		sweepInterval: defaultDiskCacheSweepInterval,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// entries left by a previous run count towards the bound
+	if err := c.sweep(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Get implements Cache.
+func (c *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	path := c.path(key)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// entries are prefixed with their expiry in unix nanoseconds
+	if len(data) < 8 {
+		return nil, false, os.Remove(path)
+	}
+
+	if expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(data))); !c.now().Before(expiresAt) {
+		return nil, false, ignoreNotExist(os.Remove(path))
+	}
+
+	return data[8:], true, nil
+}
+
+// Set implements Cache. Entries are written to a temporary file first, so
+// that readers and crashes never observe partially written values.
+func (c *DiskCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	f, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name())
+
+	var expiresAt [8]byte
+	binary.BigEndian.PutUint64(expiresAt[:], uint64(c.now().Add(ttl).UnixNano()))
+
+	if _, err := f.Write(append(expiresAt[:], value...)); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), c.path(key)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	// overwrites are counted too, until the next sweep recounts the entries
+	c.entries++
+	due := (c.maxEntries > 0 && c.entries > c.maxEntries) ||
+		(c.sweepInterval > 0 && c.now().Sub(c.lastSweep) >= c.sweepInterval)
+	c.mu.Unlock()
+
+	if due {
+		return c.sweep()
+	}
+
+	return nil
+}
+
+// Evictions returns the number of entries removed before they expired to
+// keep the cache within its maximum number of entries.
+func (c *DiskCache) Evictions() uint64 {
+	return c.evictions.Load()
+}
+
+// sweep removes expired entries, then the least recently written ones while
+// the cache holds more than its maximum number of entries.
+func (c *DiskCache) sweep() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+
+	var (
+		now  = c.now()
+		live []entry
+	)
+
+	for _, de := range dirEntries {
+		if !strings.HasSuffix(de.Name(), diskCacheExt) {
+			continue
+		}
+
+		path := filepath.Join(c.dir, de.Name())
+
+		expired, err := diskCacheExpired(path, now)
+		if err != nil {
+			return err
+		}
+
+		if expired {
+			if err := ignoreNotExist(os.Remove(path)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		info, err := de.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		live = append(live, entry{path: path, modTime: info.ModTime()})
+	}
+
+	if c.maxEntries > 0 && len(live) > c.maxEntries {
+		sort.Slice(live, func(i, j int) bool { return live[i].modTime.Before(live[j].modTime) })
+
+		for _, e := range live[:len(live)-c.maxEntries] {
+			if err := ignoreNotExist(os.Remove(e.path)); err != nil {
+				return err
+			}
+
+			c.evictions.Add(1)
+		}
+
+		live = live[len(live)-c.maxEntries:]
+	}
+
+	c.entries = len(live)
+	c.lastSweep = now
+
+	return nil
+}
+
+// diskCacheExpired reports whether the entry stored at path expired at now.
+// Entries which were removed in the meantime or are malformed are reported
+// as expired.
+func diskCacheExpired(path string, now time.Time) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close()
+
+	var expiresAt [8]byte
+	if _, err := io.ReadFull(f, expiresAt[:]); err != nil {
+		return true, nil
+	}
+
+	return !now.Before(time.Unix(0, int64(binary.BigEndian.Uint64(expiresAt[:])))), nil
+}
+
+// Delete implements Cache.
+func (c *DiskCache) Delete(_ context.Context, key string) error {
+	return ignoreNotExist(os.Remove(c.path(key)))
+}
+
+// Flush implements Cache.
+func (c *DiskCache) Flush(context.Context) error {
+	c.mu.Lock()
+	c.entries = 0
+	c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), diskCacheExt) {
+			continue
+		}
+
+		if err := ignoreNotExist(os.Remove(filepath.Join(c.dir, entry.Name()))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// path returns the file storing key, named after its hash as keys contain
+// characters which are not valid in file names.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+diskCacheExt)
+}
+
+func ignoreNotExist(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,128 @@
+package flipt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskCache(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = filepath.Join(t.TempDir(), "cache")
+		now = time.Now()
+	)
+
+	c, err := NewDiskCache(dir)
+	require.NoError(t, err)
+
+	c.now = func() time.Time { return now }
+
+	_, ok, err := c.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "bool/default/flag/abc", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Second))
+
+	// values survive restarts
+	restarted, err := NewDiskCache(dir)
+	require.NoError(t, err)
+
+	restarted.now = c.now
+
+	v, ok, err := restarted.Get(ctx, "bool/default/flag/abc")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	// values expire after their ttl
+	now = now.Add(time.Second)
+
+	_, ok, err = c.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "bool/default/flag/abc"))
+	require.NoError(t, c.Delete(ctx, "bool/default/flag/abc"))
+
+	_, ok, _ = c.Get(ctx, "bool/default/flag/abc")
+	assert.False(t, ok)
+
+	// flushing leaves unrelated files in place
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0o600))
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+	require.NoError(t, c.Flush(ctx))
+
+	_, ok, _ = c.Get(ctx, "c")
+	assert.False(t, ok)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "README", entries[0].Name())
+}
+
+func TestDiskCache_MaxEntries(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+	)
+
+	c, err := NewDiskCache(dir, WithDiskCacheMaxEntries(2), WithDiskCacheSweepInterval(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+
+	// the least recently written entry is evicted first
+	require.NoError(t, os.Chtimes(c.path("a"), time.Now(), time.Now().Add(-2*time.Minute)))
+	require.NoError(t, os.Chtimes(c.path("b"), time.Now(), time.Now().Add(-time.Minute)))
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+
+	for _, key := range []string{"b", "c"} {
+		_, ok, _ := c.Get(ctx, key)
+		assert.True(t, ok, key)
+	}
+
+	assert.Equal(t, uint64(1), c.Evictions())
+}
+
+func TestDiskCache_Sweep(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+		now = time.Now()
+	)
+
+	c, err := NewDiskCache(dir, WithDiskCacheSweepInterval(time.Minute))
+	require.NoError(t, err)
+
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Second))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Hour))
+
+	// expired entries which are never read again are removed by the next
+	// sweep
+	now = now.Add(time.Minute)
+
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Hour))
+
+	_, err = os.Stat(c.path("a"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Zero(t, c.Evictions())
+}
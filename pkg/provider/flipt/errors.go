@@ -0,0 +1,22 @@
+package flipt
+
+import "go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+
+// Errors matching, with errors.Is, the errors returned by Health, Init and
+// NewValidatedProvider, and by the services calling Flipt, e.g. to decide
+// whether to fall back to another source of flags:
+//
+//	if err := provider.Health(ctx); errors.Is(err, flipt.ErrUnavailable) {
+//		// serve from the snapshot
+//	}
+//
+// The resolution details of evaluations only carry the OpenFeature error
+// code.
+var (
+	ErrUnavailable     = transport.ErrUnavailable
+	ErrUnauthenticated = transport.ErrUnauthenticated
+	ErrNotFound        = transport.ErrNotFound
+	ErrTimeout         = transport.ErrTimeout
+	ErrCanceled        = transport.ErrCanceled
+	ErrInvalidConfig   = transport.ErrInvalidConfig
+)
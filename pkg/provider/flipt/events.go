@@ -0,0 +1,140 @@
+package flipt
+
+import (
+	"errors"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+)
+
+var _ of.EventHandler = (*Provider)(nil)
+
+const (
+	eventBufferSize            = 16
+	defaultErrorEventThreshold = 3
+)
+
+// WithErrorEventThreshold sets the number of consecutive evaluations failing
+// because Flipt is unreachable or erroring after which the provider
+// transitions to ERROR and emits a PROVIDER_ERROR event. The provider emits
+// PROVIDER_READY once evaluations succeed again. A threshold of zero disables
+// these transitions.
+func WithErrorEventThreshold(threshold int) Option {
+	return func(p *Provider) {
+		p.config.ErrorEventThreshold = threshold
+	}
+}
+
+// EventChannel returns the channel on which the provider emits events.
+func (p *Provider) EventChannel() <-chan of.Event {
+	return p.events
+}
+
+// emit sends an event without blocking the evaluation which triggered it.
+// Events are dropped when the channel buffer is full.
+func (p *Provider) emit(eventType of.EventType, details of.ProviderEventDetails) {
+	select {
+	case p.events <- of.Event{
+		ProviderName:         p.Metadata().Name,
+		EventType:            eventType,
+		ProviderEventDetails: details,
+	}:
+	default:
+	}
+}
+
+// emitConfigChange emits a PROVIDER_CONFIGURATION_CHANGED event, forwarded
+// to the providers derived from p, which do not watch for changes themselves.
+func (p *Provider) emitConfigChange(details of.ProviderEventDetails) {
+	p.emit(of.ProviderConfigChange, details)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for derived := range p.derived {
+		derived.emit(of.ProviderConfigChange, details)
+	}
+}
+
+// observe tracks consecutive backend failures, transitioning the provider
+// between READY and ERROR and emitting the corresponding events. err is the
+// error the evaluation resolved as detail failed with, if any.
+func (p *Provider) observe(detail of.ProviderResolutionDetail, err error) {
+	threshold := p.config.ErrorEventThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	var (
+		failure = isBackendError(err)
+		event   of.EventType
+	)
+
+	p.mu.Lock()
+	if failure {
+		p.failures++
+		if p.failures >= threshold && p.status != of.ErrorState {
+			p.status = of.ErrorState
+			event = of.ProviderError
+		}
+	} else {
+		p.failures = 0
+		if p.status == of.ErrorState {
+			p.status = of.ReadyState
+			event = of.ProviderReady
+		}
+	}
+	p.mu.Unlock()
+
+	switch event {
+	case of.ProviderError:
+		p.config.Logger.Warn("flipt provider transitioned to error state", "failures", threshold, "error", detail.ResolutionDetail().ErrorMessage)
+		p.emit(event, of.ProviderEventDetails{Message: detail.ResolutionDetail().ErrorMessage})
+	case of.ProviderReady:
+		p.config.Logger.Info("flipt provider recovered")
+		p.emit(event, of.ProviderEventDetails{Message: "flipt is reachable again"})
+	}
+}
+
+// observeAuth transitions the provider to ERROR as soon as Flipt rejects its
+// credentials, without waiting for the error event threshold or the circuit
+// breaker, and back to READY once they are accepted again.
+func (p *Provider) observeAuth(err error) {
+	var (
+		unauthenticated *transport.UnauthenticatedError
+		denied          *transport.PermissionDeniedError
+		rejected        = errors.As(err, &unauthenticated) || errors.As(err, &denied)
+		event           of.EventType
+	)
+
+	if !rejected && err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	switch {
+	case rejected:
+		if !p.authRejected || p.status != of.ErrorState {
+			event = of.ProviderError
+		}
+
+		p.authRejected = true
+		p.status = of.ErrorState
+	case p.authRejected:
+		p.authRejected = false
+		if p.status == of.ErrorState {
+			p.status = of.ReadyState
+			event = of.ProviderReady
+		}
+	}
+	p.mu.Unlock()
+
+	switch event {
+	case of.ProviderError:
+		p.config.Logger.Error("flipt rejected the credentials of the provider, check its authentication configuration", "error", err)
+		p.emit(event, of.ProviderEventDetails{Message: err.Error()})
+	case of.ProviderReady:
+		p.config.Logger.Info("flipt accepted the credentials of the provider again")
+		p.emit(event, of.ProviderEventDetails{Message: "flipt accepted the credentials again"})
+	}
+}
@@ -0,0 +1,80 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestEvents(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("connection refused")).Times(3)
+	mockSvc.On("Boolean", mock.Anything, "default", "missing", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found")).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+
+	p := NewProvider(WithService(mockSvc), WithErrorEventThreshold(2))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Empty(t, p.EventChannel())
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Equal(t, of.ErrorState, p.Status())
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderError, event.EventType)
+	assert.Equal(t, "flipt-provider", event.ProviderName)
+	assert.Equal(t, "connection refused", event.Message)
+
+	// further failures do not emit additional events
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Empty(t, p.EventChannel())
+
+	// a flag not found error means flipt is reachable
+	p.BooleanEvaluation(context.Background(), "missing", false, of.FlattenedContext{})
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	event = <-p.EventChannel()
+	assert.Equal(t, of.ProviderReady, event.EventType)
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Empty(t, p.EventChannel())
+}
+
+func TestEvents_AuthRejected(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, &transport.UnauthenticatedError{Message: "token expired"}).Twice()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, &transport.PermissionDeniedError{Message: "not allowed"}).Once()
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+
+	p := NewProvider(WithService(mockSvc), WithErrorEventThreshold(0))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	// a single rejection transitions the provider
+	detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Equal(t, of.GeneralCode, detail.ResolutionDetail().ErrorCode)
+	assert.Equal(t, "flipt rejected the credentials: token expired", detail.ResolutionDetail().ErrorMessage)
+	assert.Equal(t, of.ErrorState, p.Status())
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderError, event.EventType)
+	assert.Equal(t, "flipt rejected the credentials: token expired", event.Message)
+
+	// further rejections do not emit additional events
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Empty(t, p.EventChannel())
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	event = <-p.EventChannel()
+	assert.Equal(t, of.ProviderReady, event.EventType)
+}
@@ -0,0 +1,70 @@
+package flipt
+
+import (
+	"expvar"
+	"sync/atomic"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+)
+
+// WithExpvar publishes internal counters of the provider as expvar variables
+// named after prefix, served by the /debug/vars handler of the expvar
+// package, for quick debugging without a metrics stack:
+//
+//   - <prefix>.evaluations: number of evaluations
+//   - <prefix>.requests: number of requests sent to Flipt
+//   - <prefix>.retries: number of requests retrying a failed one
+//   - <prefix>.breakerState: "closed", "open", "half-open" or "disabled"
+//   - <prefix>.cacheSize: number of cached results, when reported by the
+//     cache (e.g. LRUCache) through a Len() int method
+//
+// Variables whose name is already published, e.g. by another provider, are
+// skipped.
+func WithExpvar(prefix string) Option {
+	return func(p *Provider) {
+		p.config.ExpvarPrefix = prefix
+	}
+}
+
+// debugCounters counts the calls made by the provider, shared by providers
+// derived from the same provider.
+type debugCounters struct {
+	evaluations, requests, retries atomic.Uint64
+}
+
+// count records an evaluation which made the calls described by info.
+func (c *debugCounters) count(info *transport.CallInfo) {
+	c.evaluations.Add(1)
+	c.requests.Add(uint64(info.Attempts))
+
+	if info.Attempts > 1 {
+		c.retries.Add(uint64(info.Attempts - 1))
+	}
+}
+
+// publishExpvars publishes the counters of the provider under prefix.
+func (p *Provider) publishExpvars(prefix string) {
+	vars := map[string]func() interface{}{
+		"evaluations":  func() interface{} { return p.debugCounters.evaluations.Load() },
+		"requests":     func() interface{} { return p.debugCounters.requests.Load() },
+		"retries":      func() interface{} { return p.debugCounters.retries.Load() },
+		"breakerState": func() interface{} { return p.breaker.String() },
+		"cacheSize": func() interface{} {
+			if c, ok := p.config.Cache.(interface{ Len() int }); ok {
+				return c.Len()
+			}
+
+			return nil
+		},
+	}
+
+	for name, fn := range vars {
+		name = prefix + "." + name
+		if expvar.Get(name) != nil {
+			p.config.Logger.Warn("expvar already published, skipping", "name", name)
+			continue
+		}
+
+		expvar.Publish(name, expvar.Func(fn))
+	}
+}
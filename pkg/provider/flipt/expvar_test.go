@@ -0,0 +1,61 @@
+package flipt
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestWithExpvar(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Run(func(args mock.Arguments) {
+		transport.CallInfoFromContext(args.Get(0).(context.Context)).Attempts = 3
+	}).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Once()
+
+	p := NewProvider(
+		WithService(mockSvc),
+		WithCache(NewLRUCache(10), time.Minute),
+		WithCircuitBreaker(5, time.Minute),
+		WithExpvar("flipt_test_expvar"),
+	)
+
+	value := func(name string) string {
+		v := expvar.Get("flipt_test_expvar." + name)
+		if v == nil {
+			return ""
+		}
+
+		return v.String()
+	}
+
+	assert.Equal(t, "0", value("evaluations"))
+	assert.Equal(t, "0", value("cacheSize"))
+	assert.Equal(t, `"closed"`, value("breakerState"))
+
+	// the second evaluation is served from the cache
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+
+	assert.Equal(t, "2", value("evaluations"))
+	assert.Equal(t, "3", value("requests"))
+	assert.Equal(t, "2", value("retries"))
+	assert.Equal(t, "1", value("cacheSize"))
+
+	// the variables of another provider using the same prefix are skipped
+	NewProvider(WithService(newMockService(t)), WithExpvar("flipt_test_expvar"))
+	assert.Equal(t, "2", value("evaluations"))
+}
+
+func TestWithExpvar_Defaults(t *testing.T) {
+	NewProvider(WithService(newMockService(t)), WithExpvar("flipt_test_expvar_defaults"))
+
+	assert.Equal(t, `"disabled"`, expvar.Get("flipt_test_expvar_defaults.breakerState").String())
+	assert.Equal(t, "null", expvar.Get("flipt_test_expvar_defaults.cacheSize").String())
+}
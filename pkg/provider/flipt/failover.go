@@ -0,0 +1,329 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+// FailoverMode selects how calls are spread across multiple Flipt addresses.
+type FailoverMode int
+
+const (
+	// FailoverPriority sends calls to the first healthy address, in the order
+	// given, e.g. a primary followed by secondaries.
+	FailoverPriority FailoverMode = iota
+	// FailoverRoundRobin spreads calls across healthy addresses in turn.
+	FailoverRoundRobin
+)
+
+// defaultFailoverCooldown is how long an address is skipped after a failed call.
+const defaultFailoverCooldown = 30 * time.Second
+
+// WithAddresses sets multiple addresses of Flipt replicas, e.g. across
+// zones. Calls failing because an address is unreachable or erroring are
+// retried against the next address, and the failing address is skipped for a
+// while. All other options apply to every address.
+func WithAddresses(addresses ...string) Option {
+	return func(p *Provider) {
+		p.config.Addresses = addresses
+		if len(addresses) > 0 {
+			p.config.Address = addresses[0]
+		}
+	}
+}
+
+// WithFailoverMode sets how calls are spread across the addresses set by
+// WithAddresses. By default FailoverPriority is used.
+func WithFailoverMode(mode FailoverMode) Option {
+	return func(p *Provider) {
+		p.config.FailoverMode = mode
+	}
+}
+
+// endpoint is a single Flipt address of a failoverService.
+type endpoint struct {
+	address string
+	svc     Service
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func (e *endpoint) up(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return !now.Before(e.downUntil)
+}
+
+func (e *endpoint) markDown(until time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.downUntil = until
+}
+
+// failoverService is a Service failing over between several Flipt addresses.
+type failoverService struct {
+	endpoints []*endpoint
+	mode      FailoverMode
+	cooldown  time.Duration
+	next      atomic.Uint64
+	now       func() time.Time
+	logger    logging.Logger
+}
+
+func newFailoverService(config Config, opts []transport.Option) *failoverService {
+	s := &failoverService{
+		mode:     config.FailoverMode,
+		cooldown: defaultFailoverCooldown,
+		now:      time.Now,
+		logger:   config.Logger,
+	}
+
+	for _, address := range config.Addresses {
+		s.endpoints = append(s.endpoints, &endpoint{
+			address: address,
+			svc:     transport.New(append(opts[:len(opts):len(opts)], transport.WithAddress(address))...),
+		})
+	}
+
+	return s
+}
+
+// order returns the endpoints in the order they should be tried: healthy
+// endpoints first, then unhealthy ones as a last resort.
+func (s *failoverService) order() []*endpoint {
+	var (
+		n     = len(s.endpoints)
+		start int
+		now   = s.now()
+		up    = make([]*endpoint, 0, n)
+		down  []*endpoint
+	)
+
+	if s.mode == FailoverRoundRobin {
+		start = int((s.next.Add(1) - 1) % uint64(n))
+	}
+
+	for i := 0; i < n; i++ {
+		e := s.endpoints[(start+i)%n]
+		if e.up(now) {
+			up = append(up, e)
+		} else {
+			down = append(down, e)
+		}
+	}
+
+	return append(up, down...)
+}
+
+// call calls fn against endpoints in turn until it succeeds, fails for a
+// reason other than the endpoint being unreachable or erroring, or ctx is
+// done.
+func (s *failoverService) call(ctx context.Context, fn func(Service) error) error {
+	var err error
+
+	for _, e := range s.order() {
+		if err = fn(e.svc); err == nil || !isBackendError(err) {
+			return err
+		}
+
+		// the failure of a call whose context is done, e.g. which ran out of
+		// its caller's deadline, does not mean the endpoint is down, and every
+		// other endpoint would fail alike
+		if ctx.Err() != nil {
+			return err
+		}
+
+		e.markDown(s.now().Add(s.cooldown))
+
+		s.logger.Warn("flipt address failed, failing over", "address", e.address, "error", err)
+	}
+
+	return err
+}
+
+// isBackendError reports whether err means Flipt could not be reached or
+// failed to serve the request, as opposed to a problem with the flag or
+// evaluation context. Calls canceled by their caller are not.
+func isBackendError(err error) bool {
+	if err == nil || isCanceled(err) {
+		return false
+	}
+
+	if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout) {
+		return true
+	}
+
+	var rerr of.ResolutionError
+	if !errors.As(err, &rerr) {
+		return true
+	}
+
+	switch (of.ProviderResolutionDetail{ResolutionError: rerr}).ResolutionDetail().ErrorCode {
+	case of.ProviderNotReadyCode, of.GeneralCode:
+		return true
+	}
+
+	return false
+}
+
+// isCanceled reports whether err means the call was canceled by its caller.
+func isCanceled(err error) bool {
+	return errors.Is(err, ErrCanceled) || errors.Is(err, context.Canceled)
+}
+
+func (s *failoverService) GetFlag(ctx context.Context, namespaceKey, flagKey string) (flag *flipt.Flag, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		flag, err = svc.GetFlag(ctx, namespaceKey, flagKey)
+		return err
+	})
+
+	return flag, err
+}
+
+func (s *failoverService) Evaluate(ctx context.Context, namespaceKey, flagKey string, evalCtx map[string]interface{}) (resp *evaluation.VariantEvaluationResponse, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		resp, err = svc.Evaluate(ctx, namespaceKey, flagKey, evalCtx)
+		return err
+	})
+
+	return resp, err
+}
+
+func (s *failoverService) Boolean(ctx context.Context, namespaceKey, flagKey string, evalCtx map[string]interface{}) (resp *evaluation.BooleanEvaluationResponse, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		resp, err = svc.Boolean(ctx, namespaceKey, flagKey, evalCtx)
+		return err
+	})
+
+	return resp, err
+}
+
+// ListFlags lists flags from the first endpoint able to serve them.
+func (s *failoverService) ListFlags(ctx context.Context, namespaceKey string) (flags []*flipt.Flag, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		lister, ok := svc.(interface {
+			ListFlags(ctx context.Context, namespaceKey string) ([]*flipt.Flag, error)
+		})
+		if !ok {
+			return errors.ErrUnsupported
+		}
+
+		flags, err = lister.ListFlags(ctx, namespaceKey)
+
+		return err
+	})
+
+	return flags, err
+}
+
+// ListRules lists the rules of a flag from the first endpoint able to serve
+// them.
+func (s *failoverService) ListRules(ctx context.Context, namespaceKey, flagKey string) (rules []*flipt.Rule, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		lister, ok := svc.(targetingLister)
+		if !ok {
+			return errors.ErrUnsupported
+		}
+
+		rules, err = lister.ListRules(ctx, namespaceKey, flagKey)
+
+		return err
+	})
+
+	return rules, err
+}
+
+// ListRollouts lists the rollouts of a flag from the first endpoint able to
+// serve them.
+func (s *failoverService) ListRollouts(ctx context.Context, namespaceKey, flagKey string) (rollouts []*flipt.Rollout, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		lister, ok := svc.(targetingLister)
+		if !ok {
+			return errors.ErrUnsupported
+		}
+
+		rollouts, err = lister.ListRollouts(ctx, namespaceKey, flagKey)
+
+		return err
+	})
+
+	return rollouts, err
+}
+
+// ListSegments lists segments from the first endpoint able to serve them.
+func (s *failoverService) ListSegments(ctx context.Context, namespaceKey string) (segments []*flipt.Segment, err error) {
+	err = s.call(ctx, func(svc Service) (err error) {
+		lister, ok := svc.(targetingLister)
+		if !ok {
+			return errors.ErrUnsupported
+		}
+
+		segments, err = lister.ListSegments(ctx, namespaceKey)
+
+		return err
+	})
+
+	return segments, err
+}
+
+// Check reports whether any of the endpoints is ready to serve evaluations.
+func (s *failoverService) Check(ctx context.Context) error {
+	return s.call(ctx, func(svc Service) error {
+		if c, ok := svc.(interface{ Check(context.Context) error }); ok {
+			return c.Check(ctx)
+		}
+
+		return nil
+	})
+}
+
+// CheckAccess reports whether any of the endpoints accepts the credentials.
+func (s *failoverService) CheckAccess(ctx context.Context, namespaceKey string) error {
+	return s.call(ctx, func(svc Service) error {
+		if c, ok := svc.(interface {
+			CheckAccess(ctx context.Context, namespaceKey string) error
+		}); ok {
+			return c.CheckAccess(ctx, namespaceKey)
+		}
+
+		return nil
+	})
+}
+
+// Validate validates the configuration of every endpoint.
+func (s *failoverService) Validate() error {
+	var errs []error
+
+	for _, e := range s.endpoints {
+		if v, ok := e.svc.(interface{ Validate() error }); ok {
+			errs = append(errs, v.Validate())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes the connections of every endpoint.
+func (s *failoverService) Close() error {
+	var errs []error
+
+	for _, e := range s.endpoints {
+		if c, ok := e.svc.(interface{ Close() error }); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func newTestFailoverService(mode FailoverMode, svcs ...Service) *failoverService {
+	s := &failoverService{
+		mode:     mode,
+		cooldown: time.Minute,
+		now:      time.Now,
+		logger:   logging.Nop(),
+	}
+
+	for i, svc := range svcs {
+		s.endpoints = append(s.endpoints, &endpoint{address: string(rune('a' + i)), svc: svc})
+	}
+
+	return s
+}
+
+// canceledError returns the error of a call to Flipt canceled by its caller.
+func canceledError() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return transport.ContextError(ctx)
+}
+
+func TestFailover_Priority(t *testing.T) {
+	primary := newMockService(t)
+	primary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("connection refused")).Once()
+
+	secondary := newMockService(t)
+	secondary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Twice()
+
+	s := newTestFailoverService(FailoverPriority, primary, secondary)
+
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	resp, err := s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.True(t, resp.Enabled)
+
+	// the failed primary is skipped during the cooldown
+	_, err = s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	require.NoError(t, err)
+
+	// and tried again afterwards
+	now = now.Add(time.Minute)
+	primary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{}, nil).Once()
+
+	resp, err = s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.False(t, resp.Enabled)
+}
+
+func TestFailover_RoundRobin(t *testing.T) {
+	first := newMockService(t)
+	first.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{VariantKey: "first"}, nil).Twice()
+
+	second := newMockService(t)
+	second.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{VariantKey: "second"}, nil).Once()
+
+	s := newTestFailoverService(FailoverRoundRobin, first, second)
+
+	var variants []string
+
+	for i := 0; i < 3; i++ {
+		resp, err := s.Evaluate(context.Background(), "default", "flag", map[string]interface{}{})
+		require.NoError(t, err)
+
+		variants = append(variants, resp.VariantKey)
+	}
+
+	assert.Equal(t, []string{"first", "second", "first"}, variants)
+}
+
+func TestFailover_NotBackendFailure(t *testing.T) {
+	primary := newMockService(t)
+	primary.On("Boolean", mock.Anything, "default", "missing", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found")).Once()
+
+	// a missing flag is not failed over, as every replica serves the same flags
+	s := newTestFailoverService(FailoverPriority, primary, newMockService(t))
+
+	_, err := s.Boolean(context.Background(), "default", "missing", map[string]interface{}{})
+	assert.EqualError(t, err, "FLAG_NOT_FOUND: flag not found")
+}
+
+func TestFailover_Canceled(t *testing.T) {
+	primary := newMockService(t)
+	primary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, canceledError()).Once()
+
+	// a call canceled by its caller neither fails over nor marks the address down
+	s := newTestFailoverService(FailoverPriority, primary, newMockService(t))
+
+	_, err := s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	assert.EqualError(t, err, "GENERAL: canceled: context canceled")
+	assert.True(t, s.endpoints[0].up(time.Now()))
+}
+
+func TestFailover_ContextDone(t *testing.T) {
+	primary := newMockService(t)
+	primary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("connection refused")).Once()
+
+	// the remaining addresses are not tried once the context is done, nor is
+	// the address marked down for a failure the caller's context may cause
+	s := newTestFailoverService(FailoverPriority, primary, newMockService(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Boolean(ctx, "default", "flag", map[string]interface{}{})
+	assert.EqualError(t, err, "PROVIDER_NOT_READY: connection refused")
+	assert.True(t, s.endpoints[0].up(time.Now()))
+}
+
+func TestFailover_AllFailing(t *testing.T) {
+	primary := newMockService(t)
+	primary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("primary down")).Twice()
+
+	secondary := newMockService(t)
+	secondary.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("secondary down")).Twice()
+
+	s := newTestFailoverService(FailoverPriority, primary, secondary)
+
+	_, err := s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	assert.EqualError(t, err, "PROVIDER_NOT_READY: secondary down")
+
+	// addresses which are down are still tried as a last resort
+	_, err = s.Boolean(context.Background(), "default", "flag", map[string]interface{}{})
+	assert.EqualError(t, err, "PROVIDER_NOT_READY: secondary down")
+}
+
+func TestWithAddresses(t *testing.T) {
+	p := NewProvider(WithAddresses("http://flipt-a:8080", "http://flipt-b:8080"), WithFailoverMode(FailoverRoundRobin))
+
+	s, ok := p.svc.(*failoverService)
+	require.True(t, ok)
+	require.Len(t, s.endpoints, 2)
+	assert.Equal(t, "http://flipt-b:8080", s.endpoints[1].address)
+	assert.Equal(t, FailoverRoundRobin, s.mode)
+
+	assert.NoError(t, p.Close())
+
+	_, ok = NewProvider(WithAddresses("http://flipt:8080")).svc.(*failoverService)
+	assert.False(t, ok)
+}
@@ -0,0 +1,54 @@
+package flipt
+
+import (
+	"context"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+)
+
+// WithLoggingHook enables a hook, returned from Hooks, which logs every
+// evaluation (flag key, variant, reason, duration and error) to logger. The
+// provider's logger is used when logger is nil.
+func WithLoggingHook(logger logging.Logger) Option {
+	return func(p *Provider) {
+		p.config.LoggingHook = true
+		p.config.LoggingHookLogger = logger
+	}
+}
+
+// WithHooks adds hooks, returned from Hooks, which run for every evaluation
+// by any client using the provider.
+func WithHooks(hooks ...of.Hook) Option {
+	return func(p *Provider) {
+		p.config.Hooks = append(p.config.Hooks, hooks...)
+	}
+}
+
+// loggingHook logs the outcome of evaluations.
+type loggingHook struct {
+	of.UnimplementedHook
+
+	logger logging.Logger
+}
+
+func (h loggingHook) After(_ context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, _ of.HookHints) error {
+	kv := []interface{}{
+		"flag", hookContext.FlagKey(),
+		"variant", details.Variant,
+		"reason", details.Reason,
+	}
+
+	// the duration is only known when Flipt was called
+	if latency, ok := details.FlagMetadata["backendLatencyMillis"]; ok {
+		kv = append(kv, "durationMillis", latency)
+	}
+
+	h.logger.Debug("flag evaluated", kv...)
+
+	return nil
+}
+
+func (h loggingHook) Error(_ context.Context, hookContext of.HookContext, err error, _ of.HookHints) {
+	h.logger.Warn("flag evaluation failed", "flag", hookContext.FlagKey(), "error", err)
+}
@@ -0,0 +1,60 @@
+package flipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+)
+
+func TestLoggingHook(t *testing.T) {
+	assert.Empty(t, NewProvider().Hooks())
+
+	var buf bytes.Buffer
+
+	p := NewProvider(WithLoggingHook(logging.Std(log.New(&buf, "", 0), true)))
+	require.Len(t, p.Hooks(), 1)
+
+	hook := p.Hooks()[0]
+	hookContext := of.NewHookContext("flag", of.String, "default", of.NewClientMetadata("test"), p.Metadata(), of.NewEvaluationContext("user", nil))
+
+	err := hook.After(context.Background(), hookContext, of.InterfaceEvaluationDetails{
+		Value: "abc",
+		EvaluationDetails: of.EvaluationDetails{
+			FlagKey: "flag",
+			ResolutionDetail: of.ResolutionDetail{
+				Variant:      "abc",
+				Reason:       of.TargetingMatchReason,
+				FlagMetadata: of.FlagMetadata{"backendLatencyMillis": 1.5},
+			},
+		},
+	}, of.HookHints{})
+	require.NoError(t, err)
+
+	hook.Error(context.Background(), hookContext, errors.New("flag not found"), of.HookHints{})
+
+	assert.Equal(t, "DEBUG flag evaluated flag=flag variant=abc reason=TARGETING_MATCH durationMillis=1.5\n"+
+		"WARN flag evaluation failed flag=flag error=flag not found\n", buf.String())
+}
+
+func TestWithHooks(t *testing.T) {
+	var (
+		first  = &of.UnimplementedHook{}
+		second = &of.UnimplementedHook{}
+	)
+
+	p := NewProvider(WithHooks(first), WithHooks(second), WithLoggingHook(nil))
+	require.Len(t, p.Hooks(), 3)
+
+	assert.IsType(t, loggingHook{}, p.Hooks()[0])
+	assert.Same(t, first, p.Hooks()[1])
+	assert.Same(t, second, p.Hooks()[2])
+
+	assert.Len(t, p.WithStaticContext(map[string]interface{}{"region": "eu"}).Hooks(), 3)
+}
@@ -0,0 +1,189 @@
+package flipt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+)
+
+const (
+	// webhookSignatureHeader carries the hex encoded HMAC-SHA256 of the body
+	// of requests sent by Flipt's webhook audit sink.
+	webhookSignatureHeader = "X-Flipt-Webhook-Signature"
+	maxInvalidationBody    = 1 << 20
+)
+
+// WithInvalidationSecret sets the signing secret configured for Flipt's
+// webhook audit sink. InvalidationHandler rejects requests which are not
+// signed with it.
+func WithInvalidationSecret(secret string) Option {
+	return func(p *Provider) {
+		p.config.InvalidationSecret = secret
+	}
+}
+
+// WithUnauthenticatedInvalidation makes InvalidationHandler accept unsigned
+// requests when no secret is set with WithInvalidationSecret, rather than
+// rejecting every request.
+//
+// Warning: anyone able to reach the handler can then flush the cache of the
+// provider at will, sending every evaluation to Flipt. Only use it when the
+// handler is otherwise protected, e.g. by network policy or an
+// authenticating proxy.
+func WithUnauthenticatedInvalidation() Option {
+	return func(p *Provider) {
+		p.config.UnauthenticatedInvalidation = true
+	}
+}
+
+// auditEvent is the subset of a Flipt audit event identifying what changed.
+type auditEvent struct {
+	Type    string `json:"type"`
+	Action  string `json:"action"`
+	Payload struct {
+		Key          string `json:"key"`
+		FlagKey      string `json:"flag_key"`
+		NamespaceKey string `json:"namespace_key"`
+	} `json:"payload"`
+}
+
+// InvalidationHandler returns an HTTP handler accepting Flipt audit events,
+// e.g. from Flipt's webhook audit sink, which invalidates the cached
+// evaluations of the flags affected by each event and emits a
+// PROVIDER_CONFIGURATION_CHANGED event (or broadcasts the invalidation, see
+// WithInvalidator). It allows long cache TTLs (see
+// WithCache) while keeping evaluations fresh. Changes to segments invalidate
+// all cached evaluations of the provider's namespace. Caches without a
+// DeletePrefix(ctx, prefix) method, such as DiskCache, are flushed entirely.
+//
+// Requests are verified using the secret set with WithInvalidationSecret.
+// Without a secret, every request is rejected unless
+// WithUnauthenticatedInvalidation is set.
+func (p *Provider) InvalidationHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if p.config.InvalidationSecret == "" && !p.config.UnauthenticatedInvalidation {
+			http.Error(w, "invalidation secret not configured", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxInvalidationBody))
+		if err != nil {
+			http.Error(w, "reading body", http.StatusBadRequest)
+			return
+		}
+
+		if !p.verifyWebhook(body, r.Header.Get(webhookSignatureHeader)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		events, err := decodeAuditEvents(body)
+		if err != nil {
+			http.Error(w, "decoding events: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p.invalidate(r.Context(), events)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (p *Provider) verifyWebhook(body []byte, signature string) bool {
+	if p.config.InvalidationSecret == "" {
+		return true
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.config.InvalidationSecret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// decodeAuditEvents decodes a single audit event or a list of them.
+func decodeAuditEvents(body []byte) ([]auditEvent, error) {
+	var events []auditEvent
+	if err := json.Unmarshal(body, &events); err == nil {
+		return events, nil
+	}
+
+	var event auditEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	return []auditEvent{event}, nil
+}
+
+// invalidate removes the cached evaluations affected by events, broadcasting
+// the invalidation when an invalidator is set.
+func (p *Provider) invalidate(ctx context.Context, events []auditEvent) {
+	var (
+		flags     = map[string]bool{}
+		namespace bool
+	)
+
+	for _, e := range events {
+		if e.Type == "namespace" {
+			namespace = namespace || e.Payload.Key == p.config.Namespace
+			continue
+		}
+
+		ns := e.Payload.NamespaceKey
+		if ns == "" {
+			ns = "default"
+		}
+
+		if ns != p.config.Namespace {
+			continue
+		}
+
+		switch e.Type {
+		case "flag":
+			flags[e.Payload.Key] = true
+		case "variant", "rule", "rollout", "distribution":
+			// some payloads only reference their flag by ID
+			if e.Payload.FlagKey == "" {
+				namespace = true
+			} else {
+				flags[e.Payload.FlagKey] = true
+			}
+		case "segment", "constraint":
+			namespace = true
+		}
+	}
+
+	if namespace {
+		p.broadcast(ctx, Invalidation{Namespace: p.config.Namespace})
+		return
+	}
+
+	if len(flags) == 0 {
+		return
+	}
+
+	changed := make([]string, 0, len(flags))
+	for flag := range flags {
+		changed = append(changed, flag)
+	}
+
+	sort.Strings(changed)
+
+	p.broadcast(ctx, Invalidation{Namespace: p.config.Namespace, Flags: changed})
+}
@@ -0,0 +1,142 @@
+package flipt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidationHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		body    string
+		cleared []string
+		changes []string
+	}{
+		{
+			name:    "flag updated",
+			body:    `{"version":"0.1","type":"flag","action":"updated","payload":{"key":"a","namespace_key":"default","enabled":true}}`,
+			cleared: []string{"default/a/boolean/1", "default/a/variant/1"},
+			changes: []string{"a"},
+		},
+		{
+			name:    "rules of several flags",
+			body:    `[{"type":"rule","action":"created","payload":{"flag_key":"b","namespace_key":"default"}},{"type":"variant","action":"deleted","payload":{"flag_key":"a"}}]`,
+			cleared: []string{"default/a/boolean/1", "default/a/variant/1", "default/b/boolean/1"},
+			changes: []string{"a", "b"},
+		},
+		{
+			name:    "segment updated",
+			body:    `{"type":"segment","action":"updated","payload":{"key":"users","namespace_key":"default"}}`,
+			cleared: []string{"default/a/boolean/1", "default/a/variant/1", "default/b/boolean/1"},
+		},
+		{
+			name: "other namespace",
+			body: `{"type":"flag","action":"updated","payload":{"key":"a","namespace_key":"staging"}}`,
+		},
+		{
+			name: "token created",
+			body: `{"type":"token","action":"created","payload":{"name":"ci"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			cache := NewLRUCache(10)
+			for _, key := range []string{"default/a/boolean/1", "default/a/variant/1", "default/b/boolean/1", "staging/a/boolean/1"} {
+				require.NoError(t, cache.Set(ctx, key, []byte("{}"), time.Minute))
+			}
+
+			p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute), WithUnauthenticatedInvalidation())
+
+			rec := httptest.NewRecorder()
+			p.InvalidationHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+
+			for _, key := range []string{"default/a/boolean/1", "default/a/variant/1", "default/b/boolean/1", "staging/a/boolean/1"} {
+				_, ok, _ := cache.Get(ctx, key)
+				assert.Equal(t, !contains(tt.cleared, key), ok, key)
+			}
+
+			if tt.cleared == nil {
+				assert.Empty(t, p.EventChannel())
+				return
+			}
+
+			event := <-p.EventChannel()
+			assert.Equal(t, of.ProviderConfigChange, event.EventType)
+			assert.Equal(t, tt.changes, event.FlagChanges)
+		})
+	}
+}
+
+func TestInvalidationHandlerSignature(t *testing.T) {
+	var (
+		body = `{"type":"flag","action":"updated","payload":{"key":"a","namespace_key":"default"}}`
+		mac  = hmac.New(sha256.New, []byte("secret"))
+	)
+
+	mac.Write([]byte(body))
+
+	h := NewProvider(WithService(newMockService(t)), WithCache(NewLRUCache(10), time.Minute), WithInvalidationSecret("secret")).InvalidationHandler()
+
+	for _, tt := range []struct {
+		name      string
+		signature string
+		code      int
+	}{
+		{name: "valid", signature: hex.EncodeToString(mac.Sum(nil)), code: http.StatusNoContent},
+		{name: "invalid", signature: hex.EncodeToString([]byte("nope")), code: http.StatusUnauthorized},
+		{name: "missing", code: http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("X-Flipt-Webhook-Signature", tt.signature)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
+
+func TestInvalidationHandlerNoSecret(t *testing.T) {
+	// requests are rejected unless unauthenticated invalidation is opted in
+	h := NewProvider(WithService(newMockService(t)), WithCache(NewLRUCache(10), time.Minute)).InvalidationHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"type":"flag","action":"updated","payload":{"key":"a"}}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestInvalidationHandlerBadRequests(t *testing.T) {
+	h := NewProvider(WithService(newMockService(t)), WithUnauthenticatedInvalidation()).InvalidationHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
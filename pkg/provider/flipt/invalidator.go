@@ -0,0 +1,165 @@
+package flipt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+const invalidatorRetryInterval = 5 * time.Second
+
+// Invalidation identifies cached evaluations to invalidate after flags
+// changed.
+type Invalidation struct {
+	Namespace string `json:"namespace"`
+	// Flags are the keys of the flags which changed. When empty all flags
+	// of the namespace are invalidated.
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Invalidator broadcasts invalidations between the provider instances of a
+// fleet, e.g. over a pub/sub channel, so that a change detected by one
+// instance invalidates the caches of all of them.
+type Invalidator interface {
+	// Publish broadcasts inv to all subscribers, including the publisher.
+	Publish(ctx context.Context, inv Invalidation) error
+	// Subscribe calls fn for each invalidation published until ctx is done
+	// or the subscription fails. It calls subscribed once the subscription
+	// is established, before delivering any invalidation.
+	Subscribe(ctx context.Context, subscribed func(), fn func(Invalidation)) error
+}
+
+// WithInvalidator broadcasts the flag changes detected by the provider,
+// whether by polling (see WithChangePollInterval) or through the
+// InvalidationHandler, using invalidator. The provider subscribes to the
+// invalidator once initialized and applies the invalidations published for
+// its namespace, including its own.
+func WithInvalidator(invalidator Invalidator) Option {
+	return func(p *Provider) {
+		p.config.Invalidator = invalidator
+	}
+}
+
+// startInvalidations subscribes to the invalidator, unless none is set or the
+// subscription is already running.
+func (p *Provider) startInvalidations() {
+	if p.config.Invalidator == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopInvalidations != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopInvalidations = cancel
+
+	go p.subscribe(ctx)
+}
+
+// stopInvalidationSubscription stops the subscription to the invalidator,
+// if running.
+func (p *Provider) stopInvalidationSubscription() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopInvalidations != nil {
+		p.stopInvalidations()
+		p.stopInvalidations = nil
+	}
+}
+
+// subscribe applies published invalidations until ctx is done, subscribing
+// again whenever the subscription fails. Invalidations published while the
+// subscription was down are lost, so the whole namespace is invalidated once
+// subscribed again.
+func (p *Provider) subscribe(ctx context.Context) {
+	for resubscribe := false; ; resubscribe = true {
+		subscribed := func() {
+			if resubscribe {
+				p.applyInvalidation(ctx, Invalidation{Namespace: p.config.Namespace})
+			}
+		}
+
+		err := p.config.Invalidator.Subscribe(ctx, subscribed, func(inv Invalidation) {
+			if inv.Namespace == p.config.Namespace {
+				p.applyInvalidation(ctx, inv)
+			}
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.config.Logger.Warn("subscription to invalidations failed", "error", err)
+
+		if !sleep(ctx, invalidatorRetryInterval) {
+			return
+		}
+	}
+}
+
+// broadcast publishes inv using the invalidator, or applies it directly when
+// none is set or publishing fails.
+func (p *Provider) broadcast(ctx context.Context, inv Invalidation) {
+	if p.config.Invalidator != nil {
+		err := p.config.Invalidator.Publish(ctx, inv)
+		if err == nil {
+			return
+		}
+
+		p.config.Logger.Warn("publishing invalidation failed", "namespace", inv.Namespace, "error", err)
+	}
+
+	p.applyInvalidation(ctx, inv)
+}
+
+// applyInvalidation removes the cached evaluations identified by inv, along
+// with the targeting listed for split reasons, and emits a
+// PROVIDER_CONFIGURATION_CHANGED event.
+func (p *Provider) applyInvalidation(ctx context.Context, inv Invalidation) {
+	if p.splits != nil && inv.Namespace == p.config.Namespace {
+		p.splits.forget(inv.Flags)
+	}
+
+	if len(inv.Flags) == 0 {
+		if err := p.FlushNamespace(ctx, inv.Namespace); err != nil {
+			p.config.Logger.Warn("invalidating cache failed", "namespace", inv.Namespace, "error", err)
+		}
+
+		p.emitConfigChange(of.ProviderEventDetails{
+			Message: fmt.Sprintf("flags changed in namespace %q", inv.Namespace),
+		})
+
+		return
+	}
+
+	for _, flag := range inv.Flags {
+		if err := p.FlushFlag(ctx, inv.Namespace, flag); err != nil {
+			p.config.Logger.Warn("invalidating cache failed", "namespace", inv.Namespace, "flag", flag, "error", err)
+		}
+	}
+
+	p.emitConfigChange(of.ProviderEventDetails{
+		Message:     fmt.Sprintf("%d flag(s) changed in namespace %q", len(inv.Flags), inv.Namespace),
+		FlagChanges: inv.Flags,
+	})
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,184 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryInvalidator broadcasts invalidations to the subscribers in process.
+type memoryInvalidator struct {
+	mu          sync.Mutex
+	subscribers []chan Invalidation
+	err         error
+}
+
+func (m *memoryInvalidator) Publish(_ context.Context, inv Invalidation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, s := range m.subscribers {
+		s <- inv
+	}
+
+	return nil
+}
+
+func (m *memoryInvalidator) Subscribe(ctx context.Context, subscribed func(), fn func(Invalidation)) error {
+	ch := make(chan Invalidation, 16)
+
+	m.mu.Lock()
+	m.subscribers = append(m.subscribers, ch)
+	m.mu.Unlock()
+
+	subscribed()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case inv, ok := <-ch:
+			if !ok {
+				return errors.New("disconnected")
+			}
+
+			fn(inv)
+		}
+	}
+}
+
+// disconnect ends all subscriptions with an error.
+func (m *memoryInvalidator) disconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.subscribers {
+		close(s)
+	}
+
+	m.subscribers = nil
+}
+
+func (m *memoryInvalidator) subscribed() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.subscribers)
+}
+
+func TestWithInvalidator(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		invalidator = &memoryInvalidator{}
+		caches      []*LRUCache
+		providers   []*Provider
+	)
+
+	for i := 0; i < 2; i++ {
+
+		cache := NewLRUCache(10)
+		require.NoError(t, cache.Set(ctx, "default/a/boolean/1", []byte("{}"), time.Minute))
+		require.NoError(t, cache.Set(ctx, "default/b/boolean/1", []byte("{}"), time.Minute))
+
+		p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute), WithInvalidator(invalidator), WithUnauthenticatedInvalidation())
+		require.NoError(t, p.Init(of.EvaluationContext{}))
+		t.Cleanup(p.Shutdown)
+
+		caches = append(caches, cache)
+		providers = append(providers, p)
+	}
+
+	require.Eventually(t, func() bool { return invalidator.subscribed() == 2 }, time.Second, time.Millisecond)
+
+	// a webhook received by one instance invalidates the caches of all of them
+	rec := httptest.NewRecorder()
+	providers[0].InvalidationHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader(`{"type":"flag","action":"updated","payload":{"key":"a","namespace_key":"default"}}`)))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	for i, p := range providers {
+		select {
+		case event := <-p.EventChannel():
+			assert.Equal(t, of.ProviderConfigChange, event.EventType)
+			assert.Equal(t, []string{"a"}, event.FlagChanges)
+		case <-time.After(time.Second):
+			t.Fatal("invalidation was not applied")
+		}
+
+		_, ok, _ := caches[i].Get(ctx, "default/a/boolean/1")
+		assert.False(t, ok)
+
+		_, ok, _ = caches[i].Get(ctx, "default/b/boolean/1")
+		assert.True(t, ok)
+	}
+
+	// invalidations of other namespaces are ignored
+	require.NoError(t, invalidator.Publish(ctx, Invalidation{Namespace: "staging"}))
+
+	_, ok, _ := caches[1].Get(ctx, "default/b/boolean/1")
+	assert.True(t, ok)
+}
+
+func TestWithInvalidatorPublishFailure(t *testing.T) {
+	ctx := context.Background()
+
+	cache := NewLRUCache(10)
+	require.NoError(t, cache.Set(ctx, "default/a/variant/1", []byte("{}"), time.Minute))
+
+	p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute), WithInvalidator(&memoryInvalidator{err: errors.New("unreachable")}))
+
+	// the invalidation is applied locally instead
+	p.broadcast(ctx, Invalidation{Namespace: "default"})
+
+	_, ok, _ := cache.Get(ctx, "default/a/variant/1")
+	assert.False(t, ok)
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderConfigChange, event.EventType)
+}
+
+func TestWithInvalidatorResubscribe(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		invalidator = &memoryInvalidator{}
+		cache       = NewLRUCache(10)
+	)
+
+	p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute), WithInvalidator(invalidator))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+	t.Cleanup(p.Shutdown)
+
+	require.Eventually(t, func() bool { return invalidator.subscribed() == 1 }, time.Second, time.Millisecond)
+
+	invalidator.disconnect()
+
+	// changes published while the subscription is down are missed
+	require.NoError(t, cache.Set(ctx, "default/a/boolean/1", []byte("{}"), time.Minute))
+	require.NoError(t, invalidator.Publish(ctx, Invalidation{Namespace: "default", Flags: []string{"a"}}))
+
+	select {
+	case event := <-p.EventChannel():
+		assert.Equal(t, of.ProviderConfigChange, event.EventType)
+		assert.Empty(t, event.FlagChanges)
+	case <-time.After(2 * invalidatorRetryInterval):
+		t.Fatal("namespace was not invalidated once subscribed again")
+	}
+
+	assert.Equal(t, 1, invalidator.subscribed())
+
+	_, ok, _ := cache.Get(ctx, "default/a/boolean/1")
+	assert.False(t, ok)
+}
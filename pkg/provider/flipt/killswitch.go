@@ -0,0 +1,56 @@
+package flipt
+
+import (
+	"sync"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// killSwitch holds the flags for which the code default is forced. It is
+// shared by a provider and the providers derived from it.
+type killSwitch struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+// WithForcedDefaults forces the code default to be returned for the given
+// flags, identified as "namespace/flag", regardless of the state of Flipt.
+func WithForcedDefaults(flags ...string) Option {
+	return func(p *Provider) {
+		p.config.ForcedDefaults = append(p.config.ForcedDefaults, flags...)
+	}
+}
+
+// ForceDefault enables or disables returning the code default for the flag,
+// identified as "namespace/flag", regardless of the state of Flipt. It is
+// intended for emergency mitigation when a flag's targeting misbehaves and
+// applies to the provider and all providers derived from it.
+func (p *Provider) ForceDefault(flag string, force bool) {
+	p.killSwitch.mu.Lock()
+	defer p.killSwitch.mu.Unlock()
+
+	if force {
+		p.killSwitch.flags[flag] = true
+	} else {
+		delete(p.killSwitch.flags, flag)
+	}
+}
+
+// forcedDefault returns the resolution detail to use alongside the code
+// default when it is forced for flag.
+func (p *Provider) forcedDefault(flag string) (of.ProviderResolutionDetail, bool) {
+	p.killSwitch.mu.RLock()
+	forced := p.killSwitch.flags[p.config.Namespace+"/"+flag]
+	p.killSwitch.mu.RUnlock()
+
+	if !forced {
+		return of.ProviderResolutionDetail{}, false
+	}
+
+	p.config.Logger.Debug("returning forced default", "namespace", p.config.Namespace, "flag", flag)
+
+	detail := of.ProviderResolutionDetail{Reason: of.DisabledReason}
+	p.countDefault(&detail, DefaultCauseForced)
+
+	return detail, true
+}
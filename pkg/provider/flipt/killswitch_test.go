@@ -0,0 +1,35 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestForceDefault(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "risky-flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Once()
+
+	p := NewProvider(WithService(mockSvc), WithForcedDefaults("default/other-flag"))
+	derived := p.WithStaticContext(map[string]interface{}{"region": "eu"})
+
+	detail := p.StringEvaluation(context.Background(), "other-flag", "default", of.FlattenedContext{})
+	assert.Equal(t, "default", detail.Value)
+	assert.Equal(t, of.DisabledReason, detail.Reason)
+	assert.Equal(t, DefaultCauseForced, detail.FlagMetadata["defaultCause"])
+
+	p.ForceDefault("default/risky-flag", true)
+
+	assert.False(t, p.BooleanEvaluation(context.Background(), "risky-flag", false, of.FlattenedContext{}).Value)
+	assert.False(t, derived.BooleanEvaluation(context.Background(), "risky-flag", false, of.FlattenedContext{}).Value)
+
+	p.ForceDefault("default/risky-flag", false)
+
+	assert.True(t, p.BooleanEvaluation(context.Background(), "risky-flag", false, of.FlattenedContext{}).Value)
+
+	assert.Equal(t, map[string]uint64{DefaultCauseForced: 2}, p.DefaultCounts())
+}
@@ -0,0 +1,182 @@
+package flipt
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// maxLatencySamples bounds the number of latencies kept per window. Windows
+// with more calls are sampled uniformly.
+const maxLatencySamples = 4096
+
+// WithLatencyBudget enables the detection of a degraded Flipt: when the 99th
+// percentile of the time spent waiting on Flipt by the evaluations of a
+// window exceeds budget, the provider transitions to STALE and emits a
+// PROVIDER_STALE event, and back to READY with a PROVIDER_READY event after
+// a window within budget. Windows are evaluated as they end, i.e. on the
+// first evaluation calling Flipt after that. With WithMetricsHook, the 99th
+// percentile of the last window and the number of windows over budget are
+// reported as metrics.
+func WithLatencyBudget(budget, window time.Duration) Option {
+	return func(p *Provider) {
+		p.config.LatencyBudget = budget
+		p.config.LatencyWindow = window
+	}
+}
+
+// latencyMonitor computes the 99th percentile of the latencies recorded over
+// consecutive windows, shared by providers derived with WithStaticContext.
+type latencyMonitor struct {
+	budget time.Duration
+	window time.Duration
+
+	mu      sync.Mutex
+	start   time.Time
+	calls   int
+	samples []time.Duration
+	// p99 is the 99th percentile of the last window.
+	p99 time.Duration
+	// exceeded counts the windows over budget.
+	exceeded int64
+	// slow is set while the last window was over budget.
+	slow bool
+}
+
+func newLatencyMonitor(config Config) *latencyMonitor {
+	if config.LatencyBudget <= 0 || config.LatencyWindow <= 0 {
+		return nil
+	}
+
+	return &latencyMonitor{budget: config.LatencyBudget, window: config.LatencyWindow, start: time.Now()}
+}
+
+// record adds latency to the current window. When the window has ended, a
+// new one is started and the 99th percentile of the ended one is returned,
+// along with whether it was over budget and whether that changed.
+func (m *latencyMonitor) record(latency time.Duration, now time.Time) (p99 time.Duration, slow, changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// windows without calls leave the last percentile untouched
+	if now.Sub(m.start) >= m.window {
+		if len(m.samples) > 0 {
+			m.p99 = percentile(m.samples, 0.99)
+
+			slow := m.p99 > m.budget
+			if slow {
+				m.exceeded++
+			}
+
+			changed = slow != m.slow
+			m.slow = slow
+		}
+
+		m.start, m.calls, m.samples = now, 0, m.samples[:0]
+	}
+
+	m.calls++
+	if len(m.samples) < maxLatencySamples {
+		m.samples = append(m.samples, latency)
+	} else if i := rand.Intn(m.calls); i < maxLatencySamples {
+		m.samples[i] = latency
+	}
+
+	return m.p99, m.slow, changed
+}
+
+// isSlow reports whether the last window was over budget.
+func (m *latencyMonitor) isSlow() bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.slow
+}
+
+// stats returns the 99th percentile of the last window and the number of
+// windows over budget.
+func (m *latencyMonitor) stats() (time.Duration, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.p99, m.exceeded
+}
+
+// percentile returns the q-th percentile of samples, which it sorts.
+func percentile(samples []time.Duration, q float64) time.Duration {
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	return samples[int(q*float64(len(samples)-1)+0.5)]
+}
+
+// observeLatency records the time spent waiting on Flipt by an evaluation,
+// transitioning the provider between READY and STALE as windows end.
+func (p *Provider) observeLatency(info *transport.CallInfo) {
+	if p.latency == nil || info.Attempts == 0 {
+		return
+	}
+
+	p99, slow, changed := p.latency.record(info.Latency, time.Now())
+	if !changed {
+		return
+	}
+
+	var event of.EventType
+
+	p.mu.Lock()
+	switch {
+	case slow && p.status == of.ReadyState:
+		p.status = of.StaleState
+		event = of.ProviderStale
+	case !slow && p.status == of.StaleState:
+		p.status = of.ReadyState
+		event = of.ProviderReady
+	}
+	p.mu.Unlock()
+
+	switch event {
+	case of.ProviderStale:
+		p.config.Logger.Warn("flipt evaluation latency exceeds the budget", "p99", p99, "budget", p.latency.budget, "window", p.latency.window)
+		p.emit(event, of.ProviderEventDetails{Message: "flipt evaluation latency p99 " + p99.String() + " exceeds the budget of " + p.latency.budget.String()})
+	case of.ProviderReady:
+		p.config.Logger.Info("flipt evaluation latency is within the budget again", "p99", p99)
+		p.emit(event, of.ProviderEventDetails{Message: "flipt evaluation latency is within the budget again"})
+	}
+}
+
+// observeLatencyStats reports the statistics of monitor whenever metrics are
+// collected.
+func observeLatencyStats(meter metric.Meter, monitor *latencyMonitor) error {
+	if _, err := meter.Float64ObservableGauge("feature_flag.evaluation_backend_latency_p99",
+		metric.WithDescription("99th percentile of the time spent waiting on Flipt over the last latency budget window"),
+		metric.WithUnit("ms"),
+		metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+			p99, _ := monitor.stats()
+			o.Observe(float64(p99) / float64(time.Millisecond))
+
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	_, err := meter.Int64ObservableCounter("feature_flag.latency_budget_exceeded_total",
+		metric.WithDescription("Number of latency budget windows whose 99th percentile exceeded the budget"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			_, exceeded := monitor.stats()
+			o.Observe(exceeded)
+
+			return nil
+		}))
+
+	return err
+}
@@ -0,0 +1,93 @@
+package flipt
+
+import (
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+)
+
+func TestLatencyMonitor(t *testing.T) {
+	m := newLatencyMonitor(Config{LatencyBudget: 100 * time.Millisecond, LatencyWindow: time.Minute})
+	start := m.start
+
+	// 99 fast calls and a single slow one stay within budget
+	for i := 0; i < 99; i++ {
+		m.record(10*time.Millisecond, start)
+	}
+	m.record(time.Second, start)
+
+	p99, slow, changed := m.record(10*time.Millisecond, start.Add(time.Minute))
+	assert.Equal(t, 10*time.Millisecond, p99)
+	assert.False(t, slow)
+	assert.False(t, changed)
+
+	for i := 0; i < 10; i++ {
+		m.record(200*time.Millisecond, start.Add(time.Minute))
+	}
+
+	p99, slow, changed = m.record(10*time.Millisecond, start.Add(2*time.Minute))
+	assert.Equal(t, 200*time.Millisecond, p99)
+	assert.True(t, slow)
+	assert.True(t, changed)
+
+	// a window within budget recovers
+	_, slow, changed = m.record(10*time.Millisecond, start.Add(4*time.Minute))
+	assert.False(t, slow)
+	assert.True(t, changed)
+
+	p99, exceeded := m.stats()
+	assert.Equal(t, 10*time.Millisecond, p99)
+	assert.Equal(t, int64(1), exceeded)
+
+	assert.Nil(t, newLatencyMonitor(Config{LatencyBudget: time.Second}), "no window disables the monitor")
+}
+
+func TestWithLatencyBudget(t *testing.T) {
+	rec := &recording{}
+
+	p := NewProvider(WithService(newMockService(t)), WithLatencyBudget(50*time.Millisecond, 20*time.Millisecond), WithMetricsHook(recordingMeterProvider{recording: rec}))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	slow := &transport.CallInfo{Attempts: 1, Latency: 80 * time.Millisecond}
+
+	// the window is evaluated by the first call after it ends
+	p.observeLatency(slow)
+	time.Sleep(20 * time.Millisecond)
+	p.observeLatency(slow)
+
+	assert.Equal(t, of.StaleState, p.Status())
+
+	event := <-p.EventChannel()
+	assert.Equal(t, of.ProviderStale, event.EventType)
+	assert.Equal(t, "flipt evaluation latency p99 80ms exceeds the budget of 50ms", event.Message)
+
+	// serving fresh evaluations does not hide the slowness
+	p.leaveStale()
+	assert.Equal(t, of.StaleState, p.Status())
+
+	rec.collect()
+	assert.Equal(t, []string{
+		"feature_flag.evaluation_backend_latency_p99 80 ",
+		"feature_flag.latency_budget_exceeded_total 1 ",
+	}, rec.measurements)
+
+	// cached evaluations are not measured
+	p.observeLatency(&transport.CallInfo{Cached: true})
+
+	fast := &transport.CallInfo{Attempts: 1, Latency: time.Millisecond}
+
+	// the window holding the second slow call is still over budget
+	time.Sleep(20 * time.Millisecond)
+	p.observeLatency(fast)
+	assert.Equal(t, of.StaleState, p.Status())
+
+	time.Sleep(20 * time.Millisecond)
+	p.observeLatency(fast)
+
+	assert.Equal(t, of.ReadyState, p.Status())
+	assert.Equal(t, of.ProviderReady, (<-p.EventChannel()).EventType)
+}
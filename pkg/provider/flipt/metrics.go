@@ -0,0 +1,147 @@
+package flipt
+
+import (
+	"context"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "go.flipt.io/flipt-openfeature-provider"
+
+// WithMetricsHook enables a hook, returned from Hooks, which records
+// evaluation counters and latency histograms labelled with the flag key,
+// variant and reason using OpenTelemetry metrics. When a cache is set, the
+// counters returned by CacheStats are reported as well. The global meter
+// provider is used when meterProvider is nil.
+func WithMetricsHook(meterProvider metric.MeterProvider) Option {
+	return func(p *Provider) {
+		p.config.MetricsHook = true
+		p.config.MeterProvider = meterProvider
+	}
+}
+
+// metricsHook records metrics about evaluations.
+type metricsHook struct {
+	of.UnimplementedHook
+
+	requests metric.Int64Counter
+	success  metric.Int64Counter
+	errors   metric.Int64Counter
+	latency  metric.Float64Histogram
+}
+
+// newMetricsHook returns a hook recording metrics using meterProvider, which
+// also observes the cache counters returned by cacheStats and the statistics
+// of the latency monitor unless they are nil.
+func newMetricsHook(meterProvider metric.MeterProvider, cacheStats func() CacheStats, latency *latencyMonitor) (*metricsHook, error) {
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+
+	var (
+		meter = meterProvider.Meter(meterName)
+		h     = &metricsHook{}
+		err   error
+	)
+
+	if h.requests, err = meter.Int64Counter("feature_flag.evaluation_requests_total",
+		metric.WithDescription("Number of flag evaluation requests")); err != nil {
+		return nil, err
+	}
+
+	if h.success, err = meter.Int64Counter("feature_flag.evaluation_success_total",
+		metric.WithDescription("Number of successful flag evaluations")); err != nil {
+		return nil, err
+	}
+
+	if h.errors, err = meter.Int64Counter("feature_flag.evaluation_error_total",
+		metric.WithDescription("Number of failed flag evaluations")); err != nil {
+		return nil, err
+	}
+
+	if h.latency, err = meter.Float64Histogram("feature_flag.evaluation_backend_latency",
+		metric.WithDescription("Time spent waiting on Flipt per flag evaluation"),
+		metric.WithUnit("ms")); err != nil {
+		return nil, err
+	}
+
+	if cacheStats != nil {
+		if err := observeCacheStats(meter, cacheStats); err != nil {
+			return nil, err
+		}
+	}
+
+	if latency != nil {
+		if err := observeLatencyStats(meter, latency); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// observeCacheStats reports the cache counters returned by cacheStats
+// whenever metrics are collected.
+func observeCacheStats(meter metric.Meter, cacheStats func() CacheStats) error {
+	if _, err := meter.Int64ObservableCounter("feature_flag.cache_lookups_total",
+		metric.WithDescription("Number of flag evaluations looked up in the cache by result"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			stats := cacheStats()
+			o.Observe(int64(stats.Hits), metric.WithAttributes(attribute.String("feature_flag.cache_result", "hit")))
+			o.Observe(int64(stats.Misses), metric.WithAttributes(attribute.String("feature_flag.cache_result", "miss")))
+			o.Observe(int64(stats.StaleServes), metric.WithAttributes(attribute.String("feature_flag.cache_result", "stale")))
+
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	if _, err := meter.Int64ObservableCounter("feature_flag.cache_evictions_total",
+		metric.WithDescription("Number of cached flag evaluations evicted before they expired"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(cacheStats().Evictions))
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	_, err := meter.Int64ObservableCounter("feature_flag.cache_evicted_bytes_total",
+		metric.WithDescription("Approximate size of the cached flag evaluations evicted before they expired"),
+		metric.WithUnit("By"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(cacheStats().EvictedBytes))
+			return nil
+		}))
+
+	return err
+}
+
+func (h *metricsHook) Before(ctx context.Context, hookContext of.HookContext, _ of.HookHints) (*of.EvaluationContext, error) {
+	h.requests.Add(ctx, 1, metric.WithAttributes(attribute.String("feature_flag.key", hookContext.FlagKey())))
+
+	return nil, nil
+}
+
+func (h *metricsHook) After(ctx context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, _ of.HookHints) error {
+	attrs := metric.WithAttributes(
+		attribute.String("feature_flag.key", hookContext.FlagKey()),
+		attribute.String("feature_flag.variant", details.Variant),
+		attribute.String("feature_flag.reason", string(details.Reason)),
+	)
+
+	h.success.Add(ctx, 1, attrs)
+
+	// the latency is only known when Flipt was called
+	if latency, ok := details.FlagMetadata["backendLatencyMillis"].(float64); ok {
+		h.latency.Record(ctx, latency, attrs)
+	}
+
+	return nil
+}
+
+func (h *metricsHook) Error(ctx context.Context, hookContext of.HookContext, _ error, _ of.HookHints) {
+	h.errors.Add(ctx, 1, metric.WithAttributes(attribute.String("feature_flag.key", hookContext.FlagKey())))
+}
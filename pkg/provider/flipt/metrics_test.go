@@ -0,0 +1,183 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type recording struct {
+	mu           sync.Mutex
+	measurements []string
+	callbacks    []func()
+}
+
+func (r *recording) record(name string, value interface{}, attrs attribute.Set) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.measurements = append(r.measurements, fmt.Sprintf("%s %v %s", name, value, attrs.Encoded(attribute.DefaultEncoder())))
+}
+
+type recordingMeterProvider struct {
+	noop.MeterProvider
+	*recording
+}
+
+func (p recordingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return recordingMeter{recording: p.recording}
+}
+
+type recordingMeter struct {
+	noop.Meter
+	*recording
+}
+
+func (m recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return recordingCounter{name: name, recording: m.recording}, nil
+}
+
+func (m recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return recordingHistogram{name: name, recording: m.recording}, nil
+}
+
+func (m recordingMeter) Int64ObservableCounter(name string, opts ...metric.Int64ObservableCounterOption) (metric.Int64ObservableCounter, error) {
+	for _, cb := range metric.NewInt64ObservableCounterConfig(opts...).Callbacks() {
+		cb := cb
+		m.callbacks = append(m.callbacks, func() {
+			_ = cb(context.Background(), recordingObserver{name: name, recording: m.recording})
+		})
+	}
+
+	return noop.Int64ObservableCounter{}, nil
+}
+
+// collect invokes the callbacks of the observable instruments.
+func (r *recording) collect() {
+	for _, cb := range r.callbacks {
+		cb()
+	}
+}
+
+type recordingObserver struct {
+	noop.Int64Observer
+	*recording
+	name string
+}
+
+func (o recordingObserver) Observe(v int64, opts ...metric.ObserveOption) {
+	o.record(o.name, v, metric.NewObserveConfig(opts).Attributes())
+}
+
+type recordingCounter struct {
+	noop.Int64Counter
+	*recording
+	name string
+}
+
+func (c recordingCounter) Add(_ context.Context, v int64, opts ...metric.AddOption) {
+	c.record(c.name, v, metric.NewAddConfig(opts).Attributes())
+}
+
+type recordingHistogram struct {
+	noop.Float64Histogram
+	*recording
+	name string
+}
+
+func (h recordingHistogram) Record(_ context.Context, v float64, opts ...metric.RecordOption) {
+	h.record(h.name, v, metric.NewRecordConfig(opts).Attributes())
+}
+
+func TestMetricsHook(t *testing.T) {
+	rec := &recording{}
+
+	p := NewProvider(WithMetricsHook(recordingMeterProvider{recording: rec}))
+	require.Len(t, p.Hooks(), 1)
+
+	hook := p.Hooks()[0]
+	hookContext := of.NewHookContext("flag", of.String, "default", of.NewClientMetadata("test"), p.Metadata(), of.NewEvaluationContext("user", nil))
+
+	_, err := hook.Before(context.Background(), hookContext, of.HookHints{})
+	require.NoError(t, err)
+
+	err = hook.After(context.Background(), hookContext, of.InterfaceEvaluationDetails{
+		EvaluationDetails: of.EvaluationDetails{
+			FlagKey: "flag",
+			ResolutionDetail: of.ResolutionDetail{
+				Variant:      "abc",
+				Reason:       of.TargetingMatchReason,
+				FlagMetadata: of.FlagMetadata{"backendLatencyMillis": 1.5},
+			},
+		},
+	}, of.HookHints{})
+	require.NoError(t, err)
+
+	hook.Error(context.Background(), hookContext, errors.New("boom"), of.HookHints{})
+
+	assert.Equal(t, []string{
+		"feature_flag.evaluation_requests_total 1 feature_flag.key=flag",
+		"feature_flag.evaluation_success_total 1 feature_flag.key=flag,feature_flag.reason=TARGETING_MATCH,feature_flag.variant=abc",
+		"feature_flag.evaluation_backend_latency 1.5 feature_flag.key=flag,feature_flag.reason=TARGETING_MATCH,feature_flag.variant=abc",
+		"feature_flag.evaluation_error_total 1 feature_flag.key=flag",
+	}, rec.measurements)
+}
+
+func TestMetricsHookCacheStats(t *testing.T) {
+	rec := &recording{}
+
+	cache := NewLRUCache(10)
+	p := NewProvider(WithMetricsHook(recordingMeterProvider{recording: rec}), WithCache(cache, time.Minute))
+
+	p.cacheCounters.hits.Add(3)
+	p.cacheCounters.misses.Add(2)
+	p.cacheCounters.staleServes.Add(1)
+	cache.evictions.Add(4)
+	cache.evictedBytes.Add(512)
+
+	rec.collect()
+
+	assert.Equal(t, []string{
+		"feature_flag.cache_lookups_total 3 feature_flag.cache_result=hit",
+		"feature_flag.cache_lookups_total 2 feature_flag.cache_result=miss",
+		"feature_flag.cache_lookups_total 1 feature_flag.cache_result=stale",
+		"feature_flag.cache_evictions_total 4 ",
+		"feature_flag.cache_evicted_bytes_total 512 ",
+	}, rec.measurements)
+
+	// cache counters are only observed when a cache is set
+	rec = &recording{}
+	NewProvider(WithMetricsHook(recordingMeterProvider{recording: rec}))
+	assert.Empty(t, rec.callbacks)
+}
+
+func (m recordingMeter) Float64ObservableGauge(name string, opts ...metric.Float64ObservableGaugeOption) (metric.Float64ObservableGauge, error) {
+	for _, cb := range metric.NewFloat64ObservableGaugeConfig(opts...).Callbacks() {
+		cb := cb
+		m.callbacks = append(m.callbacks, func() {
+			_ = cb(context.Background(), recordingFloat64Observer{name: name, recording: m.recording})
+		})
+	}
+
+	return noop.Float64ObservableGauge{}, nil
+}
+
+type recordingFloat64Observer struct {
+	noop.Float64Observer
+	*recording
+	name string
+}
+
+func (o recordingFloat64Observer) Observe(v float64, opts ...metric.ObserveOption) {
+	o.record(o.name, v, metric.NewObserveConfig(opts).Attributes())
+}
@@ -0,0 +1,119 @@
+package flipt
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cachePeerTimeout bounds the time spent fetching the cache of a peer.
+const cachePeerTimeout = 5 * time.Second
+
+// WithCachePeers warms the cache set using WithCache during Init from the
+// first of peers, the URLs of the CachePeerHandler of sibling replicas, able
+// to serve it, so that replicas restarted together, e.g. during a deploy or
+// a node drain, do not all evaluate their flags against Flipt at once.
+// Requests to peers carry secret, which must be the one set on the peers.
+// Failures are logged and do not fail Init, the cache then filling from
+// Flipt as usual.
+func WithCachePeers(secret string, peers ...string) Option {
+	return func(p *Provider) {
+		p.config.CachePeerSecret = secret
+		p.config.CachePeers = peers
+	}
+}
+
+// WithCachePeerSecret sets the secret CachePeerHandler requires from the
+// replicas fetching the cache, for replicas serving their cache without
+// warming their own from peers.
+func WithCachePeerSecret(secret string) Option {
+	return func(p *Provider) {
+		p.config.CachePeerSecret = secret
+	}
+}
+
+// CachePeerHandler returns an HTTP handler serving the cached results of the
+// provider, as written by ExportCache, to sibling replicas warming their
+// cache (see WithCachePeers). Requests must carry the secret set with
+// WithCachePeers or WithCachePeerSecret as a bearer token; every request is
+// rejected when no secret is set. The cached results may hold the variant
+// attachments served to users, so the handler is best served on an internal
+// port only.
+func (p *Provider) CachePeerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if p.config.CachePeerSecret == "" {
+			http.Error(w, "cache peer secret not configured", http.StatusUnauthorized)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(p.config.CachePeerSecret)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := p.ExportCache(r.Context(), w); err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, errCacheNotExportable) {
+				code = http.StatusNotImplemented
+			}
+
+			http.Error(w, err.Error(), code)
+		}
+	})
+}
+
+// warmFromPeers imports the cache of the first configured peer able to serve
+// it.
+func (p *Provider) warmFromPeers(ctx context.Context) {
+	if p.config.Cache == nil || len(p.config.CachePeers) == 0 {
+		return
+	}
+
+	for _, peer := range p.config.CachePeers {
+		err := p.warmFromPeer(ctx, peer)
+		if err == nil {
+			p.config.Logger.Debug("warmed cache from peer", "peer", peer)
+			return
+		}
+
+		p.config.Logger.Warn("warming cache from peer failed", "peer", peer, "error", err)
+	}
+}
+
+func (p *Provider) warmFromPeer(ctx context.Context, peer string) error {
+	ctx, cancel := context.WithTimeout(ctx, cachePeerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.config.CachePeerSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returned %d", resp.StatusCode)
+	}
+
+	return p.ImportCache(ctx, resp.Body)
+}
@@ -0,0 +1,71 @@
+package flipt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestWithCachePeers(t *testing.T) {
+	ctx := context.Background()
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "user"}).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+
+	peer := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Minute), WithCachePeerSecret("secret"))
+	peer.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+
+	srv := httptest.NewServer(peer.CachePeerHandler())
+	t.Cleanup(srv.Close)
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	// the first peer able to serve its cache is used
+	p := NewProvider(WithService(newMockService(t)), WithCache(NewLRUCache(10), time.Minute), WithCachePeers("secret", unreachable.URL, srv.URL))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	detail := p.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+}
+
+func TestCachePeerHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		opts   []Option
+		method string
+		token  string
+		code   int
+	}{
+		{name: "valid", opts: []Option{WithCachePeerSecret("secret")}, method: http.MethodGet, token: "secret", code: http.StatusOK},
+		{name: "invalid token", opts: []Option{WithCachePeerSecret("secret")}, method: http.MethodGet, token: "nope", code: http.StatusUnauthorized},
+		{name: "no secret", method: http.MethodGet, code: http.StatusUnauthorized},
+		{name: "method", opts: []Option{WithCachePeerSecret("secret")}, method: http.MethodPost, token: "secret", code: http.StatusMethodNotAllowed},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProvider(append([]Option{WithService(newMockService(t)), WithCache(NewLRUCache(10), time.Minute)}, tt.opts...)...)
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			p.CachePeerHandler().ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
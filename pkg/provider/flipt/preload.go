@@ -0,0 +1,74 @@
+package flipt
+
+import (
+	"context"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+)
+
+// WithPreloadFlags evaluates flags of the provider's namespace with each of
+// contexts (e.g. representative users or the static context of a service)
+// during Init, filling the cache set using WithCache so that the first
+// evaluations after a deploy are served from it. All flags of the namespace
+// are preloaded when no flags are given. Preloading requires a service which
+// can list flags; failures are logged and do not fail Init.
+func WithPreloadFlags(contexts []of.FlattenedContext, flags ...string) Option {
+	return func(p *Provider) {
+		p.config.PreloadContexts = contexts
+		p.config.PreloadFlags = flags
+	}
+}
+
+// preload evaluates the configured flags to fill the cache.
+func (p *Provider) preload(ctx context.Context) {
+	if p.config.Cache == nil || len(p.config.PreloadContexts) == 0 {
+		return
+	}
+
+	lister, ok := p.svc.(interface {
+		ListFlags(ctx context.Context, namespaceKey string) ([]*flipt.Flag, error)
+	})
+	if !ok {
+		p.config.Logger.Warn("preloading flags is not supported by the flipt service")
+		return
+	}
+
+	flags, err := lister.ListFlags(ctx, p.config.Namespace)
+	if err != nil {
+		p.config.Logger.Warn("listing flags to preload failed", "namespace", p.config.Namespace, "error", err)
+		return
+	}
+
+	wanted := make(map[string]bool, len(p.config.PreloadFlags))
+	for _, flag := range p.config.PreloadFlags {
+		wanted[flag] = true
+	}
+
+	var evaluations, failures int
+
+	for _, flag := range flags {
+		if len(wanted) > 0 && !wanted[flag.Key] {
+			continue
+		}
+
+		for _, evalCtx := range p.config.PreloadContexts {
+			_, evalCtx, _ := p.prepare(ctx, evalCtx)
+
+			if flag.Type == flipt.FlagType_BOOLEAN_FLAG_TYPE {
+				_, err = p.boolean(ctx, flag.Key, evalCtx)
+			} else {
+				_, err = p.variant(ctx, flag.Key, evalCtx)
+			}
+
+			evaluations++
+
+			if err != nil {
+				failures++
+				p.config.Logger.Debug("preloading flag failed", "namespace", p.config.Namespace, "flag", flag.Key, "error", err)
+			}
+		}
+	}
+
+	p.config.Logger.Info("preloaded flags", "namespace", p.config.Namespace, "evaluations", evaluations, "failures", failures)
+}
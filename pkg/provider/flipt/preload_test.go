@@ -0,0 +1,68 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestWithPreloadFlags(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		flags   []string
+		preload []string
+	}{
+		{name: "all flags", preload: []string{"bool", "variant"}},
+		{name: "selected flags", flags: []string{"variant"}, preload: []string{"variant"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &listingService{mockService: newMockService(t)}
+			svc.setFlags(
+				&flipt.Flag{Key: "bool", Type: flipt.FlagType_BOOLEAN_FLAG_TYPE},
+				&flipt.Flag{Key: "variant", Type: flipt.FlagType_VARIANT_FLAG_TYPE},
+			)
+
+			for _, key := range []string{"alice", "bob"} {
+				evalCtx := map[string]interface{}{of.TargetingKey: key, "region": "eu"}
+
+				if contains(tt.preload, "bool") {
+					svc.On("Boolean", mock.Anything, "default", "bool", evalCtx).Return(&evaluation.BooleanEvaluationResponse{
+						Enabled: true,
+						Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+					}, nil).Once()
+				}
+
+				if contains(tt.preload, "variant") {
+					svc.On("Evaluate", mock.Anything, "default", "variant", evalCtx).Return(&evaluation.VariantEvaluationResponse{
+						Match:      true,
+						VariantKey: "blue",
+						Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+					}, nil).Once()
+				}
+			}
+
+			p := NewProvider(
+				WithService(svc),
+				WithCache(NewLRUCache(10), time.Minute),
+				WithPreloadFlags([]of.FlattenedContext{{of.TargetingKey: "alice"}, {of.TargetingKey: "bob"}}, tt.flags...),
+			).WithStaticContext(map[string]interface{}{"region": "eu"})
+
+			require.NoError(t, p.Init(of.EvaluationContext{}))
+			defer p.Shutdown()
+
+			assert.Equal(t, uint64(2*len(tt.preload)), p.CacheStats().Misses)
+
+			// the first evaluation is served from the cache
+			detail := p.StringEvaluation(context.Background(), "variant", "red", of.FlattenedContext{of.TargetingKey: "alice"})
+			assert.Equal(t, "blue", detail.Value)
+			assert.Equal(t, true, detail.FlagMetadata["cached"])
+		})
+	}
+}
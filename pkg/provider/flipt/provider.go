@@ -2,27 +2,264 @@ package flipt
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
 	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
 	flipt "go.flipt.io/flipt/rpc/flipt"
 	"go.flipt.io/flipt/rpc/flipt/evaluation"
 	sdk "go.flipt.io/flipt/sdk/go"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
-var _ of.FeatureProvider = (*Provider)(nil)
+var (
+	_ of.FeatureProvider = (*Provider)(nil)
+	_ of.StateHandler    = (*Provider)(nil)
+)
+
+const defaultInitTimeout = 10 * time.Second
 
 // Config is a configuration for the FliptProvider.
 type Config struct {
 	Address         string
 	CertificatePath string
-	TokenProvider   sdk.ClientTokenProvider
-	Namespace       string
+	// ClientCertificatePath and ClientKeyPath are the PEM files of the client
+	// certificate presented to Flipt (mutual TLS).
+	ClientCertificatePath string
+	ClientKeyPath         string
+	// TLSConfig is the TLS configuration of connections to Flipt, which
+	// CertificatePath and ClientCertificatePath take precedence over.
+	TLSConfig *tls.Config
+	// TLSServerName is the name against which the certificate presented by
+	// Flipt is verified, independently of the address connected to.
+	TLSServerName string
+	// InsecureSkipVerifyTLS disables the verification of the certificate
+	// presented by Flipt. It must only be used in lab environments.
+	InsecureSkipVerifyTLS bool
+	// BasicAuthUsername and BasicAuthPassword are the credentials of HTTP
+	// basic authentication sent with every HTTP request.
+	BasicAuthUsername string
+	BasicAuthPassword string
+	// RedactionPatterns are patterns whose matches are redacted from errors,
+	// in addition to the credentials which are always redacted.
+	RedactionPatterns []*regexp.Regexp
+	// ProxyURL is the forward proxy through which calls are made to Flipt,
+	// instead of the proxy configured by the environment.
+	ProxyURL *url.URL
+	// SOCKS5Address is the address of the SOCKS5 proxy through which the
+	// connections to Flipt are routed, authenticated with SOCKS5Username and
+	// SOCKS5Password when set.
+	SOCKS5Address  string
+	SOCKS5Username string
+	SOCKS5Password string
+	// Headers are static headers sent with every call made to Flipt.
+	Headers map[string]string
+	// AWSSigV4Region and AWSSigV4Service enable signing HTTP requests with
+	// AWS Signature Version 4, with AWSCredentials or the credentials set by
+	// the environment when nil.
+	AWSSigV4Region  string
+	AWSSigV4Service string
+	AWSCredentials  transport.AWSCredentialsFunc
+	// GRPCCredentials are attached to every gRPC call made to Flipt.
+	GRPCCredentials credentials.PerRPCCredentials
+	// GRPCStatsHandler handles the stats of the gRPC connection to Flipt.
+	GRPCStatsHandler stats.Handler
+	// KeepaliveTime enables keepalive pings of the gRPC connection to Flipt
+	// after this long without activity, failing the connection when a ping
+	// is not acknowledged within KeepaliveTimeout. Pings are only sent while
+	// calls are in flight unless KeepalivePermitWithoutStream is set.
+	KeepaliveTime                time.Duration
+	KeepaliveTimeout             time.Duration
+	KeepalivePermitWithoutStream bool
+	// ConnectTimeout bounds the establishment of the gRPC connection to
+	// Flipt, made by Init.
+	ConnectTimeout time.Duration
+	// MaxReceiveMessageSize and MaxSendMessageSize are the maximum sizes in
+	// bytes of the messages received from and sent to Flipt over gRPC.
+	MaxReceiveMessageSize int
+	MaxSendMessageSize    int
+	// RoundRobinBalancing spreads gRPC calls across every address Address
+	// resolves to.
+	RoundRobinBalancing bool
+	// DialOptions are additional options of the gRPC connection to Flipt.
+	DialOptions []grpc.DialOption
+	// OTelGRPC instruments the gRPC connection to Flipt with otelgrpc,
+	// using TracerProvider and MeterProvider when set.
+	OTelGRPC bool
+	// WireLogging logs every request sent to Flipt and its response.
+	WireLogging bool
+	// HTTPTransportWrapper wraps the transport of the HTTP client.
+	HTTPTransportWrapper func(http.RoundTripper) http.RoundTripper
+	TokenProvider        sdk.ClientTokenProvider
+	// BearerTokenProvider provides the bearer token of each call to Flipt,
+	// taking precedence over TokenProvider.
+	BearerTokenProvider transport.TokenProvider
+	// ClientTokenFile is the path of a file holding the client token, which
+	// is reloaded when modified. It takes precedence over BearerTokenProvider
+	// and TokenProvider.
+	ClientTokenFile string
+	// KubernetesTokenPath is the path of a service account token exchanged
+	// with Flipt for client tokens, taking precedence over ClientTokenFile,
+	// BearerTokenProvider and TokenProvider.
+	KubernetesTokenPath string
+	// ClientCredentials configures the OAuth2 client credentials flow used
+	// to obtain access tokens, taking precedence over KubernetesTokenPath,
+	// ClientTokenFile, BearerTokenProvider and TokenProvider.
+	ClientCredentials *transport.ClientCredentials
+	// GoogleIDTokenAudience is the audience of Google-signed ID tokens
+	// authenticating calls to Flipt, obtained with GoogleCredentialsFile if
+	// set. It takes precedence over ClientCredentials, KubernetesTokenPath,
+	// ClientTokenFile, BearerTokenProvider and TokenProvider.
+	GoogleIDTokenAudience string
+	GoogleCredentialsFile string
+	// JWTToken authenticates calls to Flipt with a JWT, taking precedence
+	// over the other authentication methods.
+	JWTToken  string
+	Namespace string
+	// TargetingKeyFunc derives the entity ID from the evaluation context.
+	// When nil the OpenFeature targeting key is used.
+	TargetingKeyFunc transport.TargetingKeyFunc
+	// AnonymousTargetingKey generates a stable anonymous entity ID when the
+	// targeting key is missing, optionally derived from AnonymousTargetingKeyFields.
+	AnonymousTargetingKey       bool
+	AnonymousTargetingKeyFields []string
+	// ContextKeyMap renames evaluation context attributes before they are
+	// sent to Flipt.
+	ContextKeyMap map[string]string
+	// EvaluationTimeout bounds each call made to Flipt to evaluate a flag.
+	EvaluationTimeout time.Duration
+	// DefaultDeadline bounds calls made to Flipt when the caller's context
+	// has no deadline.
+	DefaultDeadline time.Duration
+	// RetryPolicy configures retries of calls to Flipt failing with a
+	// transient error.
+	RetryPolicy transport.RetryPolicy
+	// BreakerThreshold is the number of consecutive failed calls to Flipt
+	// after which the circuit breaker opens for BreakerOpenDuration.
+	BreakerThreshold    int
+	BreakerOpenDuration time.Duration
+	// RequestCoalescing shares a single call to Flipt between concurrent
+	// evaluations of the same flag and evaluation context.
+	RequestCoalescing bool
+	// DisabledFlagsReturnFalse returns false for disabled boolean flags
+	// whatever the value reported by Flipt.
+	DisabledFlagsReturnFalse bool
+	// SplitReason reports matches served by percentage distributions or
+	// threshold rollouts with the SPLIT reason.
+	SplitReason bool
+	// ResponseMetadata adds the details of Flipt's evaluation response to
+	// the flag metadata.
+	ResponseMetadata bool
+	// Cache caches evaluation results for CacheTTL.
+	Cache    Cache
+	CacheTTL time.Duration
+	// CacheTTLJitter is the maximum fraction by which the TTL of each cached
+	// result is randomly shortened.
+	CacheTTLJitter float64
+	// CacheStaleWindow is how long expired results are served from the
+	// cache while being revalidated in the background.
+	CacheStaleWindow time.Duration
+	// CacheStaleOnError is how long expired results are served from the
+	// cache when Flipt fails to evaluate a flag.
+	CacheStaleOnError time.Duration
+	// PreloadFlags are evaluated with each of PreloadContexts during Init to
+	// fill the cache. All flags are preloaded when empty.
+	PreloadFlags    []string
+	PreloadContexts []of.FlattenedContext
+	// Addresses are the addresses of Flipt replicas failed over between
+	// according to FailoverMode. When more than one is set, Address is
+	// ignored.
+	Addresses    []string
+	FailoverMode FailoverMode
+	// RedactedKeys and RedactFunc redact sensitive evaluation context values
+	// wherever the provider reports them.
+	RedactedKeys []string
+	RedactFunc   RedactFunc
+	// ErrorEventThreshold is the number of consecutive failed evaluations
+	// after which the provider transitions to ERROR.
+	ErrorEventThreshold int
+	// ChangePollInterval is the interval at which Flipt is polled for flag
+	// changes. Zero disables polling.
+	ChangePollInterval time.Duration
+	// ReadinessTimeout is the time Init waits for Flipt to report itself
+	// healthy. Zero checks its health only once.
+	ReadinessTimeout time.Duration
+	// LatencyBudget is the 99th percentile of the time spent waiting on
+	// Flipt over LatencyWindow above which the provider transitions to
+	// STALE. Zero disables the detection.
+	LatencyBudget time.Duration
+	LatencyWindow time.Duration
+	// DecisionsKey is the key used to verify pre-resolved decisions.
+	DecisionsKey []byte
+	// ForcedDefaults are the flags, identified as "namespace/flag", for which
+	// the code default is always returned.
+	ForcedDefaults []string
+	// Logger receives structured logs from the provider and transports.
+	Logger logging.Logger
+	// LogRateLimit is the interval during which repeats of a warning or
+	// error are suppressed. Zero disables rate limiting.
+	LogRateLimit time.Duration
+	// LoggingHook enables a hook logging every evaluation to
+	// LoggingHookLogger, or Logger when unset.
+	LoggingHook       bool
+	LoggingHookLogger logging.Logger
+	// MetricsHook enables a hook recording OpenTelemetry metrics using
+	// MeterProvider, or the global meter provider when unset.
+	MetricsHook   bool
+	MeterProvider metric.MeterProvider
+	// TracerProvider enables tracing of evaluations when set.
+	TracerProvider trace.TracerProvider
+	// TelemetrySampleRate is the fraction of successful evaluations recorded
+	// by the logging and metrics hooks and the audit sink. Every evaluation
+	// is recorded when it is not in (0, 1).
+	TelemetrySampleRate float64
+	// ExpvarPrefix publishes the internal counters of the provider as expvar
+	// variables named after it when set.
+	ExpvarPrefix string
+	// SpanEventHook enables a hook recording evaluations as events of the
+	// active span.
+	SpanEventHook bool
+	// RequiredContext are the evaluation context attributes which must be
+	// set for flags to be evaluated.
+	RequiredContext []string
+	// Invalidator broadcasts flag changes between provider instances.
+	Invalidator Invalidator
+	// InvalidationSecret is the secret Flipt signs webhook requests sent to
+	// the InvalidationHandler with.
+	InvalidationSecret string
+	// UnauthenticatedInvalidation makes the InvalidationHandler accept
+	// unsigned requests when InvalidationSecret is not set.
+	UnauthenticatedInvalidation bool
+	// CachePeers are the URLs of the CachePeerHandler of sibling replicas
+	// the cache is warmed from during Init.
+	CachePeers []string
+	// CachePeerSecret is the secret exchanged with the CachePeerHandler of
+	// sibling replicas.
+	CachePeerSecret string
+	// AuditSink receives an event for every flag evaluation.
+	AuditSink AuditSink
+	// EvaluationCallback is called asynchronously with an event for every
+	// flag evaluation.
+	EvaluationCallback func(EvaluationEvent)
+	// Hooks are user hooks returned from Hooks after the provider's own.
+	Hooks []of.Hook
 }
 
 // Option is a configuration option for the provider.
@@ -35,7 +272,8 @@ func WithAddress(address string) Option {
 	}
 }
 
-// WithCertificatePath is an Option to set the certificate path (grpc only).
+// WithCertificatePath is an Option to set the path of the CA certificate
+// trusted when connecting to Flipt over gRPC or HTTPS.
 func WithCertificatePath(certificatePath string) Option {
 	return func(p *Provider) {
 		p.config.CertificatePath = certificatePath
@@ -56,6 +294,250 @@ func WithService(svc Service) Option {
 	}
 }
 
+// WithClientCertificate is an Option to present the client certificate and
+// key read from the PEM files at certPath and keyPath when connecting to Flipt
+// over gRPC or HTTPS (mutual TLS). The files are reloaded when modified.
+func WithClientCertificate(certPath, keyPath string) Option {
+	return func(p *Provider) {
+		p.config.ClientCertificatePath = certPath
+		p.config.ClientKeyPath = keyPath
+	}
+}
+
+// WithTLSConfig is an Option to set the TLS configuration of connections to
+// Flipt over gRPC or HTTPS, e.g. to trust CA pools built from memory or to
+// restrict cipher suites and versions. WithCertificatePath and
+// WithClientCertificate take precedence over the corresponding fields of
+// config.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(p *Provider) {
+		p.config.TLSConfig = config
+	}
+}
+
+// WithTLSServerName is an Option to verify the certificate presented by Flipt
+// against serverName, independently of the address connected to, e.g. when
+// connecting by IP address or through a TCP load balancer whose hostname does
+// not match the certificate.
+func WithTLSServerName(serverName string) Option {
+	return func(p *Provider) {
+		p.config.TLSServerName = serverName
+	}
+}
+
+// WithInsecureSkipVerifyTLS is an Option to disable the verification of the
+// certificate presented by Flipt over gRPC or HTTPS, e.g. in lab environments
+// using self-signed certificates. It is unsafe, as connections can then be
+// intercepted, and is logged as such.
+func WithInsecureSkipVerifyTLS() Option {
+	return func(p *Provider) {
+		p.config.InsecureSkipVerifyTLS = true
+	}
+}
+
+// WithBasicAuth is an Option to send the credentials of HTTP basic
+// authentication with every HTTP request, e.g. to a reverse proxy fronting
+// Flipt. They replace the credentials of the other authentication options.
+func WithBasicAuth(username, password string) Option {
+	return func(p *Provider) {
+		p.config.BasicAuthUsername = username
+		p.config.BasicAuthPassword = password
+	}
+}
+
+// WithRedactionPatterns is an Option to redact the matches of patterns from
+// the errors returned and logged when calling Flipt, e.g. tenant identifiers.
+// URL credentials, token-like query parameters, authorization headers and JSON
+// web tokens are always redacted.
+func WithRedactionPatterns(patterns ...*regexp.Regexp) Option {
+	return func(p *Provider) {
+		p.config.RedactionPatterns = patterns
+	}
+}
+
+// WithProxyURL is an Option to make calls to Flipt through the forward proxy
+// at proxyURL, instead of the proxy configured by the HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables. gRPC connections are tunneled through
+// the proxy with HTTP CONNECT.
+func WithProxyURL(proxyURL *url.URL) Option {
+	return func(p *Provider) {
+		p.config.ProxyURL = proxyURL
+	}
+}
+
+// WithSOCKS5Proxy is an Option to route the HTTP and gRPC connections to
+// Flipt through the SOCKS5 proxy at address, authenticating with username and
+// password when set.
+func WithSOCKS5Proxy(address, username, password string) Option {
+	return func(p *Provider) {
+		p.config.SOCKS5Address = address
+		p.config.SOCKS5Username = username
+		p.config.SOCKS5Password = password
+	}
+}
+
+// WithHeaders is an Option to send static headers with every HTTP request
+// made to Flipt, and as metadata of every gRPC call, e.g. API gateway keys or
+// tenant headers.
+func WithHeaders(headers map[string]string) Option {
+	return func(p *Provider) {
+		p.config.Headers = headers
+	}
+}
+
+// ContextWithRequestHeaders returns a context whose evaluations send headers
+// with their calls to Flipt, e.g. to route them to a tenant through a proxy:
+//
+//	ctx = flipt.ContextWithRequestHeaders(ctx, map[string]string{"X-Tenant": tenant})
+//
+// Cached results and coalesced calls are only shared between evaluations
+// sending the same headers.
+func ContextWithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return transport.ContextWithRequestHeaders(ctx, headers)
+}
+
+// ContextWithRequestID returns a context whose evaluations are sent to Flipt
+// with id as their request ID, e.g. the ID of the incoming request being
+// served, so that provider logs can be correlated with the logs of Flipt:
+//
+//	ctx = flipt.ContextWithRequestID(ctx, r.Header.Get("X-Request-Id"))
+//
+// It takes precedence over a "requestID" entry of the evaluation context.
+// The request ID returned by Flipt, or the one sent when Flipt does not
+// respond, is added to the flag metadata as "requestId" and to the messages
+// of failed evaluations.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return transport.ContextWithRequestID(ctx, id)
+}
+
+// WithAWSSigV4 is an Option to sign HTTP requests made to Flipt with AWS
+// Signature Version 4 for the service in region, e.g. "execute-api" for Flipt
+// fronted by API Gateway with IAM authorization. Requests are signed with the
+// credentials returned by credentials, or set by the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables when nil.
+// The signature replaces the credentials of the other authentication options.
+func WithAWSSigV4(region, service string, credentials transport.AWSCredentialsFunc) Option {
+	return func(p *Provider) {
+		p.config.AWSSigV4Region = region
+		p.config.AWSSigV4Service = service
+		p.config.AWSCredentials = credentials
+	}
+}
+
+// WithGRPCCredentials is an Option to attach creds to every gRPC call made
+// to Flipt, e.g. OAuth token sources or custom request signers.
+func WithGRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(p *Provider) {
+		p.config.GRPCCredentials = creds
+	}
+}
+
+// WithGRPCStatsHandler is an Option to set a handler of the stats of the
+// gRPC connection to Flipt, e.g. for custom instrumentation.
+func WithGRPCStatsHandler(handler stats.Handler) Option {
+	return func(p *Provider) {
+		p.config.GRPCStatsHandler = handler
+	}
+}
+
+// WithKeepalive is an Option to enable keepalive pings of the gRPC
+// connection to Flipt, so that connections silently dropped by NATs or load
+// balancers are detected and replaced rather than timing evaluations out.
+// The connection is pinged after interval without activity and closed when
+// the ping is not acknowledged within timeout. Idle connections are only
+// pinged when permitWithoutStream is set. The keepalive enforcement policy of
+// Flipt must permit pings this frequent, or it closes the connection.
+func WithKeepalive(interval, timeout time.Duration, permitWithoutStream bool) Option {
+	return func(p *Provider) {
+		p.config.KeepaliveTime = interval
+		p.config.KeepaliveTimeout = timeout
+		p.config.KeepalivePermitWithoutStream = permitWithoutStream
+	}
+}
+
+// WithConnectTimeout is an Option to bound the establishment of the gRPC
+// connection to Flipt, which Init makes eagerly, to timeout. Init then fails
+// with an error matching ErrUnavailable when Flipt cannot be reached in time,
+// rather than waiting for the connection indefinitely, and the connection
+// keeps being established in the background for subsequent evaluations.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.config.ConnectTimeout = timeout
+	}
+}
+
+// WithMaxMessageSizes is an Option to set the maximum sizes in bytes of the
+// messages received from and sent to Flipt over gRPC, e.g. when flags carry
+// attachments larger than the 4MB gRPC receives by default. Zero keeps the
+// gRPC default.
+func WithMaxMessageSizes(receive, send int) Option {
+	return func(p *Provider) {
+		p.config.MaxReceiveMessageSize = receive
+		p.config.MaxSendMessageSize = send
+	}
+}
+
+// WithRoundRobinBalancing is an Option to spread the gRPC calls made to
+// Flipt across every address its address resolves to using DNS, e.g. all the
+// pods behind a headless Kubernetes service such as
+// flipt-headless.flipt.svc.cluster.local:9000, instead of pinning them to a
+// single pod. Unlike WithAddresses, it balances a single address served by
+// several instances.
+func WithRoundRobinBalancing() Option {
+	return func(p *Provider) {
+		p.config.RoundRobinBalancing = true
+	}
+}
+
+// WithDialOptions is an Option to set additional options of the gRPC
+// connection to Flipt, e.g. custom resolvers, interceptors or credentials,
+// overriding those set by the provider.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(p *Provider) {
+		p.config.DialOptions = append(p.config.DialOptions, opts...)
+	}
+}
+
+// WithOTelGRPC is an Option to instrument the gRPC connection to Flipt with
+// otelgrpc, recording client spans and metrics of every call. The providers
+// set by WithTracerProvider and WithMetricsHook are used when set, and the
+// global ones otherwise.
+func WithOTelGRPC() Option {
+	return func(p *Provider) {
+		p.config.OTelGRPC = true
+	}
+}
+
+// WithHTTPTransportWrapper is an Option to wrap the transport of the HTTP
+// client calling Flipt, e.g. with otelhttp so that calls get spans and
+// metrics consistent with the rest of the application:
+//
+//	flipt.WithHTTPTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+//		return otelhttp.NewTransport(rt)
+//	})
+func WithHTTPTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(p *Provider) {
+		p.config.HTTPTransportWrapper = wrap
+	}
+}
+
+// WithWireLogging is an Option to log every request sent to Flipt and its
+// response (method, URL, status, duration and truncated bodies, with secrets
+// redacted) at debug level through the configured logger. It can be toggled
+// at runtime using SetWireLogging.
+func WithWireLogging() Option {
+	return func(p *Provider) {
+		p.config.WireLogging = true
+	}
+}
+
+// SetWireLogging enables or disables, at runtime, the logging of the requests
+// sent to Flipt and of their responses, see WithWireLogging. It has no effect
+// when the service is set using WithService.
+func (p *Provider) SetWireLogging(enabled bool) {
+	p.wireLogging.SetEnabled(enabled)
+}
+
 // WithClientTokenProvider sets the token provider for auth to support client
 // auth needs.
 func WithClientTokenProvider(tokenProvider sdk.ClientTokenProvider) Option {
@@ -64,6 +546,81 @@ func WithClientTokenProvider(tokenProvider sdk.ClientTokenProvider) Option {
 	}
 }
 
+// WithTokenProvider authenticates calls to Flipt with the bearer token
+// returned by tp, which is consulted for every call so that short-lived
+// tokens can be rotated. Wrap tp with transport.NewRefreshingTokenProvider to
+// cache tokens until they expire. It takes precedence over
+// WithClientTokenProvider.
+func WithTokenProvider(tp transport.TokenProvider) Option {
+	return func(p *Provider) {
+		p.config.BearerTokenProvider = tp
+	}
+}
+
+// WithClientTokenFile authenticates calls to Flipt with the client token read
+// from the file at path, which is reloaded when modified so that tokens
+// rotated by sidecars such as Vault agent are picked up without restarts. It
+// takes precedence over WithTokenProvider and WithClientTokenProvider.
+func WithClientTokenFile(path string) Option {
+	return func(p *Provider) {
+		p.config.ClientTokenFile = path
+	}
+}
+
+// WithKubernetesAuthentication authenticates calls to Flipt with Flipt's
+// Kubernetes authentication method, exchanging the service account token read
+// from tokenPath for client tokens. An empty tokenPath defaults to the token
+// projected into the pod. It takes precedence over WithClientTokenFile,
+// WithTokenProvider and WithClientTokenProvider.
+func WithKubernetesAuthentication(tokenPath string) Option {
+	return func(p *Provider) {
+		if tokenPath == "" {
+			tokenPath = transport.DefaultServiceAccountTokenPath
+		}
+
+		p.config.KubernetesTokenPath = tokenPath
+	}
+}
+
+// WithOIDCClientCredentials authenticates calls to Flipt with access tokens
+// obtained from the OIDC issuer with the OAuth2 client credentials flow, and
+// verified by Flipt's JWT authentication method. Access tokens are requested
+// again shortly before they expire. It takes precedence over
+// WithKubernetesAuthentication, WithClientTokenFile, WithTokenProvider and
+// WithClientTokenProvider.
+func WithOIDCClientCredentials(issuer, clientID, clientSecret string, scopes ...string) Option {
+	return func(p *Provider) {
+		p.config.ClientCredentials = &transport.ClientCredentials{
+			Issuer:       issuer,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+		}
+	}
+}
+
+// WithGoogleIDToken authenticates calls to Flipt with Google-signed ID tokens
+// for audience, e.g. when Flipt is hosted on Cloud Run or behind an
+// Identity-Aware Proxy. Tokens are obtained with the service account key read
+// from credentialsFile, or from GOOGLE_APPLICATION_CREDENTIALS when empty,
+// and otherwise from the metadata server. It takes precedence over the other
+// authentication options except WithJWTAuthentication.
+func WithGoogleIDToken(audience, credentialsFile string) Option {
+	return func(p *Provider) {
+		p.config.GoogleIDTokenAudience = audience
+		p.config.GoogleCredentialsFile = credentialsFile
+	}
+}
+
+// WithJWTAuthentication authenticates calls to Flipt with token, a JWT
+// verified by Flipt's JWT authentication method. It takes precedence over
+// the other authentication options.
+func WithJWTAuthentication(token string) Option {
+	return func(p *Provider) {
+		p.config.JWTToken = token
+	}
+}
+
 // ForNamespace sets the namespace for flag lookup and evaluation in Flipt.
 func ForNamespace(namespace string) Option {
 	return func(p *Provider) {
@@ -71,29 +628,373 @@ func ForNamespace(namespace string) Option {
 	}
 }
 
+// WithTargetingKeyFunc sets the function used to derive the entity ID from the
+// evaluation context, e.g. from a "userID" attribute or a composite key.
+func WithTargetingKeyFunc(fn transport.TargetingKeyFunc) Option {
+	return func(p *Provider) {
+		p.config.TargetingKeyFunc = fn
+	}
+}
+
+// WithAnonymousTargetingKey enables generating a stable anonymous entity ID
+// when the targeting key is missing, so percentage rollouts also apply to
+// anonymous traffic. The ID is derived from the given context fields when
+// present, otherwise a single ID is generated for the provider.
+func WithAnonymousTargetingKey(fields ...string) Option {
+	return func(p *Provider) {
+		p.config.AnonymousTargetingKey = true
+		p.config.AnonymousTargetingKeyFields = fields
+	}
+}
+
+// WithContextKeyMap renames evaluation context attributes before they are sent
+// to Flipt, so that OpenFeature attribute names (e.g. "email") can be matched
+// against the property names used by Flipt segment constraints.
+func WithContextKeyMap(keyMap map[string]string) Option {
+	return func(p *Provider) {
+		p.config.ContextKeyMap = keyMap
+	}
+}
+
+// WithEvaluationTimeout bounds each call made to Flipt to evaluate a flag by
+// timeout, so that a slow Flipt server degrades to the default value quickly
+// instead of stalling request handlers.
+func WithEvaluationTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.config.EvaluationTimeout = timeout
+	}
+}
+
+// WithDefaultDeadline bounds calls made to Flipt by timeout when the caller's
+// context has no deadline, e.g. context.Background(), preventing unbounded
+// hangs. Deadlines set by callers take precedence.
+func WithDefaultDeadline(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.config.DefaultDeadline = timeout
+	}
+}
+
+// WithRetryPolicy retries calls to Flipt failing with a transient error,
+// e.g. UNAVAILABLE, with exponential backoff and jitter. Retries count
+// towards the evaluation timeout, if any.
+func WithRetryPolicy(policy transport.RetryPolicy) Option {
+	return func(p *Provider) {
+		p.config.RetryPolicy = policy
+	}
+}
+
+// WithDisabledFlagsReturnFalse makes boolean evaluations of disabled flags
+// always return false, as is commonly expected of on/off flags, rather than
+// the value reported by Flipt, which is false for disabled flags. Other flag
+// types are not affected, returning the default value passed by the caller.
+func WithDisabledFlagsReturnFalse() Option {
+	return func(p *Provider) {
+		p.config.DisabledFlagsReturnFalse = true
+	}
+}
+
+// WithResponseMetadata adds the details of the evaluation response returned
+// by Flipt to the flag metadata, available to hooks, so that custom exposure
+// logging does not need to query Flipt again: the evaluation duration
+// reported by Flipt ("serverDurationMillis") and, for variant flags, the comma
+// separated keys of the matched segments ("segmentKeys"). The request ID
+// ("requestId") is always added, see ContextWithRequestID.
+func WithResponseMetadata() Option {
+	return func(p *Provider) {
+		p.config.ResponseMetadata = true
+	}
+}
+
+// WithLogger sets the logger used by the provider and transports to report
+// failed evaluations, state changes and connection events, e.g. a *slog.Logger
+// or one of the adapters of the logging package. By default nothing is logged.
+func WithLogger(logger logging.Logger) Option {
+	return func(p *Provider) {
+		p.config.Logger = logger
+	}
+}
+
+// defaultLogRateLimit is the interval during which repeats of a warning or
+// error are suppressed by default.
+const defaultLogRateLimit = 10 * time.Second
+
+// WithLogRateLimit logs each distinct warning or error, e.g. the same
+// transport error reported by every evaluation while Flipt is down, at most
+// once per interval, with a summary of the repeats suppressed in between.
+// By default interval is 10 seconds; zero disables rate limiting.
+func WithLogRateLimit(interval time.Duration) Option {
+	return func(p *Provider) {
+		p.config.LogRateLimit = interval
+	}
+}
+
 // NewProvider returns a new Flipt provider.
 func NewProvider(opts ...Option) *Provider {
-	p := &Provider{config: Config{
-		Address:   "http://localhost:8080",
-		Namespace: "default",
-	}}
+	p := &Provider{
+		config: Config{
+			Address:             "http://localhost:8080",
+			Namespace:           "default",
+			ErrorEventThreshold: defaultErrorEventThreshold,
+			LogRateLimit:        defaultLogRateLimit,
+		},
+		events:     make(chan of.Event, eventBufferSize),
+		status:     of.NotReadyState,
+		killSwitch: &killSwitch{flags: map[string]bool{}},
+	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
+	if p.config.Logger == nil {
+		p.config.Logger = logging.Nop()
+	} else if p.config.LogRateLimit > 0 {
+		p.config.Logger = logging.RateLimited(p.config.Logger, p.config.LogRateLimit)
+	}
+
+	// validation runs first, so that rejected evaluations never reach Flipt
+	if len(p.config.RequiredContext) > 0 {
+		p.hooks = append(p.hooks, validationHook{required: p.config.RequiredContext})
+	}
+
+	if p.config.LoggingHook {
+		logger := p.config.LoggingHookLogger
+		if logger == nil {
+			logger = p.config.Logger
+		}
+
+		p.hooks = append(p.hooks, p.sampled(loggingHook{logger: logger}))
+	}
+
+	p.latency = newLatencyMonitor(p.config)
+
+	if p.config.EvaluationCallback != nil {
+		p.callbacks = NewAsyncAuditSink(auditSinkFunc(p.config.EvaluationCallback), evaluationCallbackBufferSize)
+	}
+
+	if p.config.MetricsHook {
+		var cacheStats func() CacheStats
+		if p.config.Cache != nil {
+			cacheStats = p.CacheStats
+		}
+
+		if h, err := newMetricsHook(p.config.MeterProvider, cacheStats, p.latency); err != nil {
+			p.config.Logger.Warn("creating metrics hook failed", "error", err)
+		} else {
+			p.hooks = append(p.hooks, p.sampled(h))
+		}
+	}
+
+	if p.config.SpanEventHook {
+		p.hooks = append(p.hooks, spanEventHook{})
+	}
+
+	p.hooks = append(p.hooks, p.config.Hooks...)
+
+	p.breaker = newBreaker(p.config)
+	p.revalidating = &sync.Map{}
+	p.cacheCounters = &cacheCounters{}
+	p.debugCounters = &debugCounters{}
+	p.wireLogging = &transport.WireLogging{}
+	p.refreshes = &coalescer{flights: map[string]*flight{}}
+
+	if p.config.RequestCoalescing {
+		p.coalescer = &coalescer{flights: map[string]*flight{}}
+	}
+
+	if p.config.SplitReason {
+		p.splits = &splitTargeting{flags: map[string]splitEntry{}}
+	}
+
+	for _, flag := range p.config.ForcedDefaults {
+		p.killSwitch.flags[flag] = true
+	}
+
 	if p.svc == nil {
 		topts := []transport.Option{transport.WithAddress(p.config.Address), transport.WithCertificatePath(p.config.CertificatePath)}
+		if p.config.ClientCertificatePath != "" {
+			topts = append(topts, transport.WithClientCertificate(p.config.ClientCertificatePath, p.config.ClientKeyPath))
+		}
+
+		if p.config.TLSConfig != nil {
+			topts = append(topts, transport.WithTLSConfig(p.config.TLSConfig))
+		}
+
+		if p.config.TLSServerName != "" {
+			topts = append(topts, transport.WithTLSServerName(p.config.TLSServerName))
+		}
+
+		if p.config.InsecureSkipVerifyTLS {
+			topts = append(topts, transport.WithInsecureSkipVerifyTLS())
+		}
+
+		if p.config.BasicAuthUsername != "" || p.config.BasicAuthPassword != "" {
+			topts = append(topts, transport.WithBasicAuth(p.config.BasicAuthUsername, p.config.BasicAuthPassword))
+		}
+
+		if len(p.config.RedactionPatterns) > 0 {
+			topts = append(topts, transport.WithRedactionPatterns(p.config.RedactionPatterns...))
+		}
+
+		if len(p.config.RedactedKeys) > 0 || p.config.RedactFunc != nil {
+			topts = append(topts, transport.WithContextRedaction(p.redactValue))
+		}
+
+		if p.config.ProxyURL != nil {
+			topts = append(topts, transport.WithProxyURL(p.config.ProxyURL))
+		}
+
+		if p.config.SOCKS5Address != "" {
+			topts = append(topts, transport.WithSOCKS5Proxy(p.config.SOCKS5Address, p.config.SOCKS5Username, p.config.SOCKS5Password))
+		}
+
+		if len(p.config.Headers) > 0 {
+			topts = append(topts, transport.WithHeaders(p.config.Headers))
+		}
+
+		if p.config.AWSSigV4Region != "" {
+			topts = append(topts, transport.WithAWSSigV4(p.config.AWSSigV4Region, p.config.AWSSigV4Service, p.config.AWSCredentials))
+		}
+
+		if p.config.GRPCCredentials != nil {
+			topts = append(topts, transport.WithGRPCCredentials(p.config.GRPCCredentials))
+		}
+
+		if p.config.GRPCStatsHandler != nil {
+			topts = append(topts, transport.WithGRPCStatsHandler(p.config.GRPCStatsHandler))
+		}
+
+		if p.config.ConnectTimeout > 0 {
+			topts = append(topts, transport.WithConnectTimeout(p.config.ConnectTimeout))
+		}
+
+		if p.config.KeepaliveTime > 0 {
+			topts = append(topts, transport.WithKeepalive(p.config.KeepaliveTime, p.config.KeepaliveTimeout, p.config.KeepalivePermitWithoutStream))
+		}
+
+		if p.config.MaxReceiveMessageSize > 0 || p.config.MaxSendMessageSize > 0 {
+			topts = append(topts, transport.WithMaxMessageSizes(p.config.MaxReceiveMessageSize, p.config.MaxSendMessageSize))
+		}
+
+		if p.config.RoundRobinBalancing {
+			topts = append(topts, transport.WithRoundRobinBalancing())
+		}
+
+		if len(p.config.DialOptions) > 0 {
+			topts = append(topts, transport.WithDialOptions(p.config.DialOptions...))
+		}
+
+		if p.config.OTelGRPC {
+			var oopts []otelgrpc.Option
+			if p.config.TracerProvider != nil {
+				oopts = append(oopts, otelgrpc.WithTracerProvider(p.config.TracerProvider))
+			}
+
+			if p.config.MeterProvider != nil {
+				oopts = append(oopts, otelgrpc.WithMeterProvider(p.config.MeterProvider))
+			}
+
+			topts = append(topts, transport.WithOTelGRPC(oopts...))
+		}
+
+		if p.config.HTTPTransportWrapper != nil {
+			topts = append(topts, transport.WithHTTPTransportWrapper(p.config.HTTPTransportWrapper))
+		}
+
 		if p.config.TokenProvider != nil {
 			topts = append(topts, transport.WithClientTokenProvider(p.config.TokenProvider))
 		}
 
-		p.svc = transport.New(topts...)
+		if p.config.BearerTokenProvider != nil {
+			topts = append(topts, transport.WithTokenProvider(p.config.BearerTokenProvider))
+		}
+
+		if p.config.ClientTokenFile != "" {
+			topts = append(topts, transport.WithClientTokenFile(p.config.ClientTokenFile))
+		}
+
+		if p.config.KubernetesTokenPath != "" {
+			topts = append(topts, transport.WithKubernetesAuthentication(p.config.KubernetesTokenPath))
+		}
+
+		if p.config.ClientCredentials != nil {
+			topts = append(topts, transport.WithClientCredentials(*p.config.ClientCredentials))
+		}
+
+		if p.config.GoogleIDTokenAudience != "" {
+			topts = append(topts, transport.WithGoogleIDToken(p.config.GoogleIDTokenAudience, p.config.GoogleCredentialsFile))
+		}
+
+		if p.config.JWTToken != "" {
+			topts = append(topts, transport.WithJWTAuthentication(p.config.JWTToken))
+		}
+
+		if p.config.TargetingKeyFunc != nil {
+			topts = append(topts, transport.WithTargetingKeyFunc(p.config.TargetingKeyFunc))
+		}
+
+		if p.config.AnonymousTargetingKey {
+			topts = append(topts, transport.WithAnonymousTargetingKey(p.config.AnonymousTargetingKeyFields...))
+		}
+
+		if len(p.config.ContextKeyMap) > 0 {
+			topts = append(topts, transport.WithContextKeyMap(p.config.ContextKeyMap))
+		}
+
+		if p.config.EvaluationTimeout > 0 {
+			topts = append(topts, transport.WithEvaluationTimeout(p.config.EvaluationTimeout))
+		}
+
+		if p.config.DefaultDeadline > 0 {
+			topts = append(topts, transport.WithDefaultDeadline(p.config.DefaultDeadline))
+		}
+
+		if p.config.RetryPolicy.MaxAttempts > 1 {
+			topts = append(topts, transport.WithRetryPolicy(p.config.RetryPolicy))
+		}
+
+		p.wireLogging.SetEnabled(p.config.WireLogging)
+		topts = append(topts, transport.WithWireLogging(p.wireLogging), transport.WithLogger(p.config.Logger))
+
+		if len(p.config.Addresses) > 1 {
+			p.svc = newFailoverService(p.config, topts)
+		} else {
+			p.svc = transport.New(topts...)
+		}
+	}
+
+	if p.config.ExpvarPrefix != "" {
+		p.publishExpvars(p.config.ExpvarPrefix)
 	}
 
 	return p
 }
 
+// NewValidatedProvider returns a new Flipt provider like NewProvider, but
+// validates the configuration up front and returns a descriptive error,
+// matching ErrInvalidConfig, instead of failing at evaluation time.
+func NewValidatedProvider(opts ...Option) (*Provider, error) {
+	p := NewProvider(opts...)
+
+	var errs []error
+
+	if p.config.Namespace == "" {
+		errs = append(errs, errors.New("namespace is empty"))
+	}
+
+	if v, ok := p.svc.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid flipt provider configuration: %w", &transport.ConfigError{Err: err})
+	}
+
+	return p, nil
+}
+
 //go:generate mockery --name=Service --structname=mockService --case=underscore --output=. --outpkg=flipt --filename=provider_support.go --testonly --with-expecter --disable-version-string
 type Service interface {
 	GetFlag(ctx context.Context, namespaceKey, flagKey string) (*flipt.Flag, error)
@@ -105,23 +1006,248 @@ type Service interface {
 type Provider struct {
 	svc    Service
 	config Config
+
+	// staticContext is merged into the evaluation context of every evaluation.
+	staticContext map[string]interface{}
+
+	events chan of.Event
+
+	mu       sync.RWMutex
+	status   of.State
+	failures int
+	// authRejected is set while Flipt rejects the credentials of the provider.
+	authRejected bool
+	// defaults counts evaluations which returned the default value by cause.
+	defaults map[string]uint64
+
+	killSwitch *killSwitch
+	hooks      []of.Hook
+	breaker    *breaker
+	coalescer  *coalescer
+	// revalidating holds the keys of cached results being refreshed in the
+	// background.
+	revalidating  *sync.Map
+	cacheCounters *cacheCounters
+	debugCounters *debugCounters
+	wireLogging   *transport.WireLogging
+	latency       *latencyMonitor
+	// callbacks dispatches evaluation events to the evaluation callback.
+	callbacks *AsyncAuditSink
+	// refreshes deduplicates concurrent refreshes of cached results.
+	refreshes *coalescer
+	// splits holds the targeting listed to tell split matches apart, when
+	// enabled.
+	splits *splitTargeting
+
+	// root is the provider p was derived from by WithStaticContext, which
+	// owns the connections and background work they share, or nil.
+	root *Provider
+	// derived are the providers derived from p by WithStaticContext, which
+	// the configuration changes detected by p are forwarded to.
+	derived map[*Provider]struct{}
+
+	// stopPolling stops the change poller started by Init.
+	stopPolling context.CancelFunc
+	// stopInvalidations stops the invalidator subscription started by Init.
+	stopInvalidations context.CancelFunc
 }
 
 // Metadata returns the metadata of the provider.
-func (p Provider) Metadata() of.Metadata {
+func (p *Provider) Metadata() of.Metadata {
 	return of.Metadata{Name: "flipt-provider"}
 }
 
+// WithStaticContext returns a provider sharing the configuration and
+// connections of p which merges attrs (e.g. service name or region) into the
+// evaluation context of every evaluation. Attributes provided at evaluation
+// time take precedence over static attributes.
+//
+// The connections and background work, e.g. change polling, remain owned by
+// p: Init on the derived provider only checks Flipt, and Close only detaches
+// it from p. The configuration changes detected by p are emitted by the
+// derived provider as well.
+func (p *Provider) WithStaticContext(attrs map[string]interface{}) *Provider {
+	static := make(map[string]interface{}, len(p.staticContext)+len(attrs))
+	for k, v := range p.staticContext {
+		static[k] = v
+	}
+
+	for k, v := range attrs {
+		static[k] = v
+	}
+
+	root := p
+	if p.root != nil {
+		root = p.root
+	}
+
+	derived := &Provider{
+		root:          root,
+		svc:           p.svc,
+		config:        p.config,
+		staticContext: static,
+		events:        make(chan of.Event, eventBufferSize),
+		status:        p.Status(),
+		killSwitch:    p.killSwitch,
+		hooks:         p.hooks,
+		breaker:       newBreaker(p.config),
+		coalescer:     p.coalescer,
+		revalidating:  p.revalidating,
+		cacheCounters: p.cacheCounters,
+		debugCounters: p.debugCounters,
+		wireLogging:   p.wireLogging,
+		latency:       p.latency,
+		refreshes:     p.refreshes,
+		splits:        p.splits,
+		callbacks:     p.callbacks,
+	}
+
+	root.mu.Lock()
+	if root.derived == nil {
+		root.derived = map[*Provider]struct{}{}
+	}
+
+	root.derived[derived] = struct{}{}
+	root.mu.Unlock()
+
+	return derived
+}
+
+func (p *Provider) mergeStaticContext(evalCtx of.FlattenedContext) of.FlattenedContext {
+	if len(p.staticContext) == 0 {
+		return evalCtx
+	}
+
+	merged := make(of.FlattenedContext, len(p.staticContext)+len(evalCtx))
+	for k, v := range p.staticContext {
+		merged[k] = v
+	}
+
+	for k, v := range evalCtx {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// Init verifies connectivity to Flipt and its credentials, eagerly
+// establishing the underlying connection, after waiting for Flipt to become
+// ready when configured with WithReadinessTimeout. The provider is READY when
+// Flipt is reachable and accepts the credentials, and in ERROR otherwise.
+func (p *Provider) Init(of.EvaluationContext) error {
+	err := p.awaitReadiness()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultInitTimeout)
+	defer cancel()
+
+	if err == nil {
+		err = p.health(ctx)
+	}
+
+	if err != nil {
+		p.setStatus(of.ErrorState)
+		p.config.Logger.Warn("flipt provider failed to initialize", "address", p.config.Address, "error", err)
+
+		return fmt.Errorf("initializing flipt provider: %w", err)
+	}
+
+	p.warmFromPeers(ctx)
+	p.preload(ctx)
+	p.setStatus(of.ReadyState)
+
+	// derived providers rely on the background work of their root provider
+	if p.root == nil {
+		p.startPolling()
+		p.startInvalidations()
+	}
+
+	return nil
+}
+
+// Shutdown stops watching for changes and releases the connections to Flipt
+// held by the provider.
+func (p *Provider) Shutdown() {
+	_ = p.Close()
+}
+
+// Close stops watching for changes and releases the connections to Flipt held
+// by the provider, e.g. when swapping providers in a long-running service.
+// Evaluations made after Close fail with PROVIDER_NOT_READY. Closing a
+// provider returned by WithStaticContext only detaches it from the provider
+// it was derived from, which keeps its connections.
+func (p *Provider) Close() error {
+	if p.root != nil {
+		p.root.mu.Lock()
+		delete(p.root.derived, p)
+		p.root.mu.Unlock()
+
+		p.setStatus(of.NotReadyState)
+
+		return nil
+	}
+
+	p.stopPollingChanges()
+	p.stopInvalidationSubscription()
+	defer p.setStatus(of.NotReadyState)
+
+	if p.callbacks != nil {
+		_ = p.callbacks.Close()
+	}
+
+	if c, ok := p.svc.(interface{ Close() error }); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("closing flipt provider: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns the current state of the provider.
+func (p *Provider) Status() of.State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.status
+}
+
+func (p *Provider) setStatus(status of.State) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.status = status
+}
+
 // BooleanEvaluation returns a boolean flag.
-func (p Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
-	resp, err := p.svc.Boolean(ctx, p.config.Namespace, flag, evalCtx)
+func (p *Provider) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	ctx, span := p.startEvaluation(ctx, flag)
+
+	if detail, ok := p.forcedDefault(flag); ok {
+		p.audit(flag, defaultValue, detail, evalCtx, span.start)
+		span.end(detail)
+
+		return of.BoolResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+
+	ctx, evalCtx, info := p.prepare(ctx, evalCtx)
+
+	detail := p.booleanEvaluation(ctx, flag, defaultValue, evalCtx)
+	p.finish(flag, &detail.ProviderResolutionDetail, info)
+	p.audit(flag, detail.Value, detail.ProviderResolutionDetail, evalCtx, span.start)
+	span.end(detail.ProviderResolutionDetail)
+
+	return detail
+}
+
+func (p *Provider) booleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	resp, err := p.boolean(ctx, flag, evalCtx)
 	if err != nil {
 		var (
 			rerr   of.ResolutionError
 			detail = of.BoolResolutionDetail{
 				Value: defaultValue,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason: of.DefaultReason,
+					Reason: of.ErrorReason,
 				},
 			}
 		)
@@ -137,24 +1263,59 @@ func (p Provider) BooleanEvaluation(ctx context.Context, flag string, defaultVal
 		return detail
 	}
 
+	if resp.Reason == evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON {
+		detail := of.BoolResolutionDetail{
+			Value: resp.Enabled,
+			ProviderResolutionDetail: of.ProviderResolutionDetail{
+				Reason: of.DisabledReason,
+			},
+		}
+
+		if p.config.DisabledFlagsReturnFalse {
+			detail.Value = false
+		}
+
+		return detail
+	}
+
 	return of.BoolResolutionDetail{
 		Value: resp.Enabled,
 		ProviderResolutionDetail: of.ProviderResolutionDetail{
-			Reason: of.TargetingMatchReason,
+			Reason: p.booleanMatchReason(ctx, flag, resp),
 		},
 	}
 }
 
 // StringEvaluation returns a string flag.
-func (p Provider) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
-	resp, err := p.svc.Evaluate(ctx, p.config.Namespace, flag, evalCtx)
+func (p *Provider) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	ctx, span := p.startEvaluation(ctx, flag)
+
+	if detail, ok := p.forcedDefault(flag); ok {
+		p.audit(flag, defaultValue, detail, evalCtx, span.start)
+		span.end(detail)
+
+		return of.StringResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+
+	ctx, evalCtx, info := p.prepare(ctx, evalCtx)
+
+	detail := p.stringEvaluation(ctx, flag, defaultValue, evalCtx)
+	p.finish(flag, &detail.ProviderResolutionDetail, info)
+	p.audit(flag, detail.Value, detail.ProviderResolutionDetail, evalCtx, span.start)
+	span.end(detail.ProviderResolutionDetail)
+
+	return detail
+}
+
+func (p *Provider) stringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	resp, err := p.variant(ctx, flag, evalCtx)
 	if err != nil {
 		var (
 			rerr   of.ResolutionError
 			detail = of.StringResolutionDetail{
 				Value: defaultValue,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason: of.DefaultReason,
+					Reason: of.ErrorReason,
 				},
 			}
 		)
@@ -174,7 +1335,8 @@ func (p Provider) StringEvaluation(ctx context.Context, flag string, defaultValu
 		return of.StringResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DisabledReason,
+				Reason:       of.DisabledReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled},
 			},
 		}
 	}
@@ -183,7 +1345,8 @@ func (p Provider) StringEvaluation(ctx context.Context, flag string, defaultValu
 		return of.StringResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DefaultReason,
+				Reason:       of.DefaultReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch},
 			},
 		}
 	}
@@ -191,21 +1354,41 @@ func (p Provider) StringEvaluation(ctx context.Context, flag string, defaultValu
 	return of.StringResolutionDetail{
 		Value: resp.VariantKey,
 		ProviderResolutionDetail: of.ProviderResolutionDetail{
-			Reason: of.TargetingMatchReason,
+			Reason: p.variantMatchReason(ctx, flag, resp),
 		},
 	}
 }
 
 // FloatEvaluation returns a float flag.
-func (p Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
-	resp, err := p.svc.Evaluate(ctx, p.config.Namespace, flag, evalCtx)
+func (p *Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	ctx, span := p.startEvaluation(ctx, flag)
+
+	if detail, ok := p.forcedDefault(flag); ok {
+		p.audit(flag, defaultValue, detail, evalCtx, span.start)
+		span.end(detail)
+
+		return of.FloatResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+
+	ctx, evalCtx, info := p.prepare(ctx, evalCtx)
+
+	detail := p.floatEvaluation(ctx, flag, defaultValue, evalCtx)
+	p.finish(flag, &detail.ProviderResolutionDetail, info)
+	p.audit(flag, detail.Value, detail.ProviderResolutionDetail, evalCtx, span.start)
+	span.end(detail.ProviderResolutionDetail)
+
+	return detail
+}
+
+func (p *Provider) floatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	resp, err := p.variant(ctx, flag, evalCtx)
 	if err != nil {
 		var (
 			rerr   of.ResolutionError
 			detail = of.FloatResolutionDetail{
 				Value: defaultValue,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason: of.DefaultReason,
+					Reason: of.ErrorReason,
 				},
 			}
 		)
@@ -225,7 +1408,8 @@ func (p Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue
 		return of.FloatResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DisabledReason,
+				Reason:       of.DisabledReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled},
 			},
 		}
 	}
@@ -234,7 +1418,8 @@ func (p Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue
 		return of.FloatResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DefaultReason,
+				Reason:       of.DefaultReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch},
 			},
 		}
 	}
@@ -253,21 +1438,41 @@ func (p Provider) FloatEvaluation(ctx context.Context, flag string, defaultValue
 	return of.FloatResolutionDetail{
 		Value: fv,
 		ProviderResolutionDetail: of.ProviderResolutionDetail{
-			Reason: of.TargetingMatchReason,
+			Reason: p.variantMatchReason(ctx, flag, resp),
 		},
 	}
 }
 
 // IntEvaluation returns an int flag.
-func (p Provider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
-	resp, err := p.svc.Evaluate(ctx, p.config.Namespace, flag, evalCtx)
+func (p *Provider) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	ctx, span := p.startEvaluation(ctx, flag)
+
+	if detail, ok := p.forcedDefault(flag); ok {
+		p.audit(flag, defaultValue, detail, evalCtx, span.start)
+		span.end(detail)
+
+		return of.IntResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+
+	ctx, evalCtx, info := p.prepare(ctx, evalCtx)
+
+	detail := p.intEvaluation(ctx, flag, defaultValue, evalCtx)
+	p.finish(flag, &detail.ProviderResolutionDetail, info)
+	p.audit(flag, detail.Value, detail.ProviderResolutionDetail, evalCtx, span.start)
+	span.end(detail.ProviderResolutionDetail)
+
+	return detail
+}
+
+func (p *Provider) intEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	resp, err := p.variant(ctx, flag, evalCtx)
 	if err != nil {
 		var (
 			rerr   of.ResolutionError
 			detail = of.IntResolutionDetail{
 				Value: defaultValue,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason: of.DefaultReason,
+					Reason: of.ErrorReason,
 				},
 			}
 		)
@@ -287,7 +1492,8 @@ func (p Provider) IntEvaluation(ctx context.Context, flag string, defaultValue i
 		return of.IntResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DisabledReason,
+				Reason:       of.DisabledReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled},
 			},
 		}
 	}
@@ -296,7 +1502,8 @@ func (p Provider) IntEvaluation(ctx context.Context, flag string, defaultValue i
 		return of.IntResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DefaultReason,
+				Reason:       of.DefaultReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch},
 			},
 		}
 	}
@@ -315,21 +1522,41 @@ func (p Provider) IntEvaluation(ctx context.Context, flag string, defaultValue i
 	return of.IntResolutionDetail{
 		Value: iv,
 		ProviderResolutionDetail: of.ProviderResolutionDetail{
-			Reason: of.TargetingMatchReason,
+			Reason: p.variantMatchReason(ctx, flag, resp),
 		},
 	}
 }
 
 // ObjectEvaluation returns an object flag with attachment if any. Value is a map of key/value pairs ([string]interface{}).
-func (p Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
-	resp, err := p.svc.Evaluate(ctx, p.config.Namespace, flag, evalCtx)
+func (p *Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	ctx, span := p.startEvaluation(ctx, flag)
+
+	if detail, ok := p.forcedDefault(flag); ok {
+		p.audit(flag, defaultValue, detail, evalCtx, span.start)
+		span.end(detail)
+
+		return of.InterfaceResolutionDetail{Value: defaultValue, ProviderResolutionDetail: detail}
+	}
+
+	ctx, evalCtx, info := p.prepare(ctx, evalCtx)
+
+	detail := p.objectEvaluation(ctx, flag, defaultValue, evalCtx)
+	p.finish(flag, &detail.ProviderResolutionDetail, info)
+	p.audit(flag, detail.Value, detail.ProviderResolutionDetail, evalCtx, span.start)
+	span.end(detail.ProviderResolutionDetail)
+
+	return detail
+}
+
+func (p *Provider) objectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	resp, err := p.variant(ctx, flag, evalCtx)
 	if err != nil {
 		var (
 			rerr   of.ResolutionError
 			detail = of.InterfaceResolutionDetail{
 				Value: defaultValue,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason: of.DefaultReason,
+					Reason: of.ErrorReason,
 				},
 			}
 		)
@@ -349,7 +1576,8 @@ func (p Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValu
 		return of.InterfaceResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DisabledReason,
+				Reason:       of.DisabledReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled},
 			},
 		}
 	}
@@ -358,7 +1586,8 @@ func (p Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValu
 		return of.InterfaceResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason: of.DefaultReason,
+				Reason:       of.DefaultReason,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch},
 			},
 		}
 	}
@@ -367,8 +1596,9 @@ func (p Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValu
 		return of.InterfaceResolutionDetail{
 			Value: defaultValue,
 			ProviderResolutionDetail: of.ProviderResolutionDetail{
-				Reason:  of.DefaultReason,
-				Variant: resp.VariantKey,
+				Reason:       of.DefaultReason,
+				Variant:      resp.VariantKey,
+				FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoAttachment},
 			},
 		}
 	}
@@ -387,14 +1617,161 @@ func (p Provider) ObjectEvaluation(ctx context.Context, flag string, defaultValu
 	return of.InterfaceResolutionDetail{
 		Value: out.AsMap(),
 		ProviderResolutionDetail: of.ProviderResolutionDetail{
-			Reason:  of.TargetingMatchReason,
+			Reason:  p.variantMatchReason(ctx, flag, resp),
 			Variant: resp.VariantKey,
 		},
 	}
 }
 
-// Hooks returns hooks.
-func (p Provider) Hooks() []of.Hook {
-	// code to retrieve hooks
-	return []of.Hook{}
+// prepare applies the processing shared by all evaluation types before a
+// flag is resolved.
+func (p *Provider) prepare(ctx context.Context, evalCtx of.FlattenedContext) (context.Context, of.FlattenedContext, *transport.CallInfo) {
+	ctx, info := transport.ContextWithCallInfo(ctx)
+
+	return ctx, p.mergeStaticContext(evalCtx), info
+}
+
+// finish applies the processing shared by all evaluation types to the
+// resolution detail.
+func (p *Provider) finish(flag string, detail *of.ProviderResolutionDetail, info *transport.CallInfo) {
+	p.debugCounters.count(info)
+	p.observeLatency(info)
+
+	// the metadata set by the evaluation, e.g. its default cause, is kept
+	for k, v := range callMetadata(info) {
+		if detail.FlagMetadata == nil {
+			detail.FlagMetadata = of.FlagMetadata{}
+		}
+
+		detail.FlagMetadata[k] = v
+	}
+
+	if info.Cached {
+		if detail.FlagMetadata == nil {
+			detail.FlagMetadata = of.FlagMetadata{}
+		}
+
+		detail.FlagMetadata["cached"] = true
+	}
+
+	if info.Stale {
+		detail.FlagMetadata["stale"] = true
+	}
+
+	if info.StaleOnError {
+		detail.Reason = StaleReason
+	}
+
+	if info.RequestID != "" {
+		if detail.FlagMetadata == nil {
+			detail.FlagMetadata = of.FlagMetadata{}
+		}
+
+		detail.FlagMetadata["requestId"] = info.RequestID
+	}
+
+	if p.config.ResponseMetadata && detail.FlagMetadata != nil && detail.ResolutionDetail().ErrorCode == "" {
+		detail.FlagMetadata["serverDurationMillis"] = info.ServerDurationMillis
+		if len(info.SegmentKeys) > 0 {
+			detail.FlagMetadata["segmentKeys"] = strings.Join(info.SegmentKeys, ",")
+		}
+	}
+
+	if rd := detail.ResolutionDetail(); rd.ErrorCode != "" {
+		p.config.Logger.Debug("flag evaluation failed", "namespace", p.config.Namespace, "flag", flag, "code", rd.ErrorCode, "error", rd.ErrorMessage)
+	}
+
+	// reasons introduced by newer versions of Flipt are reported as unknown
+	// along with the raw value instead of guessing their meaning
+	if raw, ok := info.UnknownEnums["reason"]; ok && detail.ResolutionDetail().ErrorCode == "" {
+		if detail.FlagMetadata == nil {
+			detail.FlagMetadata = of.FlagMetadata{}
+		}
+
+		detail.Reason = of.UnknownReason
+		detail.FlagMetadata["fliptReason"] = raw
+	}
+
+	p.recordDefault(detail)
+
+	// stale results hide the failure, which must not count as a recovery
+	if !info.StaleOnError {
+		p.observe(*detail, info.Err)
+	}
+}
+
+// Health verifies that Flipt is reachable, ready to serve evaluations and
+// accepts the credentials of the provider, e.g. to back a readiness probe:
+//
+//	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+//		if err := provider.Health(r.Context()); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+//
+// Rejected credentials transition the provider to ERROR, as they do for
+// evaluations. Init performs the same checks.
+func (p *Provider) Health(ctx context.Context) error {
+	err := p.health(ctx)
+	p.observeAuth(err)
+
+	return err
+}
+
+func (p *Provider) health(ctx context.Context) error {
+	if err := p.check(ctx); err != nil {
+		return err
+	}
+
+	if c, ok := p.svc.(interface {
+		CheckAccess(ctx context.Context, namespaceKey string) error
+	}); ok {
+		return c.CheckAccess(ctx, p.config.Namespace)
+	}
+
+	return nil
+}
+
+// check verifies connectivity to Flipt when supported by the underlying service.
+func (p *Provider) check(ctx context.Context) error {
+	if c, ok := p.svc.(interface{ Check(context.Context) error }); ok {
+		return c.Check(ctx)
+	}
+
+	return nil
+}
+
+// matchReason maps the reason Flipt gave for a successful evaluation to an
+// OpenFeature reason. A value served by the flag's own default, e.g. its
+// default variant, is reported as DEFAULT rather than TARGETING_MATCH.
+// Split matches are told apart by WithSplitReason.
+func matchReason(reason evaluation.EvaluationReason) of.Reason {
+	if reason == evaluation.EvaluationReason_DEFAULT_EVALUATION_REASON {
+		return of.DefaultReason
+	}
+
+	return of.TargetingMatchReason
+}
+
+// callMetadata returns flag metadata describing how the calls to Flipt were
+// served, or nil when no call was made.
+func callMetadata(info *transport.CallInfo) of.FlagMetadata {
+	if info.Attempts == 0 {
+		return nil
+	}
+
+	return of.FlagMetadata{
+		"attempts":             int64(info.Attempts),
+		"backendLatencyMillis": float64(info.Latency) / float64(time.Millisecond),
+		"endpoint":             info.Endpoint,
+	}
+}
+
+// Hooks returns the hooks enabled for the provider.
+func (p *Provider) Hooks() []of.Hook {
+	if p.hooks == nil {
+		return []of.Hook{}
+	}
+
+	return p.hooks
 }
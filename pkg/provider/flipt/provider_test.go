@@ -1,14 +1,20 @@
 package flipt
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"testing"
+	"time"
 
 	of "github.com/open-feature/go-sdk/pkg/openfeature"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
 	"go.flipt.io/flipt/rpc/flipt/evaluation"
 )
 
@@ -17,11 +23,263 @@ func TestMetadata(t *testing.T) {
 	assert.Equal(t, "flipt-provider", p.Metadata().Name)
 }
 
+func TestNewValidatedProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		expectedErr string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "grpc",
+			opts: []Option{WithAddress("localhost:9000")},
+		},
+		{
+			name:        "malformed address",
+			opts:        []Option{WithAddress("http://")},
+			expectedErr: `invalid flipt provider configuration: invalid address "http://": missing host`,
+		},
+		{
+			name:        "empty namespace",
+			opts:        []Option{ForNamespace("")},
+			expectedErr: "invalid flipt provider configuration: namespace is empty",
+		},
+		{
+			name:        "unreadable certificate",
+			opts:        []Option{WithAddress("localhost:9000"), WithCertificatePath("/does/not/exist.pem")},
+			expectedErr: `invalid flipt provider configuration: invalid certificate path "/does/not/exist.pem": failed to load certificate: open /does/not/exist.pem: no such file or directory`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewValidatedProvider(tt.opts...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				assert.Nil(t, p)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, p)
+		})
+	}
+}
+
+type closingService struct {
+	*mockService
+	closed bool
+	err    error
+}
+
+func (c *closingService) Close() error {
+	c.closed = true
+
+	return c.err
+}
+
+func TestStateHandler(t *testing.T) {
+	svc := &closingService{mockService: newMockService(t)}
+
+	p := NewProvider(WithService(svc))
+	assert.Equal(t, of.NotReadyState, p.Status())
+
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	p.Shutdown()
+	assert.Equal(t, of.NotReadyState, p.Status())
+	assert.True(t, svc.closed)
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found"))
+
+	p := NewProvider(WithService(mockSvc), WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+
+	assert.Contains(t, buf.String(), `msg="flag evaluation failed" namespace=default flag=flag code=FLAG_NOT_FOUND error="flag not found"`)
+}
+
+func TestClose(t *testing.T) {
+	svc := &closingService{mockService: newMockService(t), err: errors.New("connection reset")}
+
+	p := NewProvider(WithService(svc))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	assert.EqualError(t, p.Close(), "closing flipt provider: connection reset")
+	assert.Equal(t, of.NotReadyState, p.Status())
+	assert.True(t, svc.closed)
+}
+
+func TestWithStaticContext_Lifecycle(t *testing.T) {
+	svc := &closingService{mockService: newMockService(t)}
+
+	p := NewProvider(WithService(svc))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+
+	derived := p.WithStaticContext(map[string]interface{}{"region": "eu"})
+	require.NoError(t, derived.Init(of.EvaluationContext{}))
+	assert.Equal(t, of.ReadyState, derived.Status())
+
+	// configuration changes detected by the root provider are forwarded
+	p.applyInvalidation(context.Background(), Invalidation{Namespace: "default", Flags: []string{"flag"}})
+
+	event := <-derived.EventChannel()
+	assert.Equal(t, of.ProviderConfigChange, event.EventType)
+	assert.Equal(t, []string{"flag"}, event.FlagChanges)
+
+	// closing a derived provider leaves the shared connections open
+	require.NoError(t, derived.Close())
+	assert.Equal(t, of.NotReadyState, derived.Status())
+	assert.False(t, svc.closed)
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	p.applyInvalidation(context.Background(), Invalidation{Namespace: "default", Flags: []string{"flag"}})
+	assert.Empty(t, derived.EventChannel())
+
+	require.NoError(t, p.Close())
+	assert.True(t, svc.closed)
+}
+
+func TestStateHandler_InitError(t *testing.T) {
+	p := NewProvider(WithService(checkingService{mockService: newMockService(t), err: errors.New("unreachable")}))
+
+	err := p.Init(of.EvaluationContext{})
+	assert.EqualError(t, err, "initializing flipt provider: unreachable")
+	assert.Equal(t, of.ErrorState, p.Status())
+}
+
+type accessCheckingService struct {
+	*mockService
+	err error
+}
+
+func (a accessCheckingService) CheckAccess(_ context.Context, namespaceKey string) error {
+	if namespaceKey != "default" {
+		return fmt.Errorf("unexpected namespace %q", namespaceKey)
+	}
+
+	return a.err
+}
+
+func TestHealth(t *testing.T) {
+	p := NewProvider(WithService(checkingService{mockService: newMockService(t), err: errors.New("unreachable")}))
+	assert.EqualError(t, p.Health(context.Background()), "unreachable")
+
+	p = NewProvider(WithService(accessCheckingService{mockService: newMockService(t)}))
+	assert.NoError(t, p.Health(context.Background()))
+
+	// rejected credentials fail Init even though Flipt is reachable
+	p = NewProvider(WithService(accessCheckingService{mockService: newMockService(t), err: &transport.UnauthenticatedError{Message: "token expired"}}))
+
+	err := p.Init(of.EvaluationContext{})
+	assert.EqualError(t, err, "initializing flipt provider: flipt rejected the credentials: token expired")
+	assert.Equal(t, of.ErrorState, p.Status())
+}
+
+func TestWithStaticContext(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", map[string]interface{}{
+		of.TargetingKey: "123",
+		"service":       "checkout",
+		"region":        "us-west-2",
+	}).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+
+	parent := NewProvider(WithService(mockSvc))
+	p := parent.
+		WithStaticContext(map[string]interface{}{"service": "checkout", "region": "eu-west-1"}).
+		WithStaticContext(map[string]interface{}{"region": "us-east-1"})
+
+	detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{
+		of.TargetingKey: "123",
+		"region":        "us-west-2",
+	})
+	assert.True(t, detail.Value)
+
+	assert.Empty(t, parent.staticContext, "parent provider should not be modified")
+}
+
+func TestFinish_UnknownReason(t *testing.T) {
+	p := NewProvider(WithService(newMockService(t)))
+
+	detail := of.ProviderResolutionDetail{Reason: of.TargetingMatchReason, Variant: "foo"}
+	p.finish("flag", &detail, &transport.CallInfo{
+		Attempts:     1,
+		UnknownEnums: map[string]string{"reason": "ROLLOUT_EVALUATION_REASON"},
+	})
+
+	assert.Equal(t, of.UnknownReason, detail.Reason)
+	assert.Equal(t, "foo", detail.Variant)
+	assert.Equal(t, "ROLLOUT_EVALUATION_REASON", detail.FlagMetadata["fliptReason"])
+
+	detail = of.ProviderResolutionDetail{Reason: of.DefaultReason, ResolutionError: of.NewGeneralResolutionError("boom")}
+	p.finish("flag", &detail, &transport.CallInfo{
+		UnknownEnums: map[string]string{"reason": "ROLLOUT_EVALUATION_REASON"},
+	})
+
+	assert.Equal(t, of.DefaultReason, detail.Reason, "errors should keep their reason")
+}
+
+func TestResponseMetadata(t *testing.T) {
+	info := &transport.CallInfo{
+		Attempts:             1,
+		RequestID:            "123",
+		ServerDurationMillis: 0.5,
+		SegmentKeys:          []string{"beta", "internal"},
+	}
+
+	detail := of.ProviderResolutionDetail{Reason: of.TargetingMatchReason}
+	NewProvider().finish("flag", &detail, info)
+	assert.Equal(t, "123", detail.FlagMetadata["requestId"], "the request ID should always be reported")
+	assert.NotContains(t, detail.FlagMetadata, "serverDurationMillis")
+
+	detail = of.ProviderResolutionDetail{Reason: of.ErrorReason, ResolutionError: of.NewGeneralResolutionError("boom")}
+	NewProvider().finish("flag", &detail, info)
+	assert.Equal(t, "123", detail.FlagMetadata["requestId"], "failed evaluations should report the request ID")
+
+	detail = of.ProviderResolutionDetail{Reason: of.TargetingMatchReason}
+	NewProvider(WithResponseMetadata()).finish("flag", &detail, info)
+	assert.Equal(t, "123", detail.FlagMetadata["requestId"])
+	assert.Equal(t, 0.5, detail.FlagMetadata["serverDurationMillis"])
+	assert.Equal(t, "beta,internal", detail.FlagMetadata["segmentKeys"])
+}
+
+func TestCallMetadata(t *testing.T) {
+	assert.Nil(t, callMetadata(&transport.CallInfo{}))
+
+	md := callMetadata(&transport.CallInfo{
+		Attempts: 2,
+		Latency:  1500 * time.Microsecond,
+		Endpoint: "grpc://flipt:9000",
+	})
+
+	attempts, err := md.GetInt("attempts")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), attempts)
+
+	latency, err := md.GetFloat("backendLatencyMillis")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, latency)
+
+	endpoint, err := md.GetString("endpoint")
+	require.NoError(t, err)
+	assert.Equal(t, "grpc://flipt:9000", endpoint)
+}
+
 func TestBooleanEvaluation(t *testing.T) {
 	tests := []struct {
 		name                  string
 		flagKey               string
 		defaultValue          bool
+		opts                  []Option
 		mockRespEvaluation    *evaluation.BooleanEvaluationResponse
 		mockRespEvaluationErr error
 		expected              of.BoolResolutionDetail
@@ -36,6 +294,37 @@ func TestBooleanEvaluation(t *testing.T) {
 			},
 			expected: of.BoolResolutionDetail{Value: false, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.TargetingMatchReason}},
 		},
+		{
+			name:         "flag default",
+			flagKey:      "boolean-default",
+			defaultValue: false,
+			mockRespEvaluation: &evaluation.BooleanEvaluationResponse{
+				Enabled: true,
+				Reason:  evaluation.EvaluationReason_DEFAULT_EVALUATION_REASON,
+			},
+			expected: of.BoolResolutionDetail{Value: true, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason}},
+		},
+		{
+			name:         "disabled",
+			flagKey:      "boolean-disabled",
+			defaultValue: true,
+			mockRespEvaluation: &evaluation.BooleanEvaluationResponse{
+				Enabled: false,
+				Reason:  evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON,
+			},
+			expected: of.BoolResolutionDetail{Value: false, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason}},
+		},
+		{
+			name:         "disabled returns false",
+			flagKey:      "boolean-disabled",
+			defaultValue: true,
+			opts:         []Option{WithDisabledFlagsReturnFalse()},
+			mockRespEvaluation: &evaluation.BooleanEvaluationResponse{
+				Enabled: true,
+				Reason:  evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON,
+			},
+			expected: of.BoolResolutionDetail{Value: false, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason}},
+		},
 		{
 			name:                  "resolution error",
 			flagKey:               "boolean-res-error",
@@ -44,8 +333,9 @@ func TestBooleanEvaluation(t *testing.T) {
 			expected: of.BoolResolutionDetail{
 				Value: false,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewInvalidContextResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_invalid_context"},
 				},
 			},
 		},
@@ -56,7 +346,7 @@ func TestBooleanEvaluation(t *testing.T) {
 			mockSvc := newMockService(t)
 			mockSvc.On("Boolean", mock.Anything, "flipt", tt.flagKey, mock.Anything).Return(tt.mockRespEvaluation, tt.mockRespEvaluationErr).Maybe()
 
-			p := NewProvider(WithService(mockSvc), ForNamespace("flipt"))
+			p := NewProvider(append([]Option{WithService(mockSvc), ForNamespace("flipt")}, tt.opts...)...)
 
 			actual := p.BooleanEvaluation(context.Background(), tt.flagKey, tt.defaultValue, map[string]interface{}{})
 
@@ -84,6 +374,17 @@ func TestStringEvaluation(t *testing.T) {
 			},
 			expected: of.StringResolutionDetail{Value: "true", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.TargetingMatchReason}},
 		},
+		{
+			name:         "flag default variant",
+			flagKey:      "string-default",
+			defaultValue: "false",
+			mockRespEvaluation: &evaluation.VariantEvaluationResponse{
+				Match:      true,
+				VariantKey: "fallback",
+				Reason:     evaluation.EvaluationReason_DEFAULT_EVALUATION_REASON,
+			},
+			expected: of.StringResolutionDetail{Value: "fallback", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason}},
+		},
 		{
 			name:         "flag disabled",
 			flagKey:      "string-true",
@@ -92,7 +393,7 @@ func TestStringEvaluation(t *testing.T) {
 				Match:  false,
 				Reason: evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON,
 			},
-			expected: of.StringResolutionDetail{Value: "false", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason}},
+			expected: of.StringResolutionDetail{Value: "false", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled}}},
 		},
 		{
 			name:                  "resolution error",
@@ -102,8 +403,9 @@ func TestStringEvaluation(t *testing.T) {
 			expected: of.StringResolutionDetail{
 				Value: "true",
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewInvalidContextResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_invalid_context"},
 				},
 			},
 		},
@@ -115,8 +417,9 @@ func TestStringEvaluation(t *testing.T) {
 			expected: of.StringResolutionDetail{
 				Value: "true",
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewGeneralResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_general"},
 				},
 			},
 		},
@@ -128,7 +431,7 @@ func TestStringEvaluation(t *testing.T) {
 			mockRespEvaluation: &evaluation.VariantEvaluationResponse{
 				Match: false,
 			},
-			expected: of.StringResolutionDetail{Value: "default", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason}},
+			expected: of.StringResolutionDetail{Value: "default", ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch}}},
 		},
 		{
 			name:    "match",
@@ -206,7 +509,7 @@ func TestFloatEvaluation(t *testing.T) {
 				Match:  false,
 				Reason: evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON,
 			},
-			expected: of.FloatResolutionDetail{Value: 0.0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason}},
+			expected: of.FloatResolutionDetail{Value: 0.0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled}}},
 		},
 		{
 			name:                  "resolution error",
@@ -216,8 +519,9 @@ func TestFloatEvaluation(t *testing.T) {
 			expected: of.FloatResolutionDetail{
 				Value: 0.0,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewInvalidContextResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_invalid_context"},
 				},
 			},
 		},
@@ -235,6 +539,7 @@ func TestFloatEvaluation(t *testing.T) {
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
 					Reason:          of.ErrorReason,
 					ResolutionError: of.NewTypeMismatchResolutionError("value is not a float"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_type_mismatch"},
 				},
 			},
 		},
@@ -246,8 +551,9 @@ func TestFloatEvaluation(t *testing.T) {
 			expected: of.FloatResolutionDetail{
 				Value: 1.0,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewGeneralResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_general"},
 				},
 			},
 		},
@@ -259,7 +565,7 @@ func TestFloatEvaluation(t *testing.T) {
 			mockRespEvaluation: &evaluation.VariantEvaluationResponse{
 				Match: false,
 			},
-			expected: of.FloatResolutionDetail{Value: 1.0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason}},
+			expected: of.FloatResolutionDetail{Value: 1.0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch}}},
 		},
 		{
 			name:    "match",
@@ -322,7 +628,7 @@ func TestIntEvaluation(t *testing.T) {
 				Match:  false,
 				Reason: evaluation.EvaluationReason_FLAG_DISABLED_EVALUATION_REASON,
 			},
-			expected: of.IntResolutionDetail{Value: 0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason}},
+			expected: of.IntResolutionDetail{Value: 0, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DisabledReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseDisabled}}},
 		},
 		{
 			name:                  "resolution error",
@@ -332,8 +638,9 @@ func TestIntEvaluation(t *testing.T) {
 			expected: of.IntResolutionDetail{
 				Value: 0,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewInvalidContextResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_invalid_context"},
 				},
 			},
 		},
@@ -351,6 +658,7 @@ func TestIntEvaluation(t *testing.T) {
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
 					Reason:          of.ErrorReason,
 					ResolutionError: of.NewTypeMismatchResolutionError("value is not an integer"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_type_mismatch"},
 				},
 			},
 		},
@@ -362,8 +670,9 @@ func TestIntEvaluation(t *testing.T) {
 			expected: of.IntResolutionDetail{
 				Value: 1,
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewGeneralResolutionError("boom"),
+					FlagMetadata:    of.FlagMetadata{"defaultCause": "error_general"},
 				},
 			},
 		},
@@ -375,7 +684,7 @@ func TestIntEvaluation(t *testing.T) {
 			mockRespEvaluation: &evaluation.VariantEvaluationResponse{
 				Match: false,
 			},
-			expected: of.IntResolutionDetail{Value: 1, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason}},
+			expected: of.IntResolutionDetail{Value: 1, ProviderResolutionDetail: of.ProviderResolutionDetail{Reason: of.DefaultReason, FlagMetadata: of.FlagMetadata{"defaultCause": DefaultCauseNoMatch}}},
 		},
 		{
 			name:    "match",
@@ -486,7 +795,7 @@ func TestObjectEvaluation(t *testing.T) {
 				Value: map[string]interface{}{
 					"baz": "qux",
 				}, ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewInvalidContextResolutionError("boom"),
 				},
 			},
@@ -525,7 +834,7 @@ func TestObjectEvaluation(t *testing.T) {
 					"baz": "qux",
 				},
 				ProviderResolutionDetail: of.ProviderResolutionDetail{
-					Reason:          of.DefaultReason,
+					Reason:          of.ErrorReason,
 					ResolutionError: of.NewGeneralResolutionError("boom"),
 				},
 			},
@@ -603,3 +912,74 @@ func TestObjectEvaluation(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateAsync(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "boolean-flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+	mockSvc.On("Evaluate", mock.Anything, "default", "int-flag", mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true, VariantKey: "42"}, nil)
+
+	p := NewProvider(WithService(mockSvc))
+
+	boolCh := p.EvaluateAsync(context.Background(), "boolean-flag", false, map[string]interface{}{})
+	intCh := p.EvaluateAsync(context.Background(), "int-flag", 1, map[string]interface{}{})
+
+	res := <-boolCh
+	assert.Equal(t, true, res.Value)
+	assert.Equal(t, of.TargetingMatchReason, res.Reason)
+
+	res = <-intCh
+	assert.Equal(t, 42, res.Value)
+	assert.Equal(t, of.TargetingMatchReason, res.Reason)
+
+	_, ok := <-intCh
+	assert.False(t, ok, "channel should be closed after the result")
+}
+
+type tenantHook struct {
+	of.UnimplementedHook
+
+	finally *int
+}
+
+func (tenantHook) Before(context.Context, of.HookContext, of.HookHints) (*of.EvaluationContext, error) {
+	evalCtx := of.NewEvaluationContext("", map[string]interface{}{"tenant": "acme"})
+	return &evalCtx, nil
+}
+
+func (h tenantHook) Finally(context.Context, of.HookContext, of.HookHints) {
+	*h.finally++
+}
+
+func TestEvaluateAsync_Hooks(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "user", "tenant": "acme"}).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil).Once()
+
+	var after, errs, finally int
+
+	p := NewProvider(WithService(mockSvc), WithRequiredContext(of.TargetingKey), WithHooks(tenantHook{finally: &finally}, countingHook{after: &after, errors: &errs}))
+
+	res := <-p.EvaluateAsync(context.Background(), "flag", false, of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, true, res.Value)
+	assert.Equal(t, 1, after)
+	assert.Equal(t, 1, finally)
+
+	// failing Before hooks return the default value without calling Flipt
+	res = <-p.EvaluateAsync(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Equal(t, false, res.Value)
+	assert.Equal(t, of.ErrorReason, res.Reason)
+	assert.Equal(t, of.InvalidContextCode, res.ResolutionDetail().ErrorCode)
+	assert.Equal(t, 1, errs)
+	assert.Equal(t, 2, finally)
+}
+
+func TestWithWireLogging(t *testing.T) {
+	p := NewProvider(WithWireLogging())
+	static := p.WithStaticContext(map[string]interface{}{"region": "eu"})
+
+	assert.True(t, p.wireLogging.Enabled())
+
+	p.SetWireLogging(false)
+	assert.False(t, static.wireLogging.Enabled())
+
+	assert.False(t, NewProvider().wireLogging.Enabled())
+}
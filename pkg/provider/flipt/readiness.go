@@ -0,0 +1,53 @@
+package flipt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// readinessPollInterval is the interval at which Flipt is polled while
+// waiting for it to become ready.
+const readinessPollInterval = 250 * time.Millisecond
+
+// WithReadinessTimeout makes Init wait up to timeout for Flipt to report
+// itself healthy, polling its /health endpoint or the gRPC health service,
+// before verifying the credentials and reporting READY, e.g. when Flipt is
+// still booting alongside the application. Init fails as usual once the
+// timeout elapses. By default Init checks the health of Flipt only once.
+func WithReadinessTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.config.ReadinessTimeout = timeout
+	}
+}
+
+// awaitReadiness polls the health of Flipt until it reports itself ready or
+// the readiness timeout elapses, returning the last failure in that case.
+func (p *Provider) awaitReadiness() error {
+	if p.config.ReadinessTimeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.ReadinessTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		err := p.check(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == 1 {
+			p.config.Logger.Info("waiting for flipt to become ready", "address", p.config.Address, "timeout", p.config.ReadinessTimeout, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("flipt not ready after %s: %w", p.config.ReadinessTimeout, err)
+		case <-ticker.C:
+		}
+	}
+}
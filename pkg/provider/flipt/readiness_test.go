@@ -0,0 +1,59 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bootingService fails health checks until it has been checked ready times.
+type bootingService struct {
+	*mockService
+	ready  int32
+	checks atomic.Int32
+}
+
+func (b *bootingService) Check(context.Context) error {
+	if b.checks.Add(1) < b.ready {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func TestWithReadinessTimeout(t *testing.T) {
+	svc := &bootingService{mockService: newMockService(t), ready: 3}
+
+	p := NewProvider(WithService(svc), WithReadinessTimeout(time.Second))
+	require.NoError(t, p.Init(of.EvaluationContext{}))
+	assert.Equal(t, of.ReadyState, p.Status())
+
+	// the readiness poll and the final health check
+	assert.Equal(t, int32(4), svc.checks.Load())
+}
+
+func TestWithReadinessTimeout_Timeout(t *testing.T) {
+	svc := &bootingService{mockService: newMockService(t), ready: 100}
+
+	p := NewProvider(WithService(svc), WithReadinessTimeout(300*time.Millisecond))
+
+	start := time.Now()
+	err := p.Init(of.EvaluationContext{})
+	assert.EqualError(t, err, "initializing flipt provider: flipt not ready after 300ms: connection refused")
+	assert.Equal(t, of.ErrorState, p.Status())
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestWithReadinessTimeout_Disabled(t *testing.T) {
+	svc := &bootingService{mockService: newMockService(t), ready: 2}
+
+	p := NewProvider(WithService(svc))
+	assert.EqualError(t, p.Init(of.EvaluationContext{}), "initializing flipt provider: connection refused")
+	assert.Equal(t, int32(1), svc.checks.Load())
+}
@@ -0,0 +1,212 @@
+package flipt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// RedactFunc returns the value to use in place of a sensitive evaluation
+// context value in error messages, logs and telemetry emitted by the provider.
+// It returns the value unchanged for attributes which are not sensitive.
+type RedactFunc func(key string, value interface{}) interface{}
+
+// WithRedactedKeys marks the given evaluation context attributes as sensitive.
+// Their values are replaced with a short hash wherever the provider reports
+// them, so that they can still be correlated without being disclosed.
+func WithRedactedKeys(keys ...string) Option {
+	return func(p *Provider) {
+		p.config.RedactedKeys = append(p.config.RedactedKeys, keys...)
+	}
+}
+
+// WithRedactFunc sets a function used to redact sensitive evaluation context
+// values wherever the provider reports them. It is applied after any keys
+// configured with WithRedactedKeys.
+func WithRedactFunc(fn RedactFunc) Option {
+	return func(p *Provider) {
+		p.config.RedactFunc = fn
+	}
+}
+
+// hashValue returns a short, stable hash of v suitable for correlation.
+func hashValue(v interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v", v)))
+
+	return fmt.Sprintf("sha256:%x", sum[:6])
+}
+
+// redactValue returns the redacted form of the evaluation context value.
+func (p *Provider) redactValue(key string, value interface{}) interface{} {
+	for _, k := range p.config.RedactedKeys {
+		if k == key {
+			value = hashValue(value)
+			break
+		}
+	}
+
+	if p.config.RedactFunc != nil {
+		value = p.config.RedactFunc(key, value)
+	}
+
+	return value
+}
+
+// minRedactedLength is the length below which evaluation context values are
+// not redacted from error messages: short values, e.g. "1" or "eu", occur
+// throughout messages and replacing them would garble rather than protect
+// them.
+const minRedactedLength = 4
+
+// redactedError is an error whose message had sensitive evaluation context
+// values removed. It matches the redacted resolution error first, followed by
+// the original error, so that its sentinel errors still match.
+type redactedError struct {
+	of.ResolutionError
+	err error
+}
+
+func (e *redactedError) Unwrap() []error {
+	return []error{e.ResolutionError, e.err}
+}
+
+// redactError removes sensitive evaluation context values from the message
+// of err, returned by a call to Flipt for an evaluation of evalCtx.
+func (p *Provider) redactError(err error, evalCtx of.FlattenedContext) error {
+	if err == nil || (len(p.config.RedactedKeys) == 0 && p.config.RedactFunc == nil) {
+		return err
+	}
+
+	code, msg := of.GeneralCode, err.Error()
+
+	var rerr of.ResolutionError
+	if errors.As(err, &rerr) {
+		detail := of.ProviderResolutionDetail{ResolutionError: rerr}.ResolutionDetail()
+		code, msg = detail.ErrorCode, detail.ErrorMessage
+	}
+
+	redacted := p.redactMessage(msg, evalCtx)
+	if redacted == msg {
+		return err
+	}
+
+	return &redactedError{ResolutionError: newResolutionError(code, redacted), err: err}
+}
+
+// redactMessage replaces the sensitive evaluation context values occurring
+// in msg as whole tokens. Longer values are replaced first, and values of the
+// same length in key order, so that the result does not depend on the
+// iteration order of evalCtx.
+func (p *Provider) redactMessage(msg string, evalCtx of.FlattenedContext) string {
+	type replacement struct {
+		key, original, redacted string
+	}
+
+	var replacements []replacement
+
+	for k, v := range evalCtx {
+		original := fmt.Sprintf("%v", v)
+		if len(original) < minRedactedLength {
+			continue
+		}
+
+		if redacted := fmt.Sprintf("%v", p.redactValue(k, v)); redacted != original {
+			replacements = append(replacements, replacement{key: k, original: original, redacted: redacted})
+		}
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		if len(replacements[i].original) != len(replacements[j].original) {
+			return len(replacements[i].original) > len(replacements[j].original)
+		}
+
+		return replacements[i].key < replacements[j].key
+	})
+
+	for _, r := range replacements {
+		msg = replaceToken(msg, r.original, r.redacted)
+	}
+
+	return msg
+}
+
+// replaceToken replaces the occurrences of old in s which are not part of a
+// longer word or number, e.g. "123" in "user 123" but not in "51234".
+func replaceToken(s, old, new string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+
+	for i := 0; i <= len(s)-len(old); {
+		n := strings.Index(s[i:], old)
+		if n < 0 {
+			break
+		}
+
+		start, end := i+n, i+n+len(old)
+
+		if isTokenBoundary(s, start, end) {
+			b.WriteString(s[last:start])
+			b.WriteString(new)
+			last, i = end, end
+
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s[start:])
+		i = start + size
+	}
+
+	if last == 0 {
+		return s
+	}
+
+	b.WriteString(s[last:])
+
+	return b.String()
+}
+
+// isTokenBoundary reports whether s[start:end] is delimited by the bounds of
+// s or by characters which are not letters, digits or underscores.
+func isTokenBoundary(s string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWordRune(r) {
+		return false
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// newResolutionError returns a resolution error with the given code and message.
+func newResolutionError(code of.ErrorCode, msg string) of.ResolutionError {
+	switch code {
+	case of.ProviderNotReadyCode:
+		return of.NewProviderNotReadyResolutionError(msg)
+	case of.FlagNotFoundCode:
+		return of.NewFlagNotFoundResolutionError(msg)
+	case of.ParseErrorCode:
+		return of.NewParseErrorResolutionError(msg)
+	case of.TypeMismatchCode:
+		return of.NewTypeMismatchResolutionError(msg)
+	case of.TargetingKeyMissingCode:
+		return of.NewTargetingKeyMissingResolutionError(msg)
+	case of.InvalidContextCode:
+		return of.NewInvalidContextResolutionError(msg)
+	}
+
+	return of.NewGeneralResolutionError(msg)
+}
@@ -0,0 +1,126 @@
+package flipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactValue(t *testing.T) {
+	p := NewProvider(
+		WithService(newMockService(t)),
+		WithRedactedKeys("email"),
+		WithRedactFunc(func(key string, value interface{}) interface{} {
+			if key == "ssn" {
+				return "[REDACTED]"
+			}
+
+			return value
+		}),
+	)
+
+	assert.Equal(t, "123", p.redactValue(of.TargetingKey, "123"))
+	assert.Equal(t, hashValue("foo@flipt.io"), p.redactValue("email", "foo@flipt.io"))
+	assert.Equal(t, "[REDACTED]", p.redactValue("ssn", "123-45-6789"))
+}
+
+func TestRedactError(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewInvalidContextResolutionError(`invalid value "foo@flipt.io" for property email`))
+
+	p := NewProvider(WithService(mockSvc), WithRedactedKeys("email"))
+
+	detail := p.StringEvaluation(context.Background(), "flag", "default", of.FlattenedContext{
+		of.TargetingKey: "123",
+		"email":         "foo@flipt.io",
+	})
+
+	resolution := detail.ResolutionDetail()
+	assert.Equal(t, of.InvalidContextCode, resolution.ErrorCode)
+	assert.NotContains(t, resolution.ErrorMessage, "foo@flipt.io")
+	assert.Contains(t, resolution.ErrorMessage, hashValue("foo@flipt.io"))
+}
+
+func TestRedactError_Tokens(t *testing.T) {
+	p := NewProvider(WithService(newMockService(t)), WithRedactedKeys("email", "user", "id", "region"))
+
+	evalCtx := of.FlattenedContext{
+		"email":  "foo@flipt.io",
+		"user":   "foo@flipt",
+		"id":     "1234",
+		"region": "eu",
+	}
+
+	err := p.redactError(of.NewInvalidContextResolutionError("request 51234 for id 1234 in eu: invalid email foo@flipt.io of user foo@flipt"), evalCtx)
+
+	var rerr of.ResolutionError
+	require.ErrorAs(t, err, &rerr)
+
+	// longer values are replaced first, values shorter than the minimum
+	// length and occurrences within longer tokens are left as is
+	assert.Equal(t, fmt.Sprintf("INVALID_CONTEXT: request 51234 for id %s in eu: invalid email %s of user %s",
+		hashValue("1234"), hashValue("foo@flipt.io"), hashValue("foo@flipt")), rerr.Error())
+}
+
+func TestRedactError_Chain(t *testing.T) {
+	p := NewProvider(WithService(newMockService(t)), WithRedactedKeys("email"))
+
+	cause := errors.Join(of.NewProviderNotReadyResolutionError("credentials of foo@flipt.io rejected"), ErrUnauthenticated)
+
+	err := p.redactError(cause, of.FlattenedContext{"email": "foo@flipt.io"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.NotContains(t, err.Error(), "foo@flipt.io")
+
+	var rerr of.ResolutionError
+	require.ErrorAs(t, err, &rerr)
+
+	detail := of.ProviderResolutionDetail{ResolutionError: rerr}.ResolutionDetail()
+	assert.Equal(t, of.ProviderNotReadyCode, detail.ErrorCode)
+	assert.Equal(t, "credentials of "+hashValue("foo@flipt.io")+" rejected", detail.ErrorMessage)
+
+	// errors without sensitive values are returned as is
+	assert.Same(t, cause, p.redactError(cause, of.FlattenedContext{"email": "bar@flipt.io"}))
+}
+
+func TestRedactedSinks(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewInvalidContextResolutionError(`invalid value "foo@flipt.io" for property email`))
+
+	var (
+		buf    bytes.Buffer
+		sink   = &recordingSink{}
+		events = make(chan EvaluationEvent, 1)
+	)
+
+	p := NewProvider(
+		WithService(mockSvc),
+		WithRedactedKeys(of.TargetingKey, "email"),
+		WithAuditSink(sink),
+		WithEvaluationCallback(func(e EvaluationEvent) { events <- e }),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+
+	p.StringEvaluation(context.Background(), "flag", "default", of.FlattenedContext{
+		of.TargetingKey: "user-1",
+		"email":         "foo@flipt.io",
+	})
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, hashValue("user-1"), sink.events[0].Entity)
+	assert.NotContains(t, sink.events[0].Error.Error(), "foo@flipt.io")
+
+	event := <-events
+	assert.Equal(t, hashValue("user-1"), event.Entity)
+	assert.NotContains(t, event.Error.Error(), "foo@flipt.io")
+
+	assert.Contains(t, buf.String(), "flag evaluation failed")
+	assert.NotContains(t, buf.String(), "foo@flipt.io")
+}
@@ -0,0 +1,59 @@
+package flipt
+
+import (
+	"context"
+	"math/rand"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// WithTelemetrySampling records only a fraction, successRate (e.g. 0.01 for
+// 1%), of the successful evaluations with the logging and metrics hooks and
+// the audit sink, so that high-QPS services are not overwhelmed by exposure
+// telemetry. Failed evaluations are always recorded, as are the evaluation
+// requests counted by the metrics hook.
+func WithTelemetrySampling(successRate float64) Option {
+	return func(p *Provider) {
+		p.config.TelemetrySampleRate = successRate
+	}
+}
+
+// sampleSuccess reports whether a successful evaluation is recorded.
+func (p *Provider) sampleSuccess() bool {
+	return sample(p.config.TelemetrySampleRate)
+}
+
+// sample reports whether a successful evaluation is recorded at rate, any
+// rate outside of (0, 1) recording every evaluation.
+func sample(rate float64) bool {
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+
+	return rand.Float64() < rate //nolint:gosec
+}
+
+// sampledHook is a hook running the After stage of another hook for a
+// sample of the successful evaluations only.
+type sampledHook struct {
+	of.Hook
+
+	rate float64
+}
+
+func (h sampledHook) After(ctx context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, hints of.HookHints) error {
+	if !sample(h.rate) {
+		return nil
+	}
+
+	return h.Hook.After(ctx, hookContext, details, hints)
+}
+
+// sampled returns hook sampling successful evaluations as configured.
+func (p *Provider) sampled(hook of.Hook) of.Hook {
+	if rate := p.config.TelemetrySampleRate; rate > 0 && rate < 1 {
+		return sampledHook{Hook: hook, rate: rate}
+	}
+
+	return hook
+}
@@ -0,0 +1,68 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+type countingHook struct {
+	of.UnimplementedHook
+
+	after, errors *int
+}
+
+func (h countingHook) After(context.Context, of.HookContext, of.InterfaceEvaluationDetails, of.HookHints) error {
+	*h.after++
+	return nil
+}
+
+func (h countingHook) Error(context.Context, of.HookContext, error, of.HookHints) {
+	*h.errors++
+}
+
+func TestWithTelemetrySampling(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+	mockSvc.On("Boolean", mock.Anything, "default", "missing", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found"))
+
+	sink := &recordingSink{}
+
+	// a rate low enough for no success to be sampled in practice
+	p := NewProvider(WithService(mockSvc), WithAuditSink(sink), WithTelemetrySampling(1e-12))
+
+	for i := 0; i < 100; i++ {
+		p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	}
+
+	p.BooleanEvaluation(context.Background(), "missing", false, of.FlattenedContext{})
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, "missing", sink.events[0].Flag)
+}
+
+func TestSampledHook(t *testing.T) {
+	var (
+		after, errors int
+		hook          = countingHook{after: &after, errors: &errors}
+		p             = NewProvider(WithTelemetrySampling(1e-12))
+		hookContext   = of.NewHookContext("flag", of.Boolean, false, of.NewClientMetadata("test"), p.Metadata(), of.EvaluationContext{})
+	)
+
+	sampled := p.sampled(hook)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, sampled.After(context.Background(), hookContext, of.InterfaceEvaluationDetails{}, of.HookHints{}))
+		sampled.Error(context.Background(), hookContext, assert.AnError, of.HookHints{})
+	}
+
+	assert.Equal(t, 0, after)
+	assert.Equal(t, 100, errors)
+
+	// hooks are left untouched when every evaluation is recorded
+	assert.Equal(t, hook, NewProvider(WithTelemetrySampling(1)).sampled(hook))
+}
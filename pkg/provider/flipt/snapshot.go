@@ -0,0 +1,80 @@
+package flipt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// snapshotVersion is the version of the format written by ExportCache.
+const snapshotVersion = 1
+
+// errCacheNotExportable is returned by ExportCache when the cache cannot list
+// its values.
+var errCacheNotExportable = errors.New("cache does not support exporting its values")
+
+// CacheItem is a value stored in a cache, as exported by ExportCache.
+type CacheItem struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// cacheSnapshot is the document written by ExportCache.
+type cacheSnapshot struct {
+	Version int         `json:"version"`
+	Items   []CacheItem `json:"items"`
+}
+
+// ExportCache writes the unexpired cached results as JSON to w, e.g. to seed
+// tests or canary environments with a known evaluation state using
+// ImportCache, or to snapshot it before a maintenance window. The cache must
+// list its values through an Items() []CacheItem method, as LRUCache does.
+func (p *Provider) ExportCache(_ context.Context, w io.Writer) error {
+	lister, ok := p.config.Cache.(interface{ Items() []CacheItem })
+	if !ok {
+		return errCacheNotExportable
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(cacheSnapshot{Version: snapshotVersion, Items: lister.Items()})
+}
+
+// ImportCache stores the cached results exported by ExportCache, skipping
+// those which have expired since. The most recently used results are stored
+// last, so that they are evicted last.
+func (p *Provider) ImportCache(ctx context.Context, r io.Reader) error {
+	if p.config.Cache == nil {
+		return errors.New("no cache is set")
+	}
+
+	var snapshot cacheSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return fmt.Errorf("decoding cache snapshot: %w", err)
+	}
+
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported cache snapshot version %d", snapshot.Version)
+	}
+
+	// items are exported from the most to the least recently used
+	for i := len(snapshot.Items) - 1; i >= 0; i-- {
+		item := snapshot.Items[i]
+
+		ttl := time.Until(item.ExpiresAt)
+		if ttl <= 0 {
+			continue
+		}
+
+		if err := p.config.Cache.Set(ctx, item.Key, item.Value, ttl); err != nil {
+			return fmt.Errorf("importing cached result %q: %w", item.Key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package flipt
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestExportImportCache(t *testing.T) {
+	ctx := context.Background()
+
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "default", "flag", map[string]interface{}{of.TargetingKey: "user"}).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil).Once()
+
+	source := NewProvider(WithService(mockSvc), WithCache(NewLRUCache(10), time.Minute))
+	source.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+
+	var buf bytes.Buffer
+	require.NoError(t, source.ExportCache(ctx, &buf))
+
+	// the seeded provider serves the exported state without calling Flipt
+	cache := NewLRUCache(10)
+	seeded := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute))
+	require.NoError(t, seeded.ImportCache(ctx, &buf))
+
+	detail := seeded.StringEvaluation(ctx, "flag", "red", of.FlattenedContext{of.TargetingKey: "user"})
+	assert.Equal(t, "blue", detail.Value)
+	assert.Equal(t, true, detail.FlagMetadata["cached"])
+}
+
+func TestImportCache(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		expires = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		expired = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	)
+
+	cache := NewLRUCache(10)
+	p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute))
+
+	require.NoError(t, p.ImportCache(ctx, strings.NewReader(`{"version":1,"items":[
+		{"key":"a","value":{"response":{}},"expiresAt":"`+expires+`"},
+		{"key":"b","value":{"response":{}},"expiresAt":"`+expires+`"},
+		{"key":"c","value":{"response":{}},"expiresAt":"`+expired+`"}
+	]}`)))
+
+	items := cache.Items()
+	require.Len(t, items, 2)
+	assert.Equal(t, "a", items[0].Key, "most recently used item must be imported last")
+	assert.Equal(t, "b", items[1].Key)
+
+	assert.EqualError(t, p.ImportCache(ctx, strings.NewReader(`{"version":2}`)), "unsupported cache snapshot version 2")
+	assert.ErrorContains(t, p.ImportCache(ctx, strings.NewReader(`nope`)), "decoding cache snapshot")
+}
+
+func TestExportCacheNotSupported(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+
+	p := NewProvider(WithService(newMockService(t)), WithCache(cache, time.Minute))
+	assert.ErrorIs(t, p.ExportCache(context.Background(), &bytes.Buffer{}), errCacheNotExportable)
+}
@@ -0,0 +1,216 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/transport"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+// splitTargetingTTL is how long the targeting of a flag listed to tell split
+// matches apart is kept.
+const splitTargetingTTL = 30 * time.Second
+
+// WithSplitReason reports matches served by a percentage distribution of a
+// rule, or by a threshold rollout of a boolean flag, with the SPLIT reason
+// rather than TARGETING_MATCH.
+//
+// Flipt reports these matches with the same reason as any other match, so
+// the rules or rollouts of a flag are listed on its first match, at the cost
+// of a call to Flipt, and kept for 30 seconds or until the flag is
+// invalidated. A match is only reported as SPLIT when every rule or rollout
+// which may have served it is a split: rules are told apart by the segments
+// returned with variant evaluations, which boolean evaluations lack.
+// Services unable to list targeting always report TARGETING_MATCH.
+func WithSplitReason() Option {
+	return func(p *Provider) {
+		p.config.SplitReason = true
+	}
+}
+
+// splitTargeting holds the targeting of flags listed to tell split matches
+// apart, keyed by flag key. It is shared by a provider and the providers
+// derived from it.
+type splitTargeting struct {
+	mu    sync.Mutex
+	flags map[string]splitEntry
+}
+
+type splitEntry struct {
+	rules    []*flipt.Rule
+	rollouts []*flipt.Rollout
+	expires  time.Time
+}
+
+// forget drops the targeting of flags, or of every flag if none is given.
+func (t *splitTargeting) forget(flags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(flags) == 0 {
+		t.flags = map[string]splitEntry{}
+		return
+	}
+
+	for _, flag := range flags {
+		delete(t.flags, flag)
+	}
+}
+
+// targeting returns the targeting of flag, listing the rollouts of boolean
+// flags and the rules of variant flags when not known.
+func (t *splitTargeting) targeting(ctx context.Context, lister targetingLister, namespace, flag string, boolean bool) (splitEntry, error) {
+	t.mu.Lock()
+	entry, ok := t.flags[flag]
+	t.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry, nil
+	}
+
+	entry = splitEntry{expires: time.Now().Add(splitTargetingTTL)}
+
+	var err error
+	if boolean {
+		entry.rollouts, err = lister.ListRollouts(ctx, namespace, flag)
+	} else {
+		entry.rules, err = lister.ListRules(ctx, namespace, flag)
+	}
+
+	if err != nil {
+		return splitEntry{}, err
+	}
+
+	t.mu.Lock()
+	t.flags[flag] = entry
+	t.mu.Unlock()
+
+	return entry, nil
+}
+
+// booleanMatchReason returns the reason of a successful boolean evaluation,
+// reporting SPLIT for matches which can only have been served by threshold
+// rollouts.
+func (p *Provider) booleanMatchReason(ctx context.Context, flag string, resp *evaluation.BooleanEvaluationResponse) of.Reason {
+	reason := matchReason(resp.Reason)
+	if reason != of.TargetingMatchReason || p.splits == nil {
+		return reason
+	}
+
+	entry, ok := p.splitTargeting(ctx, flag, true)
+	if !ok || len(entry.rollouts) == 0 {
+		return reason
+	}
+
+	for _, rollout := range entry.rollouts {
+		if !splitRollout(rollout) {
+			return reason
+		}
+	}
+
+	return of.SplitReason
+}
+
+// variantMatchReason returns the reason of a successful variant evaluation,
+// reporting SPLIT for matches which can only have been served by rules
+// distributing their variants by percentage.
+func (p *Provider) variantMatchReason(ctx context.Context, flag string, resp *evaluation.VariantEvaluationResponse) of.Reason {
+	reason := matchReason(resp.Reason)
+	if reason != of.TargetingMatchReason || p.splits == nil {
+		return reason
+	}
+
+	entry, ok := p.splitTargeting(ctx, flag, false)
+	if !ok {
+		return reason
+	}
+
+	candidates := 0
+	for _, rule := range entry.rules {
+		if !ruleMatches(rule, resp.SegmentKeys) {
+			continue
+		}
+
+		if !splitRule(rule) {
+			return reason
+		}
+
+		candidates++
+	}
+
+	if candidates == 0 {
+		return reason
+	}
+
+	return of.SplitReason
+}
+
+// splitTargeting returns the targeting of flag, reporting false when it
+// cannot be listed.
+func (p *Provider) splitTargeting(ctx context.Context, flag string, boolean bool) (splitEntry, bool) {
+	lister, ok := p.svc.(targetingLister)
+	if !ok {
+		return splitEntry{}, false
+	}
+
+	// the listing is not recorded as a call made by the evaluation
+	ctx, _ = transport.ContextWithCallInfo(ctx)
+
+	entry, err := p.splits.targeting(ctx, lister, p.config.Namespace, flag, boolean)
+	if err != nil {
+		p.config.Logger.Debug("listing flag targeting failed", "namespace", p.config.Namespace, "flag", flag, "error", err)
+		return splitEntry{}, false
+	}
+
+	return entry, true
+}
+
+// ruleMatches reports whether rule may have matched the segments returned by
+// Flipt, any rule matching when no segment was returned.
+func ruleMatches(rule *flipt.Rule, segmentKeys []string) bool {
+	keys := rule.SegmentKeys
+	if len(keys) == 0 && rule.SegmentKey != "" {
+		keys = []string{rule.SegmentKey}
+	}
+
+	ruleKeys := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		ruleKeys[key] = true
+	}
+
+	for _, key := range segmentKeys {
+		if !ruleKeys[key] {
+			return false
+		}
+	}
+
+	// rules combining their segments with AND match all of them
+	if rule.SegmentOperator == flipt.SegmentOperator_AND_SEGMENT_OPERATOR && len(segmentKeys) > 0 {
+		return len(segmentKeys) == len(ruleKeys)
+	}
+
+	return true
+}
+
+// splitRule reports whether rule distributes its variants by percentage.
+func splitRule(rule *flipt.Rule) bool {
+	switch len(rule.Distributions) {
+	case 0:
+		return false
+	case 1:
+		return rule.Distributions[0].Rollout < 100
+	default:
+		return true
+	}
+}
+
+// splitRollout reports whether rollout applies to a percentage of entities.
+func splitRollout(rollout *flipt.Rollout) bool {
+	threshold := rollout.GetThreshold()
+
+	return threshold != nil && threshold.Percentage < 100
+}
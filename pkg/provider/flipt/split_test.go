@@ -0,0 +1,86 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+func TestWithSplitReason_Variant(t *testing.T) {
+	svc := &targetingService{
+		listingService: &listingService{mockService: newMockService(t)},
+		rules: []*flipt.Rule{
+			{FlagKey: "color", SegmentKey: "admins", Rank: 1, Distributions: []*flipt.Distribution{{VariantId: "red", Rollout: 100}}},
+			{FlagKey: "color", SegmentKey: "users", Rank: 2, Distributions: []*flipt.Distribution{{VariantId: "red", Rollout: 50}, {VariantId: "blue", Rollout: 50}}},
+		},
+	}
+
+	svc.On("Evaluate", mock.Anything, "default", "color", map[string]interface{}{"role": "admin"}).Return(&evaluation.VariantEvaluationResponse{
+		Match:       true,
+		SegmentKeys: []string{"admins"},
+		VariantKey:  "red",
+		Reason:      evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+	svc.On("Evaluate", mock.Anything, "default", "color", map[string]interface{}{"role": "user"}).Return(&evaluation.VariantEvaluationResponse{
+		Match:       true,
+		SegmentKeys: []string{"users"},
+		VariantKey:  "blue",
+		Reason:      evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+
+	p := NewProvider(WithService(svc), WithSplitReason())
+
+	detail := p.StringEvaluation(context.Background(), "color", "green", map[string]interface{}{"role": "admin"})
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+
+	detail = p.StringEvaluation(context.Background(), "color", "green", map[string]interface{}{"role": "user"})
+	assert.Equal(t, of.SplitReason, detail.Reason)
+
+	// the targeting is kept until the flag is invalidated
+	svc.update(func() { svc.rules[1].Distributions = []*flipt.Distribution{{VariantId: "blue", Rollout: 100}} })
+
+	detail = p.StringEvaluation(context.Background(), "color", "green", map[string]interface{}{"role": "user"})
+	assert.Equal(t, of.SplitReason, detail.Reason)
+
+	p.applyInvalidation(context.Background(), Invalidation{Namespace: "default", Flags: []string{"color"}})
+
+	detail = p.StringEvaluation(context.Background(), "color", "green", map[string]interface{}{"role": "user"})
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+}
+
+func TestWithSplitReason_Boolean(t *testing.T) {
+	svc := &targetingService{
+		listingService: &listingService{mockService: newMockService(t)},
+		rollouts: []*flipt.Rollout{
+			{FlagKey: "beta", Rank: 1, Type: flipt.RolloutType_THRESHOLD_ROLLOUT_TYPE, Rule: &flipt.Rollout_Threshold{Threshold: &flipt.RolloutThreshold{Percentage: 20, Value: true}}},
+		},
+	}
+
+	svc.On("Boolean", mock.Anything, "default", "beta", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{
+		Enabled: true,
+		Reason:  evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+
+	p := NewProvider(WithService(svc), WithSplitReason())
+
+	detail := p.BooleanEvaluation(context.Background(), "beta", false, map[string]interface{}{})
+	assert.Equal(t, of.SplitReason, detail.Reason)
+
+	// the match may have been served by the segment rollout
+	svc.update(func() {
+		svc.rollouts = append(svc.rollouts, &flipt.Rollout{FlagKey: "beta", Rank: 2, Type: flipt.RolloutType_SEGMENT_ROLLOUT_TYPE, Rule: &flipt.Rollout_Segment{Segment: &flipt.RolloutSegment{SegmentKey: "users", Value: true}}})
+	})
+	p.applyInvalidation(context.Background(), Invalidation{Namespace: "default"})
+
+	detail = p.BooleanEvaluation(context.Background(), "beta", false, map[string]interface{}{})
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+
+	// matches are reported as such without the option
+	detail = NewProvider(WithService(svc)).BooleanEvaluation(context.Background(), "beta", false, map[string]interface{}{})
+	assert.Equal(t, of.TargetingMatchReason, detail.Reason)
+}
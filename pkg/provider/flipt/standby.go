@@ -0,0 +1,355 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+var (
+	_ of.FeatureProvider = (*StandbyPair)(nil)
+	_ of.StateHandler    = (*StandbyPair)(nil)
+	_ of.EventHandler    = (*StandbyPair)(nil)
+)
+
+const (
+	defaultStandbyErrorBudget         = 5
+	defaultStandbyHealthCheckInterval = 10 * time.Second
+	standbyHealthCheckTimeout         = 5 * time.Second
+)
+
+// StandbyOption is a configuration option for a StandbyPair.
+type StandbyOption func(*StandbyPair)
+
+// WithStandbyErrorBudget sets the number of consecutive failed evaluations
+// against the active provider after which a healthy standby is promoted.
+// A budget of zero disables automatic promotion.
+func WithStandbyErrorBudget(budget int) StandbyOption {
+	return func(s *StandbyPair) {
+		s.budget = budget
+	}
+}
+
+// WithStandbyHealthCheckInterval sets how often the standby provider is
+// health checked to keep its connection warm.
+func WithStandbyHealthCheckInterval(interval time.Duration) StandbyOption {
+	return func(s *StandbyPair) {
+		s.interval = interval
+	}
+}
+
+// StandbyPair is a FeatureProvider which evaluates flags against an active
+// provider while keeping a standby provider (e.g. configured against a Flipt
+// instance in another region or a read replica) warm via health checks.
+//
+// The standby is promoted either explicitly using Switchover or automatically
+// once the active provider exhausts its error budget and the standby is healthy.
+//
+// The pair initializes and shuts down both providers and emits the events of
+// the active one, followed by a PROVIDER_CONFIGURATION_CHANGED event on each
+// switchover.
+type StandbyPair struct {
+	budget   int
+	interval time.Duration
+
+	mu             sync.RWMutex
+	active         *Provider
+	standby        *Provider
+	failures       int
+	standbyHealthy bool
+
+	events    chan of.Event
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewStandbyPair returns a StandbyPair evaluating flags against primary with
+// standby kept warm in the background. Close must be called to stop the
+// background health checks.
+func NewStandbyPair(primary, standby *Provider, opts ...StandbyOption) *StandbyPair {
+	s := &StandbyPair{
+		budget:   defaultStandbyErrorBudget,
+		interval: defaultStandbyHealthCheckInterval,
+		active:   primary,
+		standby:  standby,
+		events:   make(chan of.Event, eventBufferSize),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(3)
+	go s.run(ctx)
+	go s.forward(ctx, primary)
+	go s.forward(ctx, standby)
+
+	return s
+}
+
+func (s *StandbyPair) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.checkStandby(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// forward emits the events of p while it is the active provider, until ctx
+// is done.
+func (s *StandbyPair) forward(ctx context.Context, p *Provider) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.EventChannel():
+			if s.Active() == p {
+				s.emit(event)
+			}
+		}
+	}
+}
+
+// emit sends an event without blocking, dropping it when the channel buffer
+// is full.
+func (s *StandbyPair) emit(event of.Event) {
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+// checkStandby health checks the standby provider, bounded by the health
+// check interval and standbyHealthCheckTimeout, whichever is shorter.
+func (s *StandbyPair) checkStandby(ctx context.Context) {
+	s.mu.RLock()
+	standby := s.standby
+	s.mu.RUnlock()
+
+	timeout := standbyHealthCheckTimeout
+	if s.interval < timeout {
+		timeout = s.interval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	healthy := standby.check(ctx) == nil
+
+	s.mu.Lock()
+	// the pair may have been switched over while checking
+	if s.standby == standby {
+		s.standbyHealthy = healthy
+	}
+	s.mu.Unlock()
+}
+
+// Close stops the background health checks. It does not shut down the
+// providers of the pair and may be called more than once.
+func (s *StandbyPair) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+	})
+}
+
+// Init initializes both providers of the pair. It only fails when both of
+// them fail, switching over to the standby when only the active one fails.
+func (s *StandbyPair) Init(evalCtx of.EvaluationContext) error {
+	s.mu.RLock()
+	active, standby := s.active, s.standby
+	s.mu.RUnlock()
+
+	activeErr := active.Init(evalCtx)
+	standbyErr := standby.Init(evalCtx)
+
+	switch {
+	case activeErr != nil && standbyErr != nil:
+		return errors.Join(activeErr, standbyErr)
+	case activeErr != nil:
+		s.mu.Lock()
+		if s.active == active {
+			s.switchover()
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Shutdown stops the background health checks and shuts down both providers
+// of the pair.
+func (s *StandbyPair) Shutdown() {
+	s.Close()
+
+	s.mu.RLock()
+	active, standby := s.active, s.standby
+	s.mu.RUnlock()
+
+	active.Shutdown()
+	standby.Shutdown()
+}
+
+// Status returns the state of the active provider.
+func (s *StandbyPair) Status() of.State {
+	return s.Active().Status()
+}
+
+// EventChannel returns the channel on which the pair emits the events of the
+// active provider.
+func (s *StandbyPair) EventChannel() <-chan of.Event {
+	return s.events
+}
+
+// Active returns the provider currently serving evaluations.
+func (s *StandbyPair) Active() *Provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.active
+}
+
+// Switchover promotes the standby provider to active and demotes the active
+// provider to standby.
+func (s *StandbyPair) Switchover() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.switchover()
+}
+
+func (s *StandbyPair) switchover() {
+	s.active.config.Logger.Warn("switching over to standby flipt provider", "from", s.active.config.Address, "to", s.standby.config.Address)
+
+	s.active, s.standby = s.standby, s.active
+	s.failures = 0
+	// the demoted provider is unhealthy until the next check proves otherwise
+	s.standbyHealthy = false
+
+	// flags may evaluate differently against the promoted provider
+	s.emit(of.Event{
+		ProviderName: s.active.Metadata().Name,
+		EventType:    of.ProviderConfigChange,
+		ProviderEventDetails: of.ProviderEventDetails{
+			Message: fmt.Sprintf("switched over to flipt at %q", s.active.config.Address),
+		},
+	})
+}
+
+// observe accounts the outcome of an evaluation served by p, which failed
+// with err if any, against the error budget, promoting the standby once the
+// budget is exhausted.
+func (s *StandbyPair) observe(p *Provider, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ignore results from a provider which has been demoted in the meantime
+	if s.active != p {
+		return
+	}
+
+	if !isBackendError(err) {
+		s.failures = 0
+		return
+	}
+
+	s.failures++
+
+	if s.budget > 0 && s.failures >= s.budget && s.standbyHealthy {
+		s.switchover()
+	}
+}
+
+// evaluationErrorKey is the context key of the error receiving the error an
+// evaluation fails with, as recorded by recordError.
+type evaluationErrorKey struct{}
+
+// contextWithEvaluationError returns a context recording the error the
+// evaluation made with it fails with into err.
+func contextWithEvaluationError(ctx context.Context, err *error) context.Context {
+	return context.WithValue(ctx, evaluationErrorKey{}, err)
+}
+
+// Metadata returns the metadata of the provider.
+func (s *StandbyPair) Metadata() of.Metadata {
+	return s.Active().Metadata()
+}
+
+// BooleanEvaluation returns a boolean flag from the active provider.
+func (s *StandbyPair) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx of.FlattenedContext) of.BoolResolutionDetail {
+	p := s.Active()
+
+	var err error
+	detail := p.BooleanEvaluation(contextWithEvaluationError(ctx, &err), flag, defaultValue, evalCtx)
+	s.observe(p, err)
+
+	return detail
+}
+
+// StringEvaluation returns a string flag from the active provider.
+func (s *StandbyPair) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx of.FlattenedContext) of.StringResolutionDetail {
+	p := s.Active()
+
+	var err error
+	detail := p.StringEvaluation(contextWithEvaluationError(ctx, &err), flag, defaultValue, evalCtx)
+	s.observe(p, err)
+
+	return detail
+}
+
+// FloatEvaluation returns a float flag from the active provider.
+func (s *StandbyPair) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx of.FlattenedContext) of.FloatResolutionDetail {
+	p := s.Active()
+
+	var err error
+	detail := p.FloatEvaluation(contextWithEvaluationError(ctx, &err), flag, defaultValue, evalCtx)
+	s.observe(p, err)
+
+	return detail
+}
+
+// IntEvaluation returns an int flag from the active provider.
+func (s *StandbyPair) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx of.FlattenedContext) of.IntResolutionDetail {
+	p := s.Active()
+
+	var err error
+	detail := p.IntEvaluation(contextWithEvaluationError(ctx, &err), flag, defaultValue, evalCtx)
+	s.observe(p, err)
+
+	return detail
+}
+
+// ObjectEvaluation returns an object flag from the active provider.
+func (s *StandbyPair) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx of.FlattenedContext) of.InterfaceResolutionDetail {
+	p := s.Active()
+
+	var err error
+	detail := p.ObjectEvaluation(contextWithEvaluationError(ctx, &err), flag, defaultValue, evalCtx)
+	s.observe(p, err)
+
+	return detail
+}
+
+// Hooks returns the hooks of the active provider.
+func (s *StandbyPair) Hooks() []of.Hook {
+	return s.Active().Hooks()
+}
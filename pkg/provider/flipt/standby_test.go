@@ -0,0 +1,208 @@
+package flipt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+type checkingService struct {
+	*mockService
+	err error
+}
+
+func (c checkingService) Check(context.Context) error {
+	return c.err
+}
+
+func TestStandbyPair_Promotion(t *testing.T) {
+	primarySvc := newMockService(t)
+	primarySvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewProviderNotReadyResolutionError("unavailable"))
+
+	standbySvc := newMockService(t)
+	standbySvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+
+	primary := NewProvider(WithService(primarySvc))
+	standby := NewProvider(WithService(checkingService{mockService: standbySvc}))
+
+	s := NewStandbyPair(primary, standby, WithStandbyErrorBudget(2), WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	require.Eventually(t, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		return s.standbyHealthy
+	}, time.Second, time.Millisecond)
+
+	detail := s.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Equal(t, of.ProviderNotReadyCode, detail.ResolutionDetail().ErrorCode)
+	assert.Same(t, primary, s.Active())
+
+	s.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Same(t, standby, s.Active(), "standby should be promoted once the error budget is exhausted")
+
+	detail = s.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.True(t, detail.Value)
+}
+
+func TestStandbyPair_Canceled(t *testing.T) {
+	primarySvc := newMockService(t)
+	primarySvc.On("Boolean", mock.Anything, "default", "canceled", mock.Anything).Return(nil, canceledError())
+	// failures are told apart by their cause rather than their message
+	primarySvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewGeneralResolutionError("canceled: upstream reset"))
+
+	standbySvc := newMockService(t)
+
+	primary := NewProvider(WithService(primarySvc))
+	standby := NewProvider(WithService(checkingService{mockService: standbySvc}))
+
+	s := NewStandbyPair(primary, standby, WithStandbyErrorBudget(1), WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	require.Eventually(t, func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		return s.standbyHealthy
+	}, time.Second, time.Millisecond)
+
+	// evaluations canceled by their caller do not consume the error budget
+	s.BooleanEvaluation(context.Background(), "canceled", false, of.FlattenedContext{})
+	assert.Same(t, primary, s.Active())
+
+	s.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.Same(t, standby, s.Active())
+}
+
+func TestStandbyPair_UnhealthyStandby(t *testing.T) {
+	primarySvc := newMockService(t)
+	primarySvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, errors.New("boom"))
+
+	primary := NewProvider(WithService(primarySvc))
+	standby := NewProvider(WithService(checkingService{mockService: newMockService(t), err: errors.New("unreachable")}))
+
+	s := NewStandbyPair(primary, standby, WithStandbyErrorBudget(1), WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	for i := 0; i < 3; i++ {
+		s.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	}
+
+	assert.Same(t, primary, s.Active(), "unhealthy standby should not be promoted")
+}
+
+func TestStandbyPair_Switchover(t *testing.T) {
+	primary := NewProvider(WithService(newMockService(t)))
+	standby := NewProvider(WithService(newMockService(t)))
+
+	s := NewStandbyPair(primary, standby, WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	s.Switchover()
+	assert.Same(t, standby, s.Active())
+
+	s.Switchover()
+	assert.Same(t, primary, s.Active())
+}
+
+type blockingService struct {
+	*mockService
+}
+
+func (blockingService) Check(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStandbyPair_Close(t *testing.T) {
+	primary := NewProvider(WithService(newMockService(t)))
+	standby := NewProvider(WithService(blockingService{mockService: newMockService(t)}))
+
+	s := NewStandbyPair(primary, standby, WithStandbyHealthCheckInterval(time.Hour))
+
+	closed := make(chan struct{})
+
+	go func() {
+		// a pending health check does not delay closing
+		s.Close()
+		s.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("pair was not closed")
+	}
+}
+
+func TestStandbyPair_StateHandler(t *testing.T) {
+	primarySvc := &closingService{mockService: newMockService(t)}
+	standbySvc := &closingService{mockService: newMockService(t)}
+
+	primary := NewProvider(WithService(primarySvc))
+	standby := NewProvider(WithService(standbySvc))
+
+	s := NewStandbyPair(primary, standby, WithStandbyHealthCheckInterval(time.Hour))
+	assert.Equal(t, of.NotReadyState, s.Status())
+
+	require.NoError(t, s.Init(of.EvaluationContext{}))
+	assert.Equal(t, of.ReadyState, s.Status())
+	assert.Equal(t, of.ReadyState, standby.Status())
+
+	s.Shutdown()
+	assert.Equal(t, of.NotReadyState, s.Status())
+	assert.True(t, primarySvc.closed)
+	assert.True(t, standbySvc.closed)
+}
+
+func TestStandbyPair_InitError(t *testing.T) {
+	primary := NewProvider(WithService(checkingService{mockService: newMockService(t), err: errors.New("unreachable")}))
+	standby := NewProvider(WithService(newMockService(t)))
+
+	s := NewStandbyPair(primary, standby, WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	// the standby is promoted when only the primary fails to initialize
+	require.NoError(t, s.Init(of.EvaluationContext{}))
+	assert.Same(t, standby, s.Active())
+	assert.Equal(t, of.ReadyState, s.Status())
+
+	s = NewStandbyPair(primary, NewProvider(WithService(checkingService{mockService: newMockService(t), err: errors.New("unreachable")})), WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	assert.Error(t, s.Init(of.EvaluationContext{}))
+}
+
+func TestStandbyPair_Events(t *testing.T) {
+	primary := NewProvider(WithService(newMockService(t)))
+	standby := NewProvider(WithService(newMockService(t)))
+
+	s := NewStandbyPair(primary, standby, WithStandbyHealthCheckInterval(time.Hour))
+	t.Cleanup(s.Close)
+
+	s.Switchover()
+
+	event := <-s.EventChannel()
+	assert.Equal(t, of.ProviderConfigChange, event.EventType)
+
+	// events of the demoted provider are not forwarded
+	primary.emit(of.ProviderError, of.ProviderEventDetails{Message: "primary"})
+	standby.emit(of.ProviderError, of.ProviderEventDetails{Message: "standby"})
+
+	select {
+	case event := <-s.EventChannel():
+		assert.Equal(t, of.ProviderError, event.EventType)
+		assert.Equal(t, "standby", event.Message)
+	case <-time.After(time.Second):
+		t.Fatal("event was not forwarded")
+	}
+}
@@ -0,0 +1,158 @@
+package flipt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go.flipt.io/flipt-openfeature-provider"
+
+// WithTracerProvider enables OpenTelemetry tracing of evaluations using
+// tracerProvider. Each evaluation is recorded as a span labelled with the
+// flag key, namespace, variant and reason, with a child span for every call
+// made to Flipt.
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(p *Provider) {
+		p.config.TracerProvider = tracerProvider
+	}
+}
+
+// WithSpanEventHook enables a hook, returned from Hooks, which records the
+// "feature_flag" span event of the OpenTelemetry semantic conventions (flag
+// key, provider name and variant) on the span active when a flag is
+// evaluated, so that exposures appear on application traces. Failed
+// evaluations are recorded as errors of the span.
+func WithSpanEventHook() Option {
+	return func(p *Provider) {
+		p.config.SpanEventHook = true
+	}
+}
+
+// spanEventHook records evaluations as events of the active span.
+type spanEventHook struct {
+	of.UnimplementedHook
+}
+
+func (spanEventHook) After(ctx context.Context, hookContext of.HookContext, details of.InterfaceEvaluationDetails, _ of.HookHints) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	// the value stands in for the variant when the flag has none, e.g. a
+	// boolean flag, as recommended by the conventions
+	variant := details.Variant
+	if variant == "" {
+		variant = fmt.Sprint(details.Value)
+	}
+
+	span.AddEvent("feature_flag", trace.WithAttributes(
+		attribute.String("feature_flag.key", hookContext.FlagKey()),
+		attribute.String("feature_flag.provider_name", hookContext.ProviderMetadata().Name),
+		attribute.String("feature_flag.variant", variant),
+	))
+
+	return nil
+}
+
+func (spanEventHook) Error(ctx context.Context, hookContext of.HookContext, err error, _ of.HookHints) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.RecordError(err, trace.WithAttributes(
+		attribute.String("feature_flag.key", hookContext.FlagKey()),
+		attribute.String("feature_flag.provider_name", hookContext.ProviderMetadata().Name),
+	))
+}
+
+// tracer returns the tracer spans are recorded with, or nil when tracing is
+// disabled.
+func (p *Provider) tracer() trace.Tracer {
+	if p.config.TracerProvider == nil {
+		return nil
+	}
+
+	return p.config.TracerProvider.Tracer(tracerName)
+}
+
+// evaluationSpan is the span recording a single evaluation.
+type evaluationSpan struct {
+	span trace.Span
+	// start is the time the evaluation started.
+	start time.Time
+}
+
+// startEvaluation starts the span of the evaluation of flag, returning a
+// context carrying it.
+func (p *Provider) startEvaluation(ctx context.Context, flag string) (context.Context, evaluationSpan) {
+	tracer := p.tracer()
+	if tracer == nil {
+		return ctx, evaluationSpan{start: time.Now()}
+	}
+
+	ctx, span := tracer.Start(ctx, "flipt.evaluation", trace.WithAttributes(
+		attribute.String("feature_flag.key", flag),
+		attribute.String("feature_flag.provider_name", p.Metadata().Name),
+		attribute.String("flipt.namespace", p.config.Namespace),
+	))
+
+	return ctx, evaluationSpan{span: span, start: time.Now()}
+}
+
+// end records the outcome of the evaluation and ends the span.
+func (s evaluationSpan) end(detail of.ProviderResolutionDetail) {
+	if s.span == nil {
+		return
+	}
+
+	defer s.span.End()
+
+	s.span.SetAttributes(attribute.String("feature_flag.reason", string(detail.Reason)))
+
+	if rd := detail.ResolutionDetail(); rd.ErrorCode != "" {
+		s.span.SetAttributes(attribute.String("feature_flag.error_code", string(rd.ErrorCode)))
+		s.span.SetStatus(codes.Error, rd.ErrorMessage)
+	}
+}
+
+// traceVariant records the variant matched by resp on the evaluation span
+// carried by ctx.
+func (p *Provider) traceVariant(ctx context.Context, resp *evaluation.VariantEvaluationResponse) {
+	if p.config.TracerProvider == nil || resp == nil || !resp.Match {
+		return
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("feature_flag.variant", resp.VariantKey))
+}
+
+// traced calls fn, which calls method of Flipt for flag, within a child span
+// of the evaluation carried by ctx.
+func (p *Provider) traced(ctx context.Context, method, flag string, fn func(ctx context.Context) error) error {
+	tracer := p.tracer()
+	if tracer == nil {
+		return fn(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "flipt."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("feature_flag.key", flag),
+		attribute.String("flipt.namespace", p.config.Namespace),
+	))
+	defer span.End()
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,208 @@
+package flipt
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordedSpan struct {
+	trace.Span
+
+	name   string
+	parent *recordedSpan
+	kind   trace.SpanKind
+	attrs  map[attribute.Key]attribute.Value
+	code   codes.Code
+	errors []error
+	events []recordedEvent
+	ended  bool
+}
+
+type recordedEvent struct {
+	name  string
+	attrs []attribute.KeyValue
+}
+
+func (s *recordedSpan) IsRecording() bool { return true }
+
+func (s *recordedSpan) AddEvent(name string, opts ...trace.EventOption) {
+	config := trace.NewEventConfig(opts...)
+	s.events = append(s.events, recordedEvent{name: name, attrs: config.Attributes()})
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) { s.code = code }
+
+func (s *recordedSpan) RecordError(err error, opts ...trace.EventOption) {
+	s.errors = append(s.errors, err)
+	s.AddEvent("exception", opts...)
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.ended = true }
+
+func (s *recordedSpan) attr(key string) string {
+	return s.attrs[attribute.Key(key)].Emit()
+}
+
+type recordingTracerProvider struct {
+	trace.TracerProvider
+
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tp *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{tp: tp}
+}
+
+type recordingTracer struct {
+	trace.Tracer
+
+	tp *recordingTracerProvider
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+
+	span := &recordedSpan{
+		Span:  trace.SpanFromContext(context.Background()),
+		name:  name,
+		kind:  config.SpanKind(),
+		attrs: map[attribute.Key]attribute.Value{},
+	}
+	span.parent, _ = trace.SpanFromContext(ctx).(*recordedSpan)
+	span.SetAttributes(config.Attributes()...)
+
+	t.tp.mu.Lock()
+	t.tp.spans = append(t.tp.spans, span)
+	t.tp.mu.Unlock()
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Evaluate", mock.Anything, "flags", "color", mock.Anything).Return(&evaluation.VariantEvaluationResponse{
+		Match:      true,
+		VariantKey: "blue",
+		Reason:     evaluation.EvaluationReason_MATCH_EVALUATION_REASON,
+	}, nil)
+
+	tp := &recordingTracerProvider{}
+
+	p := NewProvider(WithService(mockSvc), ForNamespace("flags"), WithTracerProvider(tp))
+
+	detail := p.StringEvaluation(context.Background(), "color", "red", of.FlattenedContext{})
+	require.Equal(t, "blue", detail.Value)
+
+	require.Len(t, tp.spans, 2)
+
+	eval, call := tp.spans[0], tp.spans[1]
+	assert.Equal(t, "flipt.evaluation", eval.name)
+	assert.Equal(t, "color", eval.attr("feature_flag.key"))
+	assert.Equal(t, "flags", eval.attr("flipt.namespace"))
+	assert.Equal(t, "blue", eval.attr("feature_flag.variant"))
+	assert.Equal(t, string(of.TargetingMatchReason), eval.attr("feature_flag.reason"))
+	assert.Equal(t, codes.Unset, eval.code)
+	assert.True(t, eval.ended)
+
+	assert.Equal(t, "flipt.Evaluate", call.name)
+	assert.Same(t, eval, call.parent)
+	assert.Equal(t, trace.SpanKindClient, call.kind)
+	assert.Equal(t, "color", call.attr("feature_flag.key"))
+	assert.True(t, call.ended)
+}
+
+func TestWithTracerProvider_Error(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(nil, of.NewFlagNotFoundResolutionError("flag not found"))
+
+	tp := &recordingTracerProvider{}
+
+	p := NewProvider(WithService(mockSvc), WithTracerProvider(tp))
+
+	p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+
+	require.Len(t, tp.spans, 2)
+
+	eval, call := tp.spans[0], tp.spans[1]
+	assert.Equal(t, codes.Error, eval.code)
+	assert.Equal(t, string(of.FlagNotFoundCode), eval.attr("feature_flag.error_code"))
+	assert.NotContains(t, eval.attrs, attribute.Key("feature_flag.variant"))
+
+	assert.Equal(t, "flipt.Boolean", call.name)
+	assert.Equal(t, codes.Error, call.code)
+	assert.Len(t, call.errors, 1)
+}
+
+func TestWithTracerProvider_Disabled(t *testing.T) {
+	mockSvc := newMockService(t)
+	mockSvc.On("Boolean", mock.Anything, "default", "flag", mock.Anything).Return(&evaluation.BooleanEvaluationResponse{Enabled: true}, nil)
+
+	p := NewProvider(WithService(mockSvc))
+
+	detail := p.BooleanEvaluation(context.Background(), "flag", false, of.FlattenedContext{})
+	assert.True(t, detail.Value)
+	assert.Nil(t, p.tracer())
+}
+
+func TestSpanEventHook(t *testing.T) {
+	p := NewProvider(WithSpanEventHook())
+	require.Len(t, p.Hooks(), 1)
+
+	hook := p.Hooks()[0]
+
+	tp := &recordingTracerProvider{}
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+
+	for _, tt := range []struct {
+		flag    string
+		details of.InterfaceEvaluationDetails
+		variant string
+	}{
+		{flag: "color", details: of.InterfaceEvaluationDetails{Value: "blue", EvaluationDetails: of.EvaluationDetails{ResolutionDetail: of.ResolutionDetail{Variant: "blue-variant"}}}, variant: "blue-variant"},
+		{flag: "enabled", details: of.InterfaceEvaluationDetails{Value: true}, variant: "true"},
+	} {
+		hookContext := of.NewHookContext(tt.flag, of.String, "default", of.NewClientMetadata("test"), p.Metadata(), of.EvaluationContext{})
+		require.NoError(t, hook.After(ctx, hookContext, tt.details, of.HookHints{}))
+	}
+
+	hookContext := of.NewHookContext("missing", of.String, "default", of.NewClientMetadata("test"), p.Metadata(), of.EvaluationContext{})
+	hook.Error(ctx, hookContext, of.NewFlagNotFoundResolutionError("flag not found"), of.HookHints{})
+
+	recorded := span.(*recordedSpan)
+	assert.Equal(t, []recordedEvent{
+		{name: "feature_flag", attrs: []attribute.KeyValue{
+			attribute.String("feature_flag.key", "color"),
+			attribute.String("feature_flag.provider_name", "flipt-provider"),
+			attribute.String("feature_flag.variant", "blue-variant"),
+		}},
+		{name: "feature_flag", attrs: []attribute.KeyValue{
+			attribute.String("feature_flag.key", "enabled"),
+			attribute.String("feature_flag.provider_name", "flipt-provider"),
+			attribute.String("feature_flag.variant", "true"),
+		}},
+		{name: "exception", attrs: []attribute.KeyValue{
+			attribute.String("feature_flag.key", "missing"),
+			attribute.String("feature_flag.provider_name", "flipt-provider"),
+		}},
+	}, recorded.events)
+
+	// spans which are not recording are left untouched
+	require.NoError(t, hook.After(context.Background(), hookContext, of.InterfaceEvaluationDetails{}, of.HookHints{}))
+}
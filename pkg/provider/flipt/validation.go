@@ -0,0 +1,55 @@
+package flipt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// WithRequiredContext enables a hook, returned from Hooks, which fails
+// evaluations with INVALID_CONTEXT before Flipt is called when any of the
+// given evaluation context attributes is missing or empty. of.TargetingKey
+// refers to the targeting key. Without it, such evaluations are sent to Flipt
+// and silently fail to match any segment.
+func WithRequiredContext(attributes ...string) Option {
+	return func(p *Provider) {
+		p.config.RequiredContext = append(p.config.RequiredContext, attributes...)
+	}
+}
+
+// validationHook checks that required evaluation context attributes are set.
+type validationHook struct {
+	of.UnimplementedHook
+
+	required []string
+}
+
+func (h validationHook) Before(_ context.Context, hookContext of.HookContext, _ of.HookHints) (*of.EvaluationContext, error) {
+	evalCtx := hookContext.EvaluationContext()
+
+	var missing []string
+
+	for _, attr := range h.required {
+		if attr == of.TargetingKey {
+			if evalCtx.TargetingKey() == "" {
+				missing = append(missing, attr)
+			}
+
+			continue
+		}
+
+		if v := evalCtx.Attribute(attr); v == nil || v == "" {
+			missing = append(missing, attr)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, of.NewInvalidContextResolutionError(
+			fmt.Sprintf("flag %q requires evaluation context attributes: %s", hookContext.FlagKey(), strings.Join(missing, ", ")),
+		)
+	}
+
+	return nil, nil
+}
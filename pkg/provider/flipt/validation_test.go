@@ -0,0 +1,62 @@
+package flipt
+
+import (
+	"context"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationHook(t *testing.T) {
+	p := NewProvider(WithRequiredContext(of.TargetingKey, "tenant"))
+	require.Len(t, p.Hooks(), 1)
+
+	hook := p.Hooks()[0]
+
+	tests := []struct {
+		name    string
+		evalCtx of.EvaluationContext
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			evalCtx: of.NewEvaluationContext("user", map[string]interface{}{"tenant": "acme"}),
+		},
+		{
+			name:    "missing targeting key",
+			evalCtx: of.NewEvaluationContext("", map[string]interface{}{"tenant": "acme"}),
+			wantErr: `INVALID_CONTEXT: flag "flag" requires evaluation context attributes: targetingKey`,
+		},
+		{
+			name:    "missing attributes",
+			evalCtx: of.NewEvaluationContext("", map[string]interface{}{"tenant": ""}),
+			wantErr: `INVALID_CONTEXT: flag "flag" requires evaluation context attributes: targetingKey, tenant`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookContext := of.NewHookContext("flag", of.Boolean, false, of.NewClientMetadata("test"), p.Metadata(), tt.evalCtx)
+
+			evalCtx, err := hook.Before(context.Background(), hookContext, of.HookHints{})
+			assert.Nil(t, evalCtx)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var resErr of.ResolutionError
+			require.ErrorAs(t, err, &resErr)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidationHook_RunsFirst(t *testing.T) {
+	p := NewProvider(WithLoggingHook(nil), WithRequiredContext("tenant"))
+	require.Len(t, p.Hooks(), 2)
+	assert.IsType(t, validationHook{}, p.Hooks()[0])
+}
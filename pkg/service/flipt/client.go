@@ -10,6 +10,10 @@ import (
 //go:generate mockery --name=Client --case=underscore --inpackage --filename=service_support.go --testonly --with-expecter --disable-version-string
 type Client interface {
 	GetFlag(ctx context.Context, c *flipt.GetFlagRequest) (*flipt.Flag, error)
+	ListFlags(ctx context.Context, v *flipt.ListFlagRequest) (*flipt.FlagList, error)
+	ListRules(ctx context.Context, v *flipt.ListRuleRequest) (*flipt.RuleList, error)
+	ListRollouts(ctx context.Context, v *flipt.ListRolloutRequest) (*flipt.RolloutList, error)
+	ListSegments(ctx context.Context, v *flipt.ListSegmentRequest) (*flipt.SegmentList, error)
 	Variant(ctx context.Context, v *evaluation.EvaluationRequest) (*evaluation.VariantEvaluationResponse, error)
 	Boolean(ctx context.Context, v *evaluation.EvaluationRequest) (*evaluation.BooleanEvaluationResponse, error)
 }
@@ -134,6 +134,226 @@ func (_c *MockClient_GetFlag_Call) RunAndReturn(run func(context.Context, *rpcfl
 	return _c
 }
 
+// ListFlags provides a mock function with given fields: ctx, v
+func (_m *MockClient) ListFlags(ctx context.Context, v *rpcflipt.ListFlagRequest) (*rpcflipt.FlagList, error) {
+	ret := _m.Called(ctx, v)
+
+	var r0 *rpcflipt.FlagList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListFlagRequest) (*rpcflipt.FlagList, error)); ok {
+		return rf(ctx, v)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListFlagRequest) *rpcflipt.FlagList); ok {
+		r0 = rf(ctx, v)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpcflipt.FlagList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *rpcflipt.ListFlagRequest) error); ok {
+		r1 = rf(ctx, v)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListFlags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListFlags'
+type MockClient_ListFlags_Call struct {
+	*mock.Call
+}
+
+// ListFlags is a helper method to define mock.On call
+//   - ctx context.Context
+//   - v *rpcflipt.ListFlagRequest
+func (_e *MockClient_Expecter) ListFlags(ctx interface{}, v interface{}) *MockClient_ListFlags_Call {
+	return &MockClient_ListFlags_Call{Call: _e.mock.On("ListFlags", ctx, v)}
+}
+
+func (_c *MockClient_ListFlags_Call) Run(run func(ctx context.Context, v *rpcflipt.ListFlagRequest)) *MockClient_ListFlags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*rpcflipt.ListFlagRequest))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListFlags_Call) Return(_a0 *rpcflipt.FlagList, _a1 error) *MockClient_ListFlags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListFlags_Call) RunAndReturn(run func(context.Context, *rpcflipt.ListFlagRequest) (*rpcflipt.FlagList, error)) *MockClient_ListFlags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRollouts provides a mock function with given fields: ctx, v
+func (_m *MockClient) ListRollouts(ctx context.Context, v *rpcflipt.ListRolloutRequest) (*rpcflipt.RolloutList, error) {
+	ret := _m.Called(ctx, v)
+
+	var r0 *rpcflipt.RolloutList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListRolloutRequest) (*rpcflipt.RolloutList, error)); ok {
+		return rf(ctx, v)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListRolloutRequest) *rpcflipt.RolloutList); ok {
+		r0 = rf(ctx, v)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpcflipt.RolloutList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *rpcflipt.ListRolloutRequest) error); ok {
+		r1 = rf(ctx, v)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListRollouts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRollouts'
+type MockClient_ListRollouts_Call struct {
+	*mock.Call
+}
+
+// ListRollouts is a helper method to define mock.On call
+//   - ctx context.Context
+//   - v *rpcflipt.ListRolloutRequest
+func (_e *MockClient_Expecter) ListRollouts(ctx interface{}, v interface{}) *MockClient_ListRollouts_Call {
+	return &MockClient_ListRollouts_Call{Call: _e.mock.On("ListRollouts", ctx, v)}
+}
+
+func (_c *MockClient_ListRollouts_Call) Run(run func(ctx context.Context, v *rpcflipt.ListRolloutRequest)) *MockClient_ListRollouts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*rpcflipt.ListRolloutRequest))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListRollouts_Call) Return(_a0 *rpcflipt.RolloutList, _a1 error) *MockClient_ListRollouts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListRollouts_Call) RunAndReturn(run func(context.Context, *rpcflipt.ListRolloutRequest) (*rpcflipt.RolloutList, error)) *MockClient_ListRollouts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRules provides a mock function with given fields: ctx, v
+func (_m *MockClient) ListRules(ctx context.Context, v *rpcflipt.ListRuleRequest) (*rpcflipt.RuleList, error) {
+	ret := _m.Called(ctx, v)
+
+	var r0 *rpcflipt.RuleList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListRuleRequest) (*rpcflipt.RuleList, error)); ok {
+		return rf(ctx, v)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListRuleRequest) *rpcflipt.RuleList); ok {
+		r0 = rf(ctx, v)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpcflipt.RuleList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *rpcflipt.ListRuleRequest) error); ok {
+		r1 = rf(ctx, v)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListRules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRules'
+type MockClient_ListRules_Call struct {
+	*mock.Call
+}
+
+// ListRules is a helper method to define mock.On call
+//   - ctx context.Context
+//   - v *rpcflipt.ListRuleRequest
+func (_e *MockClient_Expecter) ListRules(ctx interface{}, v interface{}) *MockClient_ListRules_Call {
+	return &MockClient_ListRules_Call{Call: _e.mock.On("ListRules", ctx, v)}
+}
+
+func (_c *MockClient_ListRules_Call) Run(run func(ctx context.Context, v *rpcflipt.ListRuleRequest)) *MockClient_ListRules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*rpcflipt.ListRuleRequest))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListRules_Call) Return(_a0 *rpcflipt.RuleList, _a1 error) *MockClient_ListRules_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListRules_Call) RunAndReturn(run func(context.Context, *rpcflipt.ListRuleRequest) (*rpcflipt.RuleList, error)) *MockClient_ListRules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSegments provides a mock function with given fields: ctx, v
+func (_m *MockClient) ListSegments(ctx context.Context, v *rpcflipt.ListSegmentRequest) (*rpcflipt.SegmentList, error) {
+	ret := _m.Called(ctx, v)
+
+	var r0 *rpcflipt.SegmentList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListSegmentRequest) (*rpcflipt.SegmentList, error)); ok {
+		return rf(ctx, v)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *rpcflipt.ListSegmentRequest) *rpcflipt.SegmentList); ok {
+		r0 = rf(ctx, v)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rpcflipt.SegmentList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *rpcflipt.ListSegmentRequest) error); ok {
+		r1 = rf(ctx, v)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockClient_ListSegments_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSegments'
+type MockClient_ListSegments_Call struct {
+	*mock.Call
+}
+
+// ListSegments is a helper method to define mock.On call
+//   - ctx context.Context
+//   - v *rpcflipt.ListSegmentRequest
+func (_e *MockClient_Expecter) ListSegments(ctx interface{}, v interface{}) *MockClient_ListSegments_Call {
+	return &MockClient_ListSegments_Call{Call: _e.mock.On("ListSegments", ctx, v)}
+}
+
+func (_c *MockClient_ListSegments_Call) Run(run func(ctx context.Context, v *rpcflipt.ListSegmentRequest)) *MockClient_ListSegments_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*rpcflipt.ListSegmentRequest))
+	})
+	return _c
+}
+
+func (_c *MockClient_ListSegments_Call) Return(_a0 *rpcflipt.SegmentList, _a1 error) *MockClient_ListSegments_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockClient_ListSegments_Call) RunAndReturn(run func(context.Context, *rpcflipt.ListSegmentRequest) (*rpcflipt.SegmentList, error)) *MockClient_ListSegments_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Variant provides a mock function with given fields: ctx, v
 func (_m *MockClient) Variant(ctx context.Context, v *evaluation.EvaluationRequest) (*evaluation.VariantEvaluationResponse, error) {
 	ret := _m.Called(ctx, v)
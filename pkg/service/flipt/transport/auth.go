@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorization returns the value of the authorization header sent with
+// each request to Flipt.
+type authorization func(ctx context.Context) (string, error)
+
+// WithJWTAuthentication authenticates requests with token, a JWT verified by
+// Flipt's JWT authentication method. It takes precedence over
+// WithClientTokenProvider, and replaces any WithTokenProvider applied before it.
+func WithJWTAuthentication(token string) Option {
+	return func(s *Service) {
+		s.authorization = func(context.Context) (string, error) {
+			return "JWT " + token, nil
+		}
+		s.invalidateToken = nil
+	}
+}
+
+type unauthenticatedKey struct{}
+
+// unauthenticated marks calls made with ctx, such as the ones obtaining
+// credentials, as not requiring authorization.
+func unauthenticated(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unauthenticatedKey{}, true)
+}
+
+// authorizationInterceptor sets the authorization metadata of gRPC calls.
+// Calls rejected as unauthenticated are made once more with a token
+// exchanged again, when the token provider caches its tokens.
+func (s *Service) authorizationInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if skip, _ := ctx.Value(unauthenticatedKey{}).(bool); skip {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	for retried := false; ; retried = true {
+		value, err := s.authorization(ctx)
+		if err != nil {
+			return status.Errorf(codes.Unauthenticated, "authenticating: %v", err)
+		}
+
+		err = invoker(metadata.AppendToOutgoingContext(ctx, "authorization", value), method, req, reply, cc, opts...)
+		if retried || s.invalidateToken == nil || status.Code(err) != codes.Unauthenticated {
+			return err
+		}
+
+		s.invalidateToken()
+	}
+}
+
+// authTransport is an http.RoundTripper setting the authorization header of
+// requests. Requests rejected as unauthenticated are sent once more with a
+// token exchanged again, when invalidate is set and their body can be read
+// again.
+type authTransport struct {
+	next          http.RoundTripper
+	authorization authorization
+	invalidate    func()
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		value, err := t.authorization(req.Context())
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "authenticating: %v", err)
+		}
+
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", value)
+
+		if retried && req.Body != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if retried || t.invalidate == nil || status.Code(err) != codes.Unauthenticated || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		t.invalidate()
+	}
+}
+
+// WithBasicAuth sets the credentials of HTTP basic authentication sent with
+// every HTTP request, e.g. to a reverse proxy fronting Flipt. As they are
+// sent in the authorization header, they replace the credentials of the other
+// authentication options.
+func WithBasicAuth(username, password string) Option {
+	return func(s *Service) {
+		s.basicAuth = url.UserPassword(username, password)
+	}
+}
+
+// basicAuthTransport is an http.RoundTripper setting the credentials of HTTP
+// basic authentication of requests.
+type basicAuthTransport struct {
+	next     http.RoundTripper
+	userinfo *url.Userinfo
+}
+
+func (t basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	password, _ := t.userinfo.Password()
+
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.userinfo.Username(), password)
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestJWTAuthentication_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "JWT some-jwt", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithJWTAuthentication("some-jwt"))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := s.httpClient().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, req.Header.Get("Authorization"), "request should not be modified")
+}
+
+func TestJWTAuthentication_GRPC(t *testing.T) {
+	s := New(WithJWTAuthentication("some-jwt"))
+
+	err := s.authorizationInterceptor(context.Background(), "/flipt.Flipt/GetFlag", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, []string{"JWT some-jwt"}, md.Get("authorization"))
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestAuthorizationInterceptor_Error(t *testing.T) {
+	s := New()
+	s.authorization = func(context.Context) (string, error) {
+		return "", errors.New("token unavailable")
+	}
+
+	err := s.authorizationInterceptor(context.Background(), "/flipt.Flipt/GetFlag", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		t.Fatal("unexpected call")
+		return nil
+	})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestBasicAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "p@ss", password)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithBasicAuth("user", "p@ss"), WithJWTAuthentication("some-jwt"))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
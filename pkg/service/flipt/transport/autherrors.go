@@ -0,0 +1,42 @@
+package transport
+
+import (
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+)
+
+// UnauthenticatedError is returned when Flipt rejects a call because its
+// credentials are missing, invalid or expired, i.e. an HTTP 401 or a gRPC
+// Unauthenticated status.
+type UnauthenticatedError struct {
+	Message string
+}
+
+func (e *UnauthenticatedError) Error() string {
+	return "flipt rejected the credentials: " + e.Message
+}
+
+// Unwrap returns the resolution error reported to OpenFeature.
+func (e *UnauthenticatedError) Unwrap() error {
+	return of.NewGeneralResolutionError(e.Error())
+}
+
+// Is reports whether target is ErrUnauthenticated.
+func (e *UnauthenticatedError) Is(target error) bool {
+	return target == ErrUnauthenticated
+}
+
+// PermissionDeniedError is returned when Flipt accepts the credentials of a
+// call but does not allow them to perform it, i.e. an HTTP 403 or a gRPC
+// PermissionDenied status.
+type PermissionDeniedError struct {
+	Message string
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return "flipt denied permission: " + e.Message
+}
+
+// Unwrap returns the resolution error reported to OpenFeature.
+func (e *PermissionDeniedError) Unwrap() error {
+	return of.NewGeneralResolutionError(e.Error())
+}
@@ -0,0 +1,35 @@
+package transport
+
+import (
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResolutionError(t *testing.T) {
+	s := New()
+
+	var unauthenticated *UnauthenticatedError
+	err := s.resolutionError(status.Error(codes.Unauthenticated, "request was not authenticated"), "")
+	require.ErrorAs(t, err, &unauthenticated)
+	assert.Equal(t, "flipt rejected the credentials: request was not authenticated", err.Error())
+
+	var rerr of.ResolutionError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, of.NewGeneralResolutionError(err.Error()), rerr)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	var denied *PermissionDeniedError
+	err = s.resolutionError(status.Error(codes.PermissionDenied, "namespace not allowed"), "")
+	require.ErrorAs(t, err, &denied)
+	assert.Equal(t, "flipt denied permission: namespace not allowed", err.Error())
+	assert.NotErrorIs(t, err, ErrUnauthenticated)
+
+	err = s.resolutionError(status.Error(codes.NotFound, "flag not found"), "")
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, of.NewFlagNotFoundResolutionError("flag not found"), rerr)
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"time"
+)
+
+// CallInfo describes how the calls made to Flipt for a single evaluation were
+// served.
+type CallInfo struct {
+	// Attempts is the number of requests sent to Flipt.
+	Attempts int
+	// Latency is the total time spent waiting on Flipt across all attempts.
+	Latency time.Duration
+	// Endpoint is the address of the Flipt instance which served the last attempt.
+	Endpoint string
+	// UnknownEnums holds the raw enum values returned by Flipt which are not
+	// known to this version of the provider, keyed by field name (e.g. "reason").
+	UnknownEnums map[string]string
+	// RequestID, ServerDurationMillis and SegmentKeys are copied from the
+	// last evaluation response returned by Flipt. SegmentKeys are only
+	// returned for variant flags. RequestID is the one sent with the request,
+	// if any, when Flipt does not return a response.
+	RequestID            string
+	ServerDurationMillis float64
+	SegmentKeys          []string
+	// NotModified reports whether Flipt answered the last request with 304
+	// Not Modified, the previous response being reused.
+	NotModified bool
+	// Cached reports whether the evaluation was served from a cache without
+	// calling Flipt.
+	Cached bool
+	// Stale reports whether the cached evaluation had expired.
+	Stale bool
+	// StaleOnError reports whether the expired cached evaluation was served
+	// because Flipt failed to evaluate the flag.
+	StaleOnError bool
+	// Err is the error the evaluation failed with, before it is reported as
+	// an OpenFeature resolution error, which does not carry the errors it
+	// wraps, e.g. ErrCanceled.
+	Err error
+}
+
+type callInfoKey struct{}
+
+// ContextWithCallInfo returns a context which records the calls made to Flipt
+// by the Service into the returned CallInfo.
+func ContextWithCallInfo(ctx context.Context) (context.Context, *CallInfo) {
+	info := &CallInfo{}
+
+	return context.WithValue(ctx, callInfoKey{}, info), info
+}
+
+// CallInfoFromContext returns the CallInfo carried by ctx, if any.
+func CallInfoFromContext(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*CallInfo)
+
+	return info
+}
+
+// record adds a single attempt against endpoint which started at start to
+// the CallInfo carried by ctx, if any.
+func record(ctx context.Context, endpoint string, start time.Time) {
+	info := CallInfoFromContext(ctx)
+	if info == nil {
+		return
+	}
+
+	info.Attempts++
+	info.Latency += time.Since(start)
+	info.Endpoint = endpoint
+}
+
+// recordResponse copies the details of an evaluation response to the
+// CallInfo carried by ctx, if any.
+func recordResponse(ctx context.Context, requestID string, durationMillis float64, segmentKeys []string) {
+	info := CallInfoFromContext(ctx)
+	if info == nil {
+		return
+	}
+
+	info.RequestID = requestID
+	info.ServerDurationMillis = durationMillis
+	info.SegmentKeys = segmentKeys
+}
+
+// recordRequestID sets the request ID sent with an evaluation to the
+// CallInfo carried by ctx, if any, until Flipt returns its own.
+func recordRequestID(ctx context.Context, requestID string) {
+	if info := CallInfoFromContext(ctx); info != nil {
+		info.RequestID = requestID
+	}
+}
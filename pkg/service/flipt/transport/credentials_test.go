@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+type signingCredentials struct{}
+
+func (signingCredentials) GetRequestMetadata(_ context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"x-signature": "signed"}, nil
+}
+
+func (signingCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+func TestWithGRPCCredentials(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	signatures := make(chan []string, 1)
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		signatures <- md.Get("x-signature")
+
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	s := New(WithAddress(fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)), WithGRPCCredentials(signingCredentials{}))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, []string{"signed"}, <-signatures)
+}
@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	flipt "go.flipt.io/flipt/rpc/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+)
+
+// enumField describes a top level enum field of a Flipt API response.
+type enumField struct {
+	names  map[int32]string
+	values map[string]int32
+}
+
+// enumFields are the enum fields of the responses handled by the Service,
+// keyed by their JSON name.
+var enumFields = map[string]enumField{
+	"reason": {names: evaluation.EvaluationReason_name, values: evaluation.EvaluationReason_value},
+	"type":   {names: flipt.FlagType_name, values: flipt.FlagType_value},
+}
+
+// recordUnknownEnum records an enum value returned by Flipt which is not
+// known to this version of the provider into the CallInfo carried by ctx.
+func recordUnknownEnum(ctx context.Context, field, raw string) {
+	info := CallInfoFromContext(ctx)
+	if info == nil {
+		return
+	}
+
+	if info.UnknownEnums == nil {
+		info.UnknownEnums = map[string]string{}
+	}
+
+	info.UnknownEnums[field] = raw
+}
+
+// checkEnum records value as unknown when it is not a known value of field.
+func checkEnum(ctx context.Context, field string, value int32) {
+	if _, ok := enumFields[field].names[value]; !ok {
+		recordUnknownEnum(ctx, field, strconv.Itoa(int(value)))
+	}
+}
+
+// enumTransport is an http.RoundTripper which replaces enum names unknown to
+// this version of the provider (e.g. reasons added in newer Flipt versions)
+// with the zero value of the enum. Without it decoding the response would
+// fail altogether. The raw values are recorded into the request's CallInfo.
+type enumTransport struct {
+	next http.RoundTripper
+}
+
+func (t enumTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	body = sanitizeEnums(req.Context(), body)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+
+	return resp, nil
+}
+
+// sanitizeEnums replaces unknown enum names in the top level fields of body.
+// The body is returned unmodified when it is not a JSON object or all enum
+// names are known.
+func sanitizeEnums(ctx context.Context, body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	var changed bool
+
+	for name, field := range enumFields {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		var value string
+		// numeric values are decoded without error
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+
+		if _, ok := field.values[value]; ok {
+			continue
+		}
+
+		zero, _ := json.Marshal(field.names[0])
+		fields[name] = zero
+		changed = true
+
+		recordUnknownEnum(ctx, name, value)
+	}
+
+	if !changed {
+		return body
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+
+	return out
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeEnums(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+		unknown  map[string]string
+	}{
+		{
+			name:     "known reason",
+			body:     `{"enabled":true,"reason":"MATCH_EVALUATION_REASON"}`,
+			expected: `{"enabled":true,"reason":"MATCH_EVALUATION_REASON"}`,
+		},
+		{
+			name:     "future reason",
+			body:     `{"enabled":true,"reason":"ROLLOUT_EVALUATION_REASON"}`,
+			expected: `{"enabled":true,"reason":"UNKNOWN_EVALUATION_REASON"}`,
+			unknown:  map[string]string{"reason": "ROLLOUT_EVALUATION_REASON"},
+		},
+		{
+			name:     "future flag type",
+			body:     `{"key":"foo","type":"MULTIVARIATE_FLAG_TYPE"}`,
+			expected: `{"key":"foo","type":"VARIANT_FLAG_TYPE"}`,
+			unknown:  map[string]string{"type": "MULTIVARIATE_FLAG_TYPE"},
+		},
+		{
+			name:     "numeric reason",
+			body:     `{"enabled":true,"reason":7}`,
+			expected: `{"enabled":true,"reason":7}`,
+		},
+		{
+			name:     "not an object",
+			body:     `["MATCH_EVALUATION_REASON"]`,
+			expected: `["MATCH_EVALUATION_REASON"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, info := ContextWithCallInfo(context.Background())
+
+			actual := sanitizeEnums(ctx, []byte(tt.body))
+
+			assert.JSONEq(t, tt.expected, string(actual))
+			assert.Equal(t, tt.unknown, info.UnknownEnums)
+		})
+	}
+}
+
+func TestEnumTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"match":true,"reason":"ROLLOUT_EVALUATION_REASON"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, info := ContextWithCallInfo(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: enumTransport{next: http.DefaultTransport}}).Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"match":true,"reason":"UNKNOWN_EVALUATION_REASON"}`, string(body))
+	assert.Equal(t, map[string]string{"reason": "ROLLOUT_EVALUATION_REASON"}, info.UnknownEnums)
+}
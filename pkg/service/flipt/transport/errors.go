@@ -0,0 +1,244 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxErrorBody is the number of bytes of a failed HTTP response read to
+// describe the failure.
+const maxErrorBody = 1 << 10
+
+// Errors matching, with errors.Is, the errors returned by the calls made to
+// Flipt over both HTTP and gRPC, whatever their message.
+var (
+	// ErrUnavailable matches calls which failed because Flipt could not be
+	// reached or is not serving.
+	ErrUnavailable = errors.New("flipt is unavailable")
+	// ErrUnauthenticated matches calls rejected because their credentials
+	// are missing, invalid or expired, see UnauthenticatedError.
+	ErrUnauthenticated = errors.New("flipt rejected the credentials")
+	// ErrNotFound matches calls for flags or namespaces which do not exist.
+	ErrNotFound = errors.New("not found in flipt")
+	// ErrTimeout matches calls which did not complete before their deadline.
+	ErrTimeout = errors.New("flipt call timed out")
+	// ErrCanceled matches calls abandoned because their caller canceled
+	// their context, which says nothing about the health of Flipt.
+	ErrCanceled = errors.New("flipt call canceled")
+	// ErrInvalidConfig matches invalid configurations, see ConfigError.
+	ErrInvalidConfig = errors.New("invalid flipt configuration")
+)
+
+// ConfigError is returned when the configuration of the service is invalid,
+// e.g. by Validate. It matches ErrInvalidConfig.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidConfig.
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// callError is a failed call, reported to OpenFeature as its resolution
+// error and matching the sentinel error of its cause.
+type callError struct {
+	of.ResolutionError
+	cause error
+}
+
+func (e *callError) Unwrap() []error {
+	return []error{e.ResolutionError, e.cause}
+}
+
+// ContextError returns the error reported for a call to Flipt abandoned
+// because ctx is done, matching ErrCanceled or ErrTimeout, or nil while ctx
+// is live.
+func ContextError(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	code, cause := codes.DeadlineExceeded, ErrTimeout
+	if errors.Is(err, context.Canceled) {
+		code, cause = codes.Canceled, ErrCanceled
+	}
+
+	return &callError{ResolutionError: util.GRPCToOpenFeatureError(status.Error(code, err.Error())), cause: cause}
+}
+
+// resolutionError converts err, returned by a call to Flipt, to the error
+// returned by the service, with secrets redacted and the request ID of the
+// call, if any, appended to its message.
+func (s *Service) resolutionError(err error, requestID string) error {
+	err = s.redact(err)
+	cause := errorCause(err)
+
+	st, ok := status.FromError(err)
+	switch {
+	case ok:
+	case cause == ErrUnavailable:
+		st = status.New(codes.Unavailable, err.Error())
+	case cause == ErrTimeout:
+		st = status.New(codes.DeadlineExceeded, err.Error())
+	case cause == ErrCanceled:
+		st = status.New(codes.Canceled, err.Error())
+	default:
+		st = status.New(codes.Unknown, "internal error")
+	}
+
+	msg := withRequestID(st.Message(), requestID)
+
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return &UnauthenticatedError{Message: msg}
+	case codes.PermissionDenied:
+		return &PermissionDeniedError{Message: msg}
+	}
+
+	rerr := util.GRPCToOpenFeatureError(status.Error(st.Code(), msg))
+	if cause == nil {
+		return rerr
+	}
+
+	return &callError{ResolutionError: rerr, cause: cause}
+}
+
+// errorCause returns the sentinel error matching the cause of err, returned
+// by a call to Flipt, if any.
+func errorCause(err error) error {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable:
+			return ErrUnavailable
+		case codes.DeadlineExceeded:
+			return ErrTimeout
+		case codes.Canceled:
+			return ErrCanceled
+		case codes.NotFound:
+			return ErrNotFound
+		}
+
+		return nil
+	}
+
+	// HTTP requests fail with the errors of the network and context
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+
+		return ErrUnavailable
+	}
+
+	return nil
+}
+
+// errorCode returns the gRPC status code of err, returned by a call to Flipt
+// over either transport, mapping the errors of HTTP requests by their cause.
+func errorCode(err error) codes.Code {
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+
+	switch errorCause(err) {
+	case ErrUnavailable:
+		return codes.Unavailable
+	case ErrTimeout:
+		return codes.DeadlineExceeded
+	case ErrCanceled:
+		return codes.Canceled
+	}
+
+	return codes.Unknown
+}
+
+// statusTransport reports HTTP 401, 403 and 5xx responses as the equivalent
+// gRPC status errors, whether they come from Flipt or from a proxy in front
+// of it, so that they are reported like the failures of gRPC calls.
+type statusTransport struct {
+	next http.RoundTripper
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var code codes.Code
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case resp.StatusCode == http.StatusForbidden:
+		code = codes.PermissionDenied
+	case resp.StatusCode == http.StatusBadGateway, resp.StatusCode == http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case resp.StatusCode == http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case resp.StatusCode >= http.StatusInternalServerError:
+		code = codes.Internal
+	default:
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	var flipt fliptErrorBody
+	if json.Unmarshal(body, &flipt) == nil && resp.StatusCode >= http.StatusInternalServerError && flipt.Code != nil {
+		// Flipt reports the code of the failed call along with the status
+		code = codes.Code(*flipt.Code)
+	}
+
+	return nil, status.Error(code, statusErrorMessage(resp.Status, body))
+}
+
+// fliptErrorBody is the body of the error responses of Flipt.
+type fliptErrorBody struct {
+	Code    *uint32 `json:"code"`
+	Message string  `json:"message"`
+}
+
+// statusErrorMessage describes a failed HTTP response, preferring the message
+// of a Flipt error body.
+func statusErrorMessage(statusText string, body []byte) string {
+	var flipt fliptErrorBody
+
+	if json.Unmarshal(body, &flipt) == nil && flipt.Message != "" {
+		return fmt.Sprintf("%s: %s", statusText, flipt.Message)
+	}
+
+	if text := strings.TrimSpace(string(body)); text != "" && !strings.HasPrefix(text, "<") {
+		return fmt.Sprintf("%s: %s", statusText, text)
+	}
+
+	return statusText
+}
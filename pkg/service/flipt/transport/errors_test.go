@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResolutionError_Cause(t *testing.T) {
+	s := New()
+
+	for _, tt := range []struct {
+		name  string
+		err   error
+		cause error
+		code  of.ErrorCode
+	}{
+		{name: "unavailable", err: status.Error(codes.Unavailable, "connection refused"), cause: ErrUnavailable, code: of.ProviderNotReadyCode},
+		{name: "deadline exceeded", err: status.Error(codes.DeadlineExceeded, "context deadline exceeded"), cause: ErrTimeout, code: of.GeneralCode},
+		{name: "not found", err: status.Error(codes.NotFound, "flag not found"), cause: ErrNotFound, code: of.FlagNotFoundCode},
+		{name: "http timeout", err: &url.Error{Op: "Post", URL: "http://flipt", Err: context.DeadlineExceeded}, cause: ErrTimeout, code: of.GeneralCode},
+		{name: "canceled", err: status.Error(codes.Canceled, "context canceled"), cause: ErrCanceled, code: of.GeneralCode},
+		{name: "http canceled", err: &url.Error{Op: "Post", URL: "http://flipt", Err: context.Canceled}, cause: ErrCanceled, code: of.GeneralCode},
+		{name: "http connection refused", err: &url.Error{Op: "Post", URL: "http://flipt", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrPermission}}, cause: ErrUnavailable, code: of.ProviderNotReadyCode},
+		{name: "internal", err: status.Error(codes.Internal, "database is locked"), code: of.GeneralCode},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.resolutionError(tt.err, "")
+
+			for _, sentinel := range []error{ErrUnavailable, ErrTimeout, ErrCanceled, ErrNotFound, ErrUnauthenticated} {
+				assert.Equal(t, sentinel == tt.cause, errors.Is(err, sentinel), sentinel.Error())
+			}
+
+			var rerr of.ResolutionError
+			require.ErrorAs(t, err, &rerr)
+			assert.Contains(t, rerr.Error(), string(tt.code)+":")
+		})
+	}
+}
+
+func TestConfigError(t *testing.T) {
+	err := fmt.Errorf("invalid configuration: %w", &ConfigError{Err: os.ErrNotExist})
+
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.EqualError(t, err, "invalid configuration: file does not exist")
+}
+
+func TestStatusTransport(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		status  int
+		body    string
+		code    codes.Code
+		message string
+	}{
+		{
+			name:    "flipt unauthenticated",
+			status:  http.StatusUnauthorized,
+			body:    `{"code":16,"message":"request was not authenticated"}`,
+			code:    codes.Unauthenticated,
+			message: "401 Unauthorized: request was not authenticated",
+		},
+		{
+			name:    "proxy forbidden",
+			status:  http.StatusForbidden,
+			body:    "<html><body>Forbidden</body></html>",
+			code:    codes.PermissionDenied,
+			message: "403 Forbidden",
+		},
+		{
+			name:    "plain text",
+			status:  http.StatusForbidden,
+			body:    "access denied\n",
+			code:    codes.PermissionDenied,
+			message: "403 Forbidden: access denied",
+		},
+		{
+			name:    "proxy unavailable",
+			status:  http.StatusServiceUnavailable,
+			body:    "<html><body>Service Unavailable</body></html>",
+			code:    codes.Unavailable,
+			message: "503 Service Unavailable",
+		},
+		{
+			name:    "proxy timeout",
+			status:  http.StatusGatewayTimeout,
+			code:    codes.DeadlineExceeded,
+			message: "504 Gateway Timeout",
+		},
+		{
+			name:    "flipt internal error",
+			status:  http.StatusInternalServerError,
+			body:    `{"code":14,"message":"database is unavailable"}`,
+			code:    codes.Unavailable,
+			message: "500 Internal Server Error: database is unavailable",
+		},
+		{
+			name:    "internal error",
+			status:  http.StatusInternalServerError,
+			code:    codes.Internal,
+			message: "500 Internal Server Error",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			_, err := New().httpClient().Get(srv.URL)
+			require.Error(t, err)
+
+			st, ok := status.FromError(errors.Unwrap(err))
+			require.True(t, ok)
+			assert.Equal(t, tt.code, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxETagEntries bounds the number of responses kept for revalidation.
+const maxETagEntries = 1024
+
+// etagEntry is a response kept to be served when Flipt reports it has not
+// been modified.
+type etagEntry struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// etagTransport is an http.RoundTripper which revalidates GET requests (e.g.
+// GetFlag and ListFlags) whose previous response carried an ETag by sending
+// If-None-Match. When Flipt answers 304 Not Modified the previous response is
+// returned instead, saving the transfer of unchanged flags. Such calls are
+// recorded as NotModified in the request's CallInfo.
+type etagTransport struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+func newETagTransport(next http.RoundTripper) *etagTransport {
+	return &etagTransport{next: next, entries: map[string]etagEntry{}}
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	t.mu.Lock()
+	entry, cached := t.entries[key]
+	t.mu.Unlock()
+
+	if cached {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	switch {
+	case cached && resp.StatusCode == http.StatusNotModified:
+		_ = resp.Body.Close()
+
+		if info := CallInfoFromContext(req.Context()); info != nil {
+			info.NotModified = true
+		}
+
+		resp.StatusCode = http.StatusOK
+		resp.Status = http.StatusText(http.StatusOK)
+		resp.Header = entry.header.Clone()
+		resp.Body = io.NopCloser(bytes.NewReader(entry.body))
+		resp.ContentLength = int64(len(entry.body))
+
+		return resp, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		t.store(key, etagEntry{etag: resp.Header.Get("ETag"), header: resp.Header.Clone(), body: body})
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		return resp, nil
+	case cached && resp.StatusCode == http.StatusOK:
+		// the response no longer carries an ETag
+		t.mu.Lock()
+		delete(t.entries, key)
+		t.mu.Unlock()
+	}
+
+	return resp, nil
+}
+
+func (t *etagTransport) store(key string, entry etagEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.entries[key]; !ok && len(t.entries) >= maxETagEntries {
+		// evict an arbitrary entry, which is revalidated unconditionally
+		for k := range t.entries {
+			delete(t.entries, k)
+			break
+		}
+	}
+
+	t.entries[key] = entry
+}
@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagTransport(t *testing.T) {
+	var (
+		version  atomic.Int32
+		notMod   atomic.Int32
+		withETag = true
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := strconv.Itoa(int(version.Load()))
+		etag := `"v` + v + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notMod.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		if withETag {
+			w.Header().Set("ETag", etag)
+		}
+
+		_, _ = w.Write([]byte(`{"key":"flag","version":` + v + `}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: newETagTransport(http.DefaultTransport)}
+
+	get := func() (string, *CallInfo) {
+		t.Helper()
+
+		ctx, info := ContextWithCallInfo(context.Background())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/namespaces/default/flags/flag", nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(body), info
+	}
+
+	body, info := get()
+	assert.JSONEq(t, `{"key":"flag","version":0}`, body)
+	assert.False(t, info.NotModified)
+
+	// unchanged flags are not transferred again
+	body, info = get()
+	assert.JSONEq(t, `{"key":"flag","version":0}`, body)
+	assert.True(t, info.NotModified)
+	assert.Equal(t, int32(1), notMod.Load())
+
+	version.Store(1)
+
+	body, info = get()
+	assert.JSONEq(t, `{"key":"flag","version":1}`, body)
+	assert.False(t, info.NotModified)
+
+	// responses without an ETag are not revalidated
+	withETag = false
+	version.Store(2)
+
+	body, _ = get()
+	assert.JSONEq(t, `{"key":"flag","version":2}`, body)
+
+	body, info = get()
+	assert.JSONEq(t, `{"key":"flag","version":2}`, body)
+	assert.False(t, info.NotModified)
+	assert.Equal(t, int32(1), notMod.Load())
+}
+
+func TestETagTransportIgnoresPost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tr := newETagTransport(http.DefaultTransport)
+	client := &http.Client{Transport: tr}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(srv.URL, "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Empty(t, tr.entries)
+}
@@ -0,0 +1,266 @@
+package transport
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// googleMetadataIdentityURL is the endpoint of the GCE metadata server
+	// issuing ID tokens for the default service account.
+	googleMetadataIdentityURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+
+	// googleTokenLeeway is how long before its expiry a Google ID token is
+	// fetched again.
+	googleTokenLeeway = 5 * time.Minute
+)
+
+// WithGoogleIDToken authenticates requests with Google-signed ID tokens for
+// audience, e.g. the OAuth client ID of an Identity-Aware Proxy or the URL of
+// a Cloud Run service fronting Flipt. Tokens are obtained with the service
+// account key read from credentialsFile, or from GOOGLE_APPLICATION_CREDENTIALS
+// when empty, and otherwise from the metadata server, and fetched again
+// shortly before they expire.
+func WithGoogleIDToken(audience, credentialsFile string) Option {
+	return func(s *Service) {
+		source := &googleIDTokenSource{
+			audience:        audience,
+			credentialsFile: credentialsFile,
+			metadataURL:     googleMetadataIdentityURL,
+			client:          &http.Client{},
+		}
+
+		s.useTokenProvider("Bearer", NewRefreshingTokenProvider(source.token, googleTokenLeeway))
+	}
+}
+
+// googleIDTokenSource fetches Google-signed ID tokens.
+type googleIDTokenSource struct {
+	audience        string
+	credentialsFile string
+	metadataURL     string
+	client          *http.Client
+}
+
+// googleServiceAccount is a service account key file.
+type googleServiceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func (g *googleIDTokenSource) token(ctx context.Context) (string, time.Time, error) {
+	path := g.credentialsFile
+	if path == "" {
+		path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+
+	var (
+		token string
+		err   error
+	)
+
+	if path != "" {
+		token, err = g.serviceAccountToken(ctx, path)
+	} else {
+		token, err = g.metadataToken(ctx)
+	}
+
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("fetching google id token: %w", err)
+	}
+
+	expiry, err := jwtExpiry(token)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("fetching google id token: %w", err)
+	}
+
+	return token, expiry, nil
+}
+
+// metadataToken fetches an ID token from the metadata server.
+func (g *googleIDTokenSource) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.metadataURL+"?"+url.Values{
+		"audience": {g.audience},
+		"format":   {"full"},
+	}.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// serviceAccountToken exchanges an assertion signed with the service account
+// key read from path for an ID token.
+func (g *googleIDTokenSource) serviceAccountToken(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var account googleServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return "", fmt.Errorf("parsing credentials: %w", err)
+	}
+
+	if account.Type != "service_account" {
+		return "", fmt.Errorf("unsupported credentials type %q", account.Type)
+	}
+
+	now := time.Now()
+
+	assertion, err := signJWT(account.PrivateKey, account.PrivateKeyID, map[string]interface{}{
+		"iss":             account.ClientEmail,
+		"sub":             account.ClientEmail,
+		"aud":             account.TokenURI,
+		"iat":             now.Unix(),
+		"exp":             now.Add(time.Hour).Unix(),
+		"target_audience": g.audience,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.IDToken == "" {
+		return "", errors.New("token endpoint returned no id token")
+	}
+
+	return token.IDToken, nil
+}
+
+// signJWT returns a JWT of claims signed with RS256 by the PEM encoded
+// private key.
+func signJWT(privateKey, keyID string, claims map[string]interface{}) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", errors.New("parsing private key: no PEM data")
+	}
+
+	var key *rsa.PrivateKey
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("parsing private key: %w", err)
+		}
+	} else {
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return "", errors.New("parsing private key: not an RSA key")
+		}
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtExpiry returns the expiry of the JWT token, without verifying it.
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("malformed token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed token: %w", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("malformed token: %w", err)
+	}
+
+	if claims.Exp == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
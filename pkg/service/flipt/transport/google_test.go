@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsignedJWT returns a JWT with the given claims and a fake signature.
+func unsignedJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+func TestGoogleIDToken_Metadata(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	idToken := unsignedJWT(t, map[string]interface{}{"aud": "https://flipt.example.com", "exp": expiry.Unix()})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "https://flipt.example.com", r.URL.Query().Get("audience"))
+		assert.Equal(t, "full", r.URL.Query().Get("format"))
+		_, _ = w.Write([]byte(idToken))
+	}))
+	t.Cleanup(srv.Close)
+
+	source := &googleIDTokenSource{audience: "https://flipt.example.com", metadataURL: srv.URL, client: srv.Client()}
+
+	token, exp, err := source.token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, idToken, token)
+	assert.True(t, expiry.Equal(exp))
+}
+
+func TestGoogleIDToken_ServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	idToken := unsignedJWT(t, map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, "flipt@project.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, "https://flipt.example.com", claims["target_audience"])
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	}))
+	t.Cleanup(srv.Close)
+
+	credentials, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "flipt@project.iam.gserviceaccount.com",
+		"private_key_id": "key-id",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      srv.URL + "/token",
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0o600))
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+
+	s := New(WithGoogleIDToken("https://flipt.example.com", ""))
+
+	value, err := s.authorization(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer "+idToken, value)
+}
+
+func TestGoogleIDToken_UnsupportedCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"authorized_user"}`), 0o600))
+
+	s := New(WithGoogleIDToken("https://flipt.example.com", path))
+
+	_, err := s.authorization(context.Background())
+	assert.EqualError(t, err, `fetching google id token: unsupported credentials type "authorized_user"`)
+}
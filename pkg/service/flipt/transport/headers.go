@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// WithHeaders sets static headers sent with every HTTP request, and as
+// metadata of every gRPC call, e.g. API gateway keys or tenant headers.
+// Headers set by the authentication options take precedence over them.
+func WithHeaders(headers map[string]string) Option {
+	return func(s *Service) {
+		s.headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			s.headers[k] = v
+		}
+	}
+}
+
+type requestHeadersKey struct{}
+
+// ContextWithRequestHeaders returns a context whose calls to Flipt are sent
+// with headers, as HTTP headers or gRPC metadata, e.g. to route them to a
+// tenant through a proxy. They are merged with the headers carried by ctx and
+// take precedence over the ones set by WithHeaders, but not over the ones set
+// by the authentication options.
+func ContextWithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string, len(headers))
+	for k, v := range requestHeaders(ctx) {
+		merged[k] = v
+	}
+
+	for k, v := range headers {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// RequestHeadersFromContext returns the headers set by
+// ContextWithRequestHeaders carried by ctx, if any. The returned map must not
+// be modified.
+func RequestHeadersFromContext(ctx context.Context) map[string]string {
+	return requestHeaders(ctx)
+}
+
+// requestHeaders returns the headers carried by ctx, if any.
+func requestHeaders(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(requestHeadersKey{}).(map[string]string)
+
+	return headers
+}
+
+// headersInterceptor sets the static and per-request headers as metadata of
+// gRPC calls.
+func (s *Service) headersInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	perRequest := requestHeaders(ctx)
+	if len(s.headers) == 0 && len(perRequest) == 0 {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	md := metadata.MD{}
+	for k, v := range s.headers {
+		md.Set(k, v)
+	}
+
+	for k, v := range perRequest {
+		md.Set(k, v)
+	}
+
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = metadata.Join(outgoing, md)
+	}
+
+	return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+}
+
+// headerTransport is an http.RoundTripper setting the static and per-request
+// headers of requests.
+type headerTransport struct {
+	next    http.RoundTripper
+	headers map[string]string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	perRequest := requestHeaders(req.Context())
+	if len(t.headers) == 0 && len(perRequest) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	for k, v := range perRequest {
+		req.Header.Set(k, v)
+	}
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithHeaders_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant"))
+		assert.Equal(t, "JWT some-jwt", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	headers := map[string]string{"X-Api-Key": "key", "X-Tenant": "acme", "Authorization": "ignored"}
+
+	s := New(WithHeaders(headers), WithJWTAuthentication("some-jwt"))
+
+	// the headers are copied
+	headers["X-Tenant"] = "other"
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestContextWithRequestHeaders_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "globex", r.Header.Get("X-Tenant"))
+		assert.Equal(t, "eu", r.Header.Get("X-Region"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithHeaders(map[string]string{"X-Api-Key": "key", "X-Tenant": "acme"}))
+
+	ctx := ContextWithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "initech", "X-Region": "eu"})
+	ctx = ContextWithRequestHeaders(ctx, map[string]string{"X-Tenant": "globex"})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := s.httpClient().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWithHeaders_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	received := make(chan metadata.MD, 1)
+
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received <- md
+
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	s := New(WithAddress(fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)), WithHeaders(map[string]string{"X-Tenant": "acme"}))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, []string{"acme"}, (<-received).Get("x-tenant"))
+
+	ctx := ContextWithRequestHeaders(context.Background(), map[string]string{"X-Tenant": "globex", "X-Region": "eu"})
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "42")
+
+	require.NoError(t, s.Check(ctx))
+
+	md := <-received
+	assert.Equal(t, []string{"globex"}, md.Get("x-tenant"))
+	assert.Equal(t, []string{"eu"}, md.Get("x-region"))
+	assert.Equal(t, []string{"42"}, md.Get("x-request-id"))
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.flipt.io/flipt/rpc/flipt/auth"
+)
+
+const (
+	// DefaultServiceAccountTokenPath is the path at which Kubernetes projects
+	// the token of the pod's service account.
+	DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// kubernetesTokenLeeway is how long before its expiry a client token
+	// obtained with a service account token is exchanged again.
+	kubernetesTokenLeeway = time.Minute
+)
+
+// WithKubernetesAuthentication authenticates requests with Flipt's Kubernetes
+// authentication method: the service account token read from tokenPath, or
+// DefaultServiceAccountTokenPath when empty, is exchanged with Flipt for a
+// client token, which is exchanged again shortly before it expires. The
+// service account token is read for every exchange, so that rotated tokens
+// are picked up.
+func WithKubernetesAuthentication(tokenPath string) Option {
+	if tokenPath == "" {
+		tokenPath = DefaultServiceAccountTokenPath
+	}
+
+	return func(s *Service) {
+		s.useTokenProvider("Bearer", NewRefreshingTokenProvider(func(ctx context.Context) (string, time.Time, error) {
+			return s.verifyServiceAccount(ctx, tokenPath)
+		}, kubernetesTokenLeeway))
+	}
+}
+
+// verifyServiceAccount exchanges the service account token read from
+// tokenPath for a client token and its expiry.
+func (s *Service) verifyServiceAccount(ctx context.Context, tokenPath string) (string, time.Time, error) {
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("reading service account token: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+
+	if s.usesHTTP() {
+		return s.verifyServiceAccountHTTP(ctx, token)
+	}
+
+	s.connMu.Lock()
+	conn := s.conn
+	s.connMu.Unlock()
+
+	resp, err := auth.NewAuthenticationMethodKubernetesServiceClient(conn).VerifyServiceAccount(unauthenticated(ctx), &auth.VerifyServiceAccountRequest{
+		ServiceAccountToken: token,
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("verifying service account: %w", err)
+	}
+
+	var expiry time.Time
+	if expiresAt := resp.GetAuthentication().GetExpiresAt(); expiresAt != nil {
+		expiry = expiresAt.AsTime()
+	}
+
+	return resp.GetClientToken(), expiry, nil
+}
+
+func (s *Service) verifyServiceAccountHTTP(ctx context.Context, token string) (string, time.Time, error) {
+	body, err := json.Marshal(map[string]string{"serviceAccountToken": token})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(s.address, "/")+"/auth/v1/method/kubernetes/serviceaccount", bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("verifying service account: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Transport: s.httpTransport}).Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("verifying service account: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("verifying service account: flipt returned %d", resp.StatusCode)
+	}
+
+	var verified struct {
+		ClientToken    string `json:"clientToken"`
+		Authentication struct {
+			ExpiresAt *time.Time `json:"expiresAt"`
+		} `json:"authentication"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&verified); err != nil {
+		return "", time.Time{}, fmt.Errorf("verifying service account: %w", err)
+	}
+
+	var expiry time.Time
+	if verified.Authentication.ExpiresAt != nil {
+		expiry = *verified.Authentication.ExpiresAt
+	}
+
+	return verified.ClientToken, expiry, nil
+}
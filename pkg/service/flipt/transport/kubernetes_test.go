@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestKubernetesAuthentication_HTTP(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("sa-token-1\n"), 0o600))
+
+	var exchanges int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/v1/method/kubernetes/serviceaccount" {
+			var req struct {
+				ServiceAccountToken string `json:"serviceAccountToken"`
+			}
+
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Empty(t, r.Header.Get("Authorization"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			exchanges++
+
+			// the client token expires immediately, so that it is exchanged again
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"clientToken": "client-" + req.ServiceAccountToken,
+				"authentication": map[string]interface{}{
+					"expiresAt": time.Now().Format(time.RFC3339Nano),
+				},
+			})
+
+			return
+		}
+
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithAddress(srv.URL), WithKubernetesAuthentication(tokenPath))
+	client := s.httpClient()
+
+	get := func() string {
+		resp, err := client.Get(srv.URL + "/api/v1/namespaces/default/flags")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.Header.Get("X-Authorization")
+	}
+
+	assert.Equal(t, "Bearer client-sa-token-1", get())
+
+	require.NoError(t, os.WriteFile(tokenPath, []byte("sa-token-2"), 0o600))
+
+	assert.Equal(t, "Bearer client-sa-token-2", get())
+	assert.Equal(t, 2, exchanges)
+}
+
+func TestKubernetesAuthentication_MissingToken(t *testing.T) {
+	s := New(WithKubernetesAuthentication(filepath.Join(t.TempDir(), "token")))
+
+	_, err := s.authorization(context.Background())
+	assert.ErrorContains(t, err, "reading service account token")
+}
+
+func TestAuthorizationInterceptor_Unauthenticated(t *testing.T) {
+	s := New()
+	s.authorization = func(context.Context) (string, error) {
+		t.Fatal("unexpected call")
+		return "", nil
+	}
+
+	var called bool
+
+	err := s.authorizationInterceptor(unauthenticated(context.Background()), "/flipt.auth.AuthenticationMethodKubernetesService/VerifyServiceAccount", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		called = true
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oidcTokenLeeway is how long before its expiry an access token obtained
+// with client credentials is requested again.
+const oidcTokenLeeway = 30 * time.Second
+
+// ClientCredentials configures the OAuth2 client credentials flow used to
+// obtain access tokens from an OIDC issuer.
+type ClientCredentials struct {
+	// Issuer is the URL of the OIDC issuer, whose token endpoint is
+	// discovered from its /.well-known/openid-configuration document.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// WithClientCredentials authenticates requests with access tokens obtained
+// from an OIDC issuer with the OAuth2 client credentials flow, which Flipt
+// verifies with its JWT authentication method. Access tokens are requested
+// again shortly before they expire.
+func WithClientCredentials(cc ClientCredentials) Option {
+	return func(s *Service) {
+		flow := &clientCredentialsFlow{config: cc, client: &http.Client{}}
+
+		s.useTokenProvider("JWT", NewRefreshingTokenProvider(flow.token, oidcTokenLeeway))
+	}
+}
+
+// clientCredentialsFlow requests access tokens with client credentials.
+type clientCredentialsFlow struct {
+	config   ClientCredentials
+	client   *http.Client
+	endpoint string
+}
+
+// token requests an access token, discovering the issuer's token endpoint
+// the first time it is called.
+func (f *clientCredentialsFlow) token(ctx context.Context) (string, time.Time, error) {
+	if f.endpoint == "" {
+		endpoint, err := f.discover(ctx)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+
+		f.endpoint = endpoint
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(f.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(f.config.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting access token: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(f.config.ClientID), url.QueryEscape(f.config.ClientSecret))
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := f.do(req, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting access token: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", time.Time{}, errors.New("requesting access token: issuer returned no access token")
+	}
+
+	var expiry time.Time
+	if token.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return token.AccessToken, expiry, nil
+}
+
+// discover returns the token endpoint of the issuer.
+func (f *clientCredentialsFlow) discover(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(f.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return "", fmt.Errorf("discovering token endpoint: %w", err)
+	}
+
+	var configuration struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+
+	if err := f.do(req, &configuration); err != nil {
+		return "", fmt.Errorf("discovering token endpoint: %w", err)
+	}
+
+	if configuration.TokenEndpoint == "" {
+		return "", errors.New("discovering token endpoint: issuer has no token endpoint")
+	}
+
+	return configuration.TokenEndpoint, nil
+}
+
+// do sends req, decoding the JSON body of a successful response into v.
+func (f *clientCredentialsFlow) do(req *http.Request, v interface{}) error {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("issuer returned %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCredentials(t *testing.T) {
+	var (
+		issuer   *httptest.Server
+		requests int
+	)
+
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"token_endpoint": issuer.URL + "/token"})
+		case "/token":
+			requests++
+
+			id, secret, ok := r.BasicAuth()
+			require.True(t, ok)
+			assert.Equal(t, "client", id)
+			assert.Equal(t, "s%26cret", secret)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "flipt read", r.PostForm.Get("scope"))
+
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "access-token",
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(issuer.Close)
+
+	s := New(WithClientCredentials(ClientCredentials{
+		Issuer:       issuer.URL + "/",
+		ClientID:     "client",
+		ClientSecret: "s&cret",
+		Scopes:       []string{"flipt", "read"},
+	}))
+
+	for i := 0; i < 2; i++ {
+		value, err := s.authorization(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "JWT access-token", value)
+	}
+
+	assert.Equal(t, 1, requests)
+}
+
+func TestClientCredentials_Error(t *testing.T) {
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(issuer.Close)
+
+	s := New(WithClientCredentials(ClientCredentials{Issuer: issuer.URL}))
+
+	_, err := s.authorization(context.Background())
+	assert.EqualError(t, err, "discovering token endpoint: issuer returned 404")
+}
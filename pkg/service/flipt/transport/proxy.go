@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// WithProxyURL sends requests to Flipt through the forward proxy at proxyURL,
+// instead of the proxy configured by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables, which are honored by default. gRPC connections are
+// tunneled through the proxy with HTTP CONNECT.
+func WithProxyURL(proxyURL *url.URL) Option {
+	return func(s *Service) {
+		s.proxyURL = proxyURL
+	}
+}
+
+// WithSOCKS5Proxy routes the HTTP and gRPC connections to Flipt through the
+// SOCKS5 proxy at address, authenticating with username and password when
+// set. Proxies configured by the environment are then ignored, unless set
+// with WithProxyURL, in which case they are reached through the SOCKS5 proxy.
+func WithSOCKS5Proxy(address, username, password string) Option {
+	return func(s *Service) {
+		var auth *proxy.Auth
+		if username != "" || password != "" {
+			auth = &proxy.Auth{User: username, Password: password}
+		}
+
+		// SOCKS5 only fails for unsupported networks
+		dialer, _ := proxy.SOCKS5("tcp", address, auth, proxy.Direct)
+
+		s.socks5 = dialer.(proxy.ContextDialer)
+	}
+}
+
+// dial connects to addr, through the SOCKS5 proxy if any.
+func (s *Service) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if s.socks5 != nil {
+		return s.socks5.DialContext(ctx, network, addr)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// dialGRPC connects to addr for gRPC, through the proxies if any.
+func (s *Service) dialGRPC(ctx context.Context, addr string) (net.Conn, error) {
+	if s.proxyURL != nil {
+		return s.dialProxy(ctx, addr)
+	}
+
+	return s.dial(ctx, "tcp", addr)
+}
+
+// dialProxy returns a connection to addr tunneled through the proxy with
+// HTTP CONNECT.
+func (s *Service) dialProxy(ctx context.Context, addr string) (net.Conn, error) {
+	proxyAddr := s.proxyURL.Host
+	if s.proxyURL.Port() == "" {
+		port := "80"
+		if s.proxyURL.Scheme == "https" {
+			port = "443"
+		}
+
+		proxyAddr = net.JoinHostPort(s.proxyURL.Hostname(), port)
+	}
+
+	conn, err := s.dial(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dialing proxy: %w", err)
+	}
+
+	if s.proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: s.proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("dialing proxy: %w", err)
+		}
+
+		conn = tlsConn
+	}
+
+	tunnel, err := connect(ctx, conn, addr, s.proxyURL.User)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tunnel, nil
+}
+
+// connect requests the proxy connected to by conn to tunnel it to addr.
+func connect(ctx context.Context, conn net.Conn, addr string, user *url.Userinfo) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+
+	if user != nil {
+		password, _ := user.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("connecting through proxy: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, fmt.Errorf("connecting through proxy: %w", err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("connecting through proxy: proxy returned %q", resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn is a net.Conn whose reads are served from r first, which may
+// have buffered data received after the response of the proxy.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
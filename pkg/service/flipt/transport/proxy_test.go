@@ -0,0 +1,275 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWithProxyURL_HTTP(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "flipt.internal:8080", r.URL.Host)
+		w.Header().Set("X-Proxied", "true")
+	}))
+	t.Cleanup(proxy.Close)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	s := New(WithAddress("http://flipt.internal:8080"), WithProxyURL(proxyURL))
+
+	resp, err := s.httpClient().Get("http://flipt.internal:8080/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "true", resp.Header.Get("X-Proxied"))
+}
+
+// connectProxy is an HTTP CONNECT proxy recording the tunneled addresses.
+func connectProxy(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	tunneled := make(chan string, 1)
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+
+				req, err := http.ReadRequest(r)
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+
+				if user, password, ok := (&http.Request{Header: http.Header{"Authorization": req.Header["Proxy-Authorization"]}}).BasicAuth(); !ok || user != "user" || password != "pass" {
+					_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+					return
+				}
+
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+
+				defer target.Close()
+
+				tunneled <- req.Host
+
+				_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+				go func() { _, _ = io.Copy(target, r) }()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return lis.Addr().String(), tunneled
+}
+
+func TestWithProxyURL_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	proxyAddr, tunneled := connectProxy(t)
+
+	addr := fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)
+
+	s := New(WithAddress(addr), WithProxyURL(&url.URL{Scheme: "http", Host: proxyAddr, User: url.UserPassword("user", "pass")}))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, addr, <-tunneled)
+}
+
+func TestConnect_Rejected(t *testing.T) {
+	proxyAddr, _ := connectProxy(t)
+
+	conn, err := net.Dial("tcp", proxyAddr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = connect(context.Background(), conn, "flipt:9000", nil)
+	assert.EqualError(t, err, `connecting through proxy: proxy returned "407 Proxy Authentication Required"`)
+}
+
+// socks5Proxy is a SOCKS5 proxy requiring username and password
+// authentication, recording the tunneled addresses.
+func socks5Proxy(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	tunneled := make(chan string, 2)
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+
+				// greeting, selecting username/password authentication
+				greeting := make([]byte, 2)
+				if _, err := io.ReadFull(r, greeting); err != nil {
+					return
+				}
+
+				if _, err := io.ReadFull(r, make([]byte, greeting[1])); err != nil {
+					return
+				}
+
+				_, _ = conn.Write([]byte{5, 2})
+
+				// username/password authentication
+				version, _ := r.ReadByte()
+				user := readSOCKSString(r)
+				password := readSOCKSString(r)
+
+				if version != 1 || user != "user" || password != "pass" {
+					_, _ = conn.Write([]byte{1, 1})
+					return
+				}
+
+				_, _ = conn.Write([]byte{1, 0})
+
+				// connect request
+				header := make([]byte, 4)
+				if _, err := io.ReadFull(r, header); err != nil {
+					return
+				}
+
+				var host string
+
+				switch header[3] {
+				case 1:
+					ip := make([]byte, 4)
+					_, _ = io.ReadFull(r, ip)
+					host = net.IP(ip).String()
+				case 3:
+					host = readSOCKSString(r)
+				default:
+					return
+				}
+
+				port := make([]byte, 2)
+				_, _ = io.ReadFull(r, port)
+
+				addr := net.JoinHostPort(host, fmt.Sprint(int(port[0])<<8|int(port[1])))
+
+				target, err := net.Dial("tcp", addr)
+				if err != nil {
+					_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+
+				defer target.Close()
+
+				tunneled <- addr
+
+				_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+				go func() { _, _ = io.Copy(target, r) }()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return lis.Addr().String(), tunneled
+}
+
+func readSOCKSString(r *bufio.Reader) string {
+	n, err := r.ReadByte()
+	if err != nil {
+		return ""
+	}
+
+	b := make([]byte, n)
+	_, _ = io.ReadFull(r, b)
+
+	return string(b)
+}
+
+func TestWithSOCKS5Proxy_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	proxyAddr, tunneled := socks5Proxy(t)
+
+	s := New(WithAddress(srv.URL), WithSOCKS5Proxy(proxyAddr, "user", "pass"))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, srv.Listener.Addr().String(), <-tunneled)
+}
+
+func TestWithSOCKS5Proxy_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	proxyAddr, tunneled := socks5Proxy(t)
+
+	addr := fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)
+
+	s := New(WithAddress(addr), WithSOCKS5Proxy(proxyAddr, "user", "pass"))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, addr, <-tunneled)
+}
+
+func TestWithSOCKS5Proxy_Rejected(t *testing.T) {
+	proxyAddr, _ := socks5Proxy(t)
+
+	s := New(WithSOCKS5Proxy(proxyAddr, "user", "wrong"))
+
+	_, err := s.httpClient().Get("http://127.0.0.1:1")
+	assert.Error(t, err)
+}
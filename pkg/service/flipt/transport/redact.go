@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"encoding/json"
+	"regexp"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/util"
+)
+
+// WithRedactionPatterns sets patterns whose matches are redacted from the
+// errors returned and logged by the service, in addition to URL credentials,
+// token-like query parameters, authorization headers and JSON web tokens,
+// which are always redacted.
+func WithRedactionPatterns(patterns ...*regexp.Regexp) Option {
+	return func(s *Service) {
+		s.redactionPatterns = patterns
+	}
+}
+
+// ContextRedactFunc returns the value to log in place of a sensitive
+// evaluation context value, or the value unchanged when it is not sensitive.
+// The entity ID of evaluations is passed with the "targetingKey" key.
+type ContextRedactFunc func(key string, value interface{}) interface{}
+
+// WithContextRedaction sets the function redacting the entity IDs and
+// evaluation context values of the request bodies logged by wire logging.
+func WithContextRedaction(fn ContextRedactFunc) Option {
+	return func(s *Service) {
+		s.contextRedaction = fn
+	}
+}
+
+// redactRequestBody returns the JSON body of a request with the entity IDs
+// and evaluation context values of the evaluation requests it holds redacted
+// by the context redaction, if any. Bodies which are not JSON objects are
+// omitted then, as they cannot be redacted.
+func (s *Service) redactRequestBody(body []byte) []byte {
+	if s.contextRedaction == nil || len(body) == 0 {
+		return body
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return []byte("(omitted)")
+	}
+
+	s.redactEvaluationRequests(decoded)
+
+	redacted, err := json.Marshal(decoded)
+	if err != nil {
+		return []byte("(omitted)")
+	}
+
+	return redacted
+}
+
+// redactEvaluationRequests redacts the entity IDs and contexts of the
+// evaluation requests found in v, e.g. the requests of a batch evaluation.
+func (s *Service) redactEvaluationRequests(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			switch k {
+			case "entityId":
+				v[k] = s.contextRedaction(of.TargetingKey, value)
+			case "context":
+				if evalCtx, ok := value.(map[string]interface{}); ok {
+					for ck, cv := range evalCtx {
+						evalCtx[ck] = s.contextRedaction(ck, cv)
+					}
+				}
+			default:
+				s.redactEvaluationRequests(value)
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			s.redactEvaluationRequests(value)
+		}
+	}
+}
+
+// redact returns err with secrets removed from its message.
+func (s *Service) redact(err error) error {
+	return util.RedactError(err, s.redactionPatterns...)
+}
+
+// redactedAddress returns the address of the service with secrets removed.
+func (s *Service) redactedAddress() string {
+	return util.Redact(s.address, s.redactionPatterns...)
+}
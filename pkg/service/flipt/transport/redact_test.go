@@ -0,0 +1,30 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedaction(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// nothing listens on the address anymore
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	s := New(WithAddress("http://"+addr+"/?token=s3cret"), WithRedactionPatterns(regexp.MustCompile(regexp.QuoteMeta(addr))))
+
+	err = s.Check(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	assert.NotContains(t, err.Error(), addr)
+	assert.Contains(t, err.Error(), "[REDACTED]")
+
+	assert.Equal(t, "http://[REDACTED]/?token=[REDACTED]", s.redactedAddress())
+}
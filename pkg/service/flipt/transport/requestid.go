@@ -0,0 +1,33 @@
+package transport
+
+import "context"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context whose evaluations are sent to Flipt
+// with id as their request ID, so that Flipt logs them under the same ID as
+// the caller, e.g. the ID of the incoming request being served. It takes
+// precedence over a "requestID" entry of the evaluation context.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the request ID of an evaluation, as set by
+// ContextWithRequestID or by the "requestID" entry of its context ec.
+func requestIDFromContext(ctx context.Context, ec map[string]string) string {
+	if id, _ := ctx.Value(requestIDKey{}).(string); id != "" {
+		return id
+	}
+
+	return ec[requestID]
+}
+
+// withRequestID appends id, if any, to msg so that errors can be correlated
+// with the logs of Flipt.
+func withRequestID(msg, id string) string {
+	if id == "" {
+		return msg
+	}
+
+	return msg + " (request ID: " + id + ")"
+}
@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy configures how calls to Flipt failing with a transient error
+// are retried. The same policy applies to the gRPC and HTTP transports, as
+// errors of both are reported as gRPC status codes: HTTP requests which
+// cannot reach Flipt fail with Unavailable and those timing out with
+// DeadlineExceeded.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, doubled for every
+	// subsequent retry up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay which is
+	// randomized to avoid retries from many clients being synchronized.
+	Jitter float64
+	// RetryableCodes are the status codes which are retried. By default only
+	// Unavailable and ResourceExhausted are retried.
+	RetryableCodes []codes.Code
+}
+
+// WithRetryPolicy sets the policy used to retry calls to Flipt failing with
+// a transient error. By default calls are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *Service) {
+		s.retryPolicy = policy
+	}
+}
+
+// retry calls call until it succeeds, fails with an error which is not
+// retryable, the attempts are exhausted or ctx is done, returning the error
+// of the last attempt.
+func (s *Service) retry(ctx context.Context, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= s.retryPolicy.MaxAttempts || !s.retryPolicy.retryable(err) {
+			return err
+		}
+
+		delay := s.retryPolicy.backoff(attempt, rand.Float64())
+
+		s.log().Debug("retrying flipt call", "attempt", attempt, "delay", delay, "error", s.redact(err))
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	code := errorCode(err)
+
+	if len(p.RetryableCodes) == 0 {
+		return code == codes.Unavailable || code == codes.ResourceExhausted
+	}
+
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before retrying after the given attempt, where r
+// is a random number in [0, 1) used to apply jitter.
+func (p RetryPolicy) backoff(attempt int, r float64) time.Duration {
+	delay := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	if jitter > 0 {
+		delay -= time.Duration(float64(delay) * jitter * r)
+	}
+
+	return delay
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	offlipt "go.flipt.io/flipt-openfeature-provider/pkg/service/flipt"
+	"go.flipt.io/flipt/rpc/flipt/evaluation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		r        float64
+		expected time.Duration
+	}{
+		{
+			name:     "first retry",
+			policy:   RetryPolicy{BaseBackoff: 100 * time.Millisecond},
+			attempt:  1,
+			expected: 100 * time.Millisecond,
+		},
+		{
+			name:     "exponential",
+			policy:   RetryPolicy{BaseBackoff: 100 * time.Millisecond},
+			attempt:  4,
+			expected: 800 * time.Millisecond,
+		},
+		{
+			name:     "capped",
+			policy:   RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond},
+			attempt:  4,
+			expected: 300 * time.Millisecond,
+		},
+		{
+			name:     "jitter",
+			policy:   RetryPolicy{BaseBackoff: 100 * time.Millisecond, Jitter: 0.5},
+			attempt:  2,
+			r:        0.5,
+			expected: 150 * time.Millisecond,
+		},
+		{
+			name:     "full jitter",
+			policy:   RetryPolicy{BaseBackoff: 100 * time.Millisecond, Jitter: 2},
+			attempt:  1,
+			r:        0.25,
+			expected: 75 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.backoff(tt.attempt, tt.r))
+		})
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	var defaults RetryPolicy
+
+	assert.True(t, defaults.retryable(status.Error(codes.Unavailable, "unavailable")))
+	assert.True(t, defaults.retryable(status.Error(codes.ResourceExhausted, "rate limited")))
+	assert.False(t, defaults.retryable(status.Error(codes.NotFound, "not found")))
+	assert.False(t, defaults.retryable(errors.New("boom")))
+	assert.True(t, defaults.retryable(&url.Error{Op: "Post", URL: "http://flipt", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}))
+	assert.False(t, defaults.retryable(&url.Error{Op: "Post", URL: "http://flipt", Err: context.Canceled}))
+
+	custom := RetryPolicy{RetryableCodes: []codes.Code{codes.Aborted}}
+
+	assert.True(t, custom.retryable(status.Error(codes.Aborted, "aborted")))
+	assert.False(t, custom.retryable(status.Error(codes.Unavailable, "unavailable")))
+}
+
+func TestEvaluate_Retry(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Variant(mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unavailable, "unavailable")).Twice()
+	mockClient.EXPECT().Variant(mock.Anything, mock.Anything).Return(&evaluation.VariantEvaluationResponse{Match: true}, nil).Once()
+
+	s := &Service{
+		client:      mockClient,
+		retryPolicy: RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond},
+	}
+
+	ctx, info := ContextWithCallInfo(context.Background())
+
+	resp, err := s.Evaluate(ctx, "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	require.NoError(t, err)
+	assert.True(t, resp.Match)
+	assert.Equal(t, 3, info.Attempts)
+}
+
+func TestEvaluate_RetryExhausted(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Boolean(mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unavailable, "unavailable")).Twice()
+
+	s := &Service{
+		client:      mockClient,
+		retryPolicy: RetryPolicy{MaxAttempts: 2},
+	}
+
+	_, err := s.Boolean(context.Background(), "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	assert.EqualError(t, err, of.NewProviderNotReadyResolutionError("unavailable").Error())
+}
+
+func TestEvaluate_NotRetryable(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Boolean(mock.Anything, mock.Anything).Return(nil, status.Error(codes.NotFound, "not found")).Once()
+
+	s := &Service{
+		client:      mockClient,
+		retryPolicy: RetryPolicy{MaxAttempts: 3},
+	}
+
+	_, err := s.Boolean(context.Background(), "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	assert.EqualError(t, err, of.NewFlagNotFoundResolutionError("not found").Error())
+}
+
+func TestEvaluate_RetryHTTP(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	address := "http://" + lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	// connections refused by a closed port are retried like Unavailable
+	s := New(WithAddress(address), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}))
+	t.Cleanup(func() { _ = s.Close() })
+
+	ctx, info := ContextWithCallInfo(context.Background())
+
+	_, err = s.Boolean(ctx, "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 3, info.Attempts)
+}
@@ -2,26 +2,39 @@ package transport
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
-	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
-	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
 	offlipt "go.flipt.io/flipt-openfeature-provider/pkg/service/flipt"
-	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/util"
 	flipt "go.flipt.io/flipt/rpc/flipt"
 	"go.flipt.io/flipt/rpc/flipt/evaluation"
 	sdk "go.flipt.io/flipt/sdk/go"
 	sdkgrpc "go.flipt.io/flipt/sdk/go/grpc"
 	sdkhttp "go.flipt.io/flipt/sdk/go/http"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/net/proxy"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -29,14 +42,62 @@ const (
 	defaultAddr = "http://localhost:8080"
 )
 
+// roundRobinServiceConfig is the gRPC service config spreading calls across
+// the resolved addresses.
+const roundRobinServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// errClosed is returned by calls made after the Service has been closed.
+var errClosed = of.NewProviderNotReadyResolutionError("flipt service is closed")
+
+// TargetingKeyFunc extracts the entity ID used for evaluation from the
+// evaluation context.
+type TargetingKeyFunc func(evalCtx of.FlattenedContext) (string, error)
+
 // Service is a Transport service.
 type Service struct {
-	client            offlipt.Client
-	address           string
-	certificatePath   string
-	unaryInterceptors []grpc.UnaryClientInterceptor
-	once              sync.Once
-	tokenProvider     sdk.ClientTokenProvider
+	client             offlipt.Client
+	conn               *grpc.ClientConn
+	hclient            *http.Client
+	httpTransport      *http.Transport
+	wrapHTTPTransport  func(http.RoundTripper) http.RoundTripper
+	wireLogging        *WireLogging
+	closed             atomic.Bool
+	address            string
+	certificatePath    string
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	otelInterceptor    grpc.UnaryClientInterceptor
+	statsHandlers      []stats.Handler
+	dialOptions        []grpc.DialOption
+	keepalive          *keepalive.ClientParameters
+	connectTimeout     time.Duration
+	maxRecvMsgSize     int
+	maxSendMsgSize     int
+	roundRobin         bool
+	connMu             sync.Mutex
+	clientCertificate  *clientCertificate
+	tlsClientConfig    *tls.Config
+	insecureSkipVerify bool
+	tlsServerName      string
+	perRPCCredentials  credentials.PerRPCCredentials
+	tokenProvider      sdk.ClientTokenProvider
+	authorization      authorization
+	invalidateToken    func()
+	basicAuth          *url.Userinfo
+	sigV4              *sigV4Signer
+	proxyURL           *url.URL
+	socks5             proxy.ContextDialer
+	headers            map[string]string
+	redactionPatterns  []*regexp.Regexp
+	contextRedaction   ContextRedactFunc
+	targetingKeyFunc   TargetingKeyFunc
+	anonymous          bool
+	anonymousFields    []string
+	anonymousID        string
+	contextKeyMap      map[string]string
+	logger             logging.Logger
+	evaluationTimeout  time.Duration
+	defaultDeadline    time.Duration
+	retryPolicy        RetryPolicy
 }
 
 // Option is a service option.
@@ -49,7 +110,8 @@ func WithAddress(address string) Option {
 	}
 }
 
-// WithCertificatePath sets the certificate path for the service.
+// WithCertificatePath sets the path of the CA certificate trusted by the
+// gRPC and HTTPS transports.
 func WithCertificatePath(certificatePath string) Option {
 	return func(s *Service) {
 		s.certificatePath = certificatePath
@@ -57,10 +119,111 @@ func WithCertificatePath(certificatePath string) Option {
 }
 
 // WithUnaryClientInterceptor sets the provided unary client interceptors
-// to be applied to the established gRPC client connection.
+// to be applied to the established gRPC client connection, in place of the
+// default otelgrpc interceptor.
 func WithUnaryClientInterceptor(unaryInterceptors ...grpc.UnaryClientInterceptor) Option {
 	return func(s *Service) {
 		s.unaryInterceptors = unaryInterceptors
+		s.otelInterceptor = nil
+	}
+}
+
+// WithGRPCStatsHandler sets handlers of the stats of the established gRPC
+// client connection, e.g. otelgrpc.NewClientHandler().
+func WithGRPCStatsHandler(handlers ...stats.Handler) Option {
+	return func(s *Service) {
+		s.statsHandlers = append(s.statsHandlers, handlers...)
+	}
+}
+
+// WithOTelGRPC instruments the established gRPC client connection with the
+// otelgrpc client stats handler configured by opts, recording spans and
+// metrics of every call. It replaces the default otelgrpc interceptor, which
+// only uses the global tracer provider.
+func WithOTelGRPC(opts ...otelgrpc.Option) Option {
+	return func(s *Service) {
+		s.statsHandlers = append(s.statsHandlers, otelgrpc.NewClientHandler(opts...))
+		s.otelInterceptor = nil
+	}
+}
+
+// WithConnectTimeout bounds the establishment of the gRPC connection, made
+// by the first call to Flipt, e.g. the Check made by the provider on Init, to
+// timeout. That call then fails with an error matching ErrUnavailable while
+// the connection keeps being established in the background. By default the
+// first call waits for the connection until its context is done, failing
+// with an error matching ErrTimeout or ErrCanceled then.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.connectTimeout = timeout
+	}
+}
+
+// WithMaxMessageSizes sets the maximum size in bytes of the messages
+// received and sent over the established gRPC client connection, e.g. for
+// large flag attachments. Zero keeps the gRPC default, 4MB for received
+// messages and unbounded for sent ones.
+func WithMaxMessageSizes(receive, send int) Option {
+	return func(s *Service) {
+		s.maxRecvMsgSize = receive
+		s.maxSendMsgSize = send
+	}
+}
+
+// WithRoundRobinBalancing spreads the calls made over gRPC across every
+// address the target of the service resolves to, e.g. all the Flipt pods
+// behind a headless Kubernetes service, instead of pinning them to the first
+// one. Targets without a resolver scheme are resolved using DNS, which is
+// re-resolved as connections are lost.
+func WithRoundRobinBalancing() Option {
+	return func(s *Service) {
+		s.roundRobin = true
+	}
+}
+
+// WithDialOptions sets additional options of the established gRPC client
+// connection, e.g. custom resolvers, interceptors or credentials. They are
+// applied after the options set by the service, which they override.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(s *Service) {
+		s.dialOptions = append(s.dialOptions, opts...)
+	}
+}
+
+// WithKeepalive enables keepalive pings of the established gRPC client
+// connection: after interval without activity, the connection is pinged and
+// closed when the ping is not acknowledged within timeout, so that
+// connections silently dropped by NATs or load balancers are replaced
+// instead of failing calls. Idle connections are only pinged when
+// permitWithoutStream is set. Flipt must permit pings this frequent.
+func WithKeepalive(interval, timeout time.Duration, permitWithoutStream bool) Option {
+	return func(s *Service) {
+		s.keepalive = &keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+	}
+}
+
+// WithHTTPTransportWrapper sets a function wrapping the transport of the
+// HTTP client, e.g. to instrument calls to Flipt:
+//
+//	transport.WithHTTPTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+//		return otelhttp.NewTransport(rt)
+//	})
+func WithHTTPTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(s *Service) {
+		s.wrapHTTPTransport = wrap
+	}
+}
+
+// WithGRPCCredentials sets credentials attached to every gRPC call, such as
+// OAuth token sources or custom request signers. Credentials requiring
+// transport security can only be used with TLS.
+func WithGRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(s *Service) {
+		s.perRPCCredentials = creds
 	}
 }
 
@@ -72,15 +235,70 @@ func WithClientTokenProvider(tokenProvider sdk.ClientTokenProvider) Option {
 	}
 }
 
+// WithTargetingKeyFunc sets the function used to derive the entity ID from the
+// evaluation context. By default the OpenFeature targeting key is used.
+func WithTargetingKeyFunc(fn TargetingKeyFunc) Option {
+	return func(s *Service) {
+		s.targetingKeyFunc = fn
+	}
+}
+
+// WithAnonymousTargetingKey generates a stable anonymous entity ID when the
+// targeting key is missing, instead of failing with TARGETING_KEY_MISSING.
+// When fields are provided and present in the evaluation context, the ID is
+// derived from their values, otherwise an ID generated once per service is used.
+func WithAnonymousTargetingKey(fields ...string) Option {
+	return func(s *Service) {
+		s.anonymous = true
+		s.anonymousFields = fields
+		s.anonymousID = newUUID()
+	}
+}
+
+// WithContextKeyMap renames evaluation context attributes before they are sent
+// to Flipt, e.g. {"email": "user_email"} sends the "email" attribute as the
+// "user_email" property. Renamed attributes take precedence over existing
+// attributes with the same name.
+func WithContextKeyMap(keyMap map[string]string) Option {
+	return func(s *Service) {
+		s.contextKeyMap = keyMap
+	}
+}
+
+// WithLogger sets the logger used to report connection events and failed
+// calls to Flipt. By default nothing is logged.
+func WithLogger(logger logging.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithEvaluationTimeout bounds each GetFlag, Boolean and Evaluate call to
+// Flipt by timeout, so that a slow server fails fast instead of stalling the
+// caller. A timeout of zero disables it.
+func WithEvaluationTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.evaluationTimeout = timeout
+	}
+}
+
+// WithDefaultDeadline bounds calls to Flipt by timeout when the caller's
+// context has no deadline, e.g. context.Background(), so that they cannot hang
+// indefinitely. Deadlines set by the caller are left untouched.
+func WithDefaultDeadline(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.defaultDeadline = timeout
+	}
+}
+
 // New creates a new Transport service.
 func New(opts ...Option) *Service {
 	s := &Service{
 		address: defaultAddr,
-		unaryInterceptors: []grpc.UnaryClientInterceptor{
-			// by default this establishes the otel.TextMapPropagator
-			// registers to the otel package.
-			otelgrpc.UnaryClientInterceptor(),
-		},
+		logger:  logging.Nop(),
+		// by default this establishes the otel.TextMapPropagator
+		// registers to the otel package.
+		otelInterceptor: otelgrpc.UnaryClientInterceptor(),
 	}
 
 	for _, opt := range opts {
@@ -90,129 +308,513 @@ func New(opts ...Option) *Service {
 	return s
 }
 
-func (s *Service) connect() (*grpc.ClientConn, error) {
-	var (
-		err         error
-		credentials = insecure.NewCredentials()
-	)
+// Validate reports whether the address and certificate path of the service
+// are usable, so that misconfiguration is detected before the first call.
+// The error returned is a *ConfigError.
+func (s *Service) Validate() error {
+	var errs []error
+
+	if err := validateAddress(s.address); err != nil {
+		errs = append(errs, fmt.Errorf("invalid address %q: %w", s.address, err))
+	}
 
 	if s.certificatePath != "" {
-		credentials, err = loadTLSCredentials(s.certificatePath)
-		if err != nil {
-			// TODO: log error?
-			credentials = insecure.NewCredentials()
+		if _, err := loadTLSCredentials(s.certificatePath); err != nil {
+			errs = append(errs, fmt.Errorf("invalid certificate path %q: %w", s.certificatePath, err))
+		}
+	}
+
+	if c := s.clientCertificate; c != nil {
+		if _, err := tls.LoadX509KeyPair(c.certPath, c.keyPath); err != nil {
+			errs = append(errs, fmt.Errorf("invalid client certificate %q: %w", c.certPath, err))
+		}
+	}
+
+	if err := s.redact(errors.Join(errs...)); err != nil {
+		return &ConfigError{Err: err}
+	}
+
+	return nil
+}
+
+// validateAddress checks that address is an HTTP(S) URL, a unix socket or a
+// gRPC target.
+func validateAddress(address string) error {
+	if address == "" {
+		return errors.New("address is empty")
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https":
+		if u.Host == "" {
+			return errors.New("missing host")
+		}
+	case u.Scheme == "unix":
+		if u.Path == "" && u.Opaque == "" {
+			return errors.New("missing socket path")
+		}
+	case strings.Contains(address, "://"):
+		// other gRPC resolver schemes, e.g. dns:///flipt:9000
+	default:
+		if _, port, err := net.SplitHostPort(address); err != nil {
+			return err
+		} else if port == "" {
+			return errors.New("missing port")
+		}
+	}
+
+	return nil
+}
+
+// connect establishes the gRPC connection to Flipt, waiting until it is
+// ready, ctx is done or the connect timeout elapses.
+func (s *Service) connect(ctx context.Context) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+
+	if s.usesTLS() {
+		config, cerr := s.tlsConfig()
+		if cerr != nil {
+			s.log().Warn("falling back to insecure credentials", "certificatePath", s.certificatePath, "error", s.redact(cerr))
+		} else {
+			creds = credentials.NewTLS(config)
 		}
 	}
 
 	var address = s.address
 
-	if strings.HasPrefix(s.address, "unix://") {
+	switch {
+	case strings.HasPrefix(s.address, "unix://"):
 		address = "passthrough:///" + s.address
+	case s.roundRobin && !strings.Contains(s.address, "://"):
+		// the default resolver passes the target through to a single
+		// connection
+		address = "dns:///" + s.address
 	}
 
-	conn, err := grpc.Dial(
-		address,
-		grpc.WithTransportCredentials(credentials),
-		grpc.WithBlock(),
-		grpc.WithChainUnaryInterceptor(s.unaryInterceptors...),
-	)
-	if err != nil {
+	var interceptors []grpc.UnaryClientInterceptor
+	if s.otelInterceptor != nil {
+		interceptors = append(interceptors, s.otelInterceptor)
+	}
+
+	interceptors = append(interceptors, s.unaryInterceptors...)
+	interceptors = append(interceptors, s.headersInterceptor)
+
+	if s.authorization != nil {
+		interceptors = append(interceptors, s.authorizationInterceptor)
+	}
+
+	if s.wireLogging != nil {
+		interceptors = append(interceptors, s.wireLogInterceptor)
+	}
+
+	s.log().Debug("connecting to flipt", "address", s.redactedAddress())
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	}
+
+	if s.perRPCCredentials != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(s.perRPCCredentials))
+	}
+
+	for _, handler := range s.statsHandlers {
+		opts = append(opts, grpc.WithStatsHandler(handler))
+	}
+
+	if (s.proxyURL != nil || s.socks5 != nil) && !strings.HasPrefix(s.address, "unix://") {
+		opts = append(opts, grpc.WithContextDialer(s.dialGRPC))
+	}
+
+	if s.keepalive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*s.keepalive))
+	}
+
+	if s.roundRobin {
+		opts = append(opts, grpc.WithDefaultServiceConfig(roundRobinServiceConfig))
+	}
+
+	var callOpts []grpc.CallOption
+	if s.maxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(s.maxRecvMsgSize))
+	}
+
+	if s.maxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(s.maxSendMsgSize))
+	}
+
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	opts = append(opts, s.dialOptions...)
+
+	return s.dialConn(ctx, address, opts)
+}
+
+// dialConn establishes the gRPC connection to address, failing when it is
+// not ready before ctx is done or the connect timeout elapses. The
+// connection is returned along with the error in that case, still connecting
+// in the background so that the calls made once Flipt is reachable succeed.
+func (s *Service) dialConn(ctx context.Context, address string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	dialCtx, cancel := ctx, context.CancelFunc(func() {})
+	if s.connectTimeout > 0 {
+		dialCtx, cancel = context.WithTimeout(ctx, s.connectTimeout)
+	}
+	defer cancel()
+
+	conn, err := grpc.DialContext(dialCtx, address, append(opts, grpc.WithBlock())...)
+	if err == nil {
+		return conn, nil
+	}
+
+	s.log().Warn("connecting to flipt failed", "address", s.redactedAddress(), "timeout", s.connectTimeout, "error", s.redact(err))
+
+	if dialCtx.Err() == nil {
 		return nil, fmt.Errorf("dialing %w", err)
 	}
 
-	return conn, nil
+	conn, derr := grpc.Dial(address, opts...)
+	if derr != nil {
+		return nil, fmt.Errorf("dialing %w", derr)
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return conn, fmt.Errorf("dialing %s canceled: %w", s.redactedAddress(), ErrCanceled)
+	case ctx.Err() != nil:
+		return conn, fmt.Errorf("dialing %s timed out: %w", s.redactedAddress(), ErrTimeout)
+	}
+
+	return conn, fmt.Errorf("dialing %s timed out after %s: %w", s.redactedAddress(), s.connectTimeout, ErrUnavailable)
+}
+
+// log returns the logger of the service.
+func (s *Service) log() logging.Logger {
+	if s.logger == nil {
+		return logging.Nop()
+	}
+
+	return s.logger
 }
 
-func (s *Service) instance() (offlipt.Client, error) {
+func (s *Service) instance(ctx context.Context) (offlipt.Client, error) {
 	type fclient struct {
 		*sdk.Flipt
 		*sdk.Evaluation
 	}
 
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	// checked once the lock is held, as Close may have been called while
+	// waiting for it
+	if s.closed.Load() {
+		return nil, errClosed
+	}
+
 	if s.client != nil {
 		return s.client, nil
 	}
 
-	var err error
+	opts := []sdk.Option{}
 
-	s.once.Do(func() {
-		u, uerr := url.Parse(s.address)
-		if uerr != nil {
-			err = fmt.Errorf("connecting %w", uerr)
-		}
+	if s.tokenProvider != nil && s.authorization == nil {
+		opts = append(opts, sdk.WithClientTokenProvider(s.tokenProvider))
+	}
 
-		opts := []sdk.Option{}
+	if s.usesHTTP() {
+		// kept for the health checks to share the middleware of evaluations
+		s.hclient = s.httpClient()
 
-		if s.tokenProvider != nil {
-			opts = append(opts, sdk.WithClientTokenProvider(s.tokenProvider))
+		hclient := sdk.New(sdkhttp.NewTransport(s.address, sdkhttp.WithHTTPClient(s.hclient)), opts...)
+		s.client = &fclient{
+			hclient.Flipt(),
+			hclient.Evaluation(),
 		}
 
-		hclient := sdk.New(sdkhttp.NewTransport(s.address), opts...)
-		if u.Scheme == "https" || u.Scheme == "http" {
-			s.client = &fclient{
-				hclient.Flipt(),
-				hclient.Evaluation(),
-			}
+		return s.client, nil
+	}
 
-			return
-		}
+	// a failed connection is not kept, so that the next call connects again
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return nil, s.redact(fmt.Errorf("connecting %w", err))
+	}
 
-		conn, cerr := s.connect()
-		if cerr != nil {
-			err = fmt.Errorf("connecting %w", cerr)
-		}
+	s.conn = conn
 
-		gclient := sdk.New(sdkgrpc.NewTransport(conn), opts...)
-		s.client = &fclient{
-			gclient.Flipt(),
-			gclient.Evaluation(),
+	gclient := sdk.New(sdkgrpc.NewTransport(conn), opts...)
+	s.client = &fclient{
+		gclient.Flipt(),
+		gclient.Evaluation(),
+	}
+
+	return s.client, nil
+}
+
+// usesHTTP reports whether Flipt is called over its HTTP API rather than
+// gRPC. gRPC targets such as 10.0.0.1:9000 are not valid URLs.
+func (s *Service) usesHTTP() bool {
+	u, err := url.Parse(s.address)
+
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http")
+}
+
+// httpClient returns the client used for requests to the Flipt HTTP API.
+// Each Service owns its connection pool so that it can be released by Close.
+func (s *Service) httpClient() *http.Client {
+	s.httpTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+	if s.socks5 != nil {
+		s.httpTransport.Proxy = nil
+		s.httpTransport.DialContext = s.dial
+	}
+
+	if s.proxyURL != nil {
+		s.httpTransport.Proxy = http.ProxyURL(s.proxyURL)
+	}
+
+	if s.usesTLS() {
+		config, err := s.tlsConfig()
+		if err != nil {
+			s.log().Warn("falling back to default TLS configuration", "certificatePath", s.certificatePath, "error", s.redact(err))
+		} else {
+			s.httpTransport.TLSClientConfig = config
 		}
-	})
+	}
+
+	var transport http.RoundTripper = s.httpTransport
+	if s.wireLogging != nil {
+		transport = wireLogTransport{next: transport, s: s}
+	}
+
+	transport = statusTransport{next: transport}
+	if s.sigV4 != nil {
+		transport = sigV4Transport{next: transport, signer: s.sigV4}
+	}
 
-	return s.client, err
+	if s.basicAuth != nil {
+		transport = basicAuthTransport{next: transport, userinfo: s.basicAuth}
+	}
+
+	if s.authorization != nil {
+		transport = authTransport{next: transport, authorization: s.authorization, invalidate: s.invalidateToken}
+	}
+
+	transport = headerTransport{next: transport, headers: s.headers}
+
+	transport = enumTransport{next: newETagTransport(transport)}
+
+	if s.wrapHTTPTransport != nil {
+		transport = s.wrapHTTPTransport(transport)
+	}
+
+	return &http.Client{Transport: transport}
 }
 
 // GetFlag returns a flag if it exists for the given namespace/flag key pair.
 func (s *Service) GetFlag(ctx context.Context, namespaceKey, flagKey string) (*flipt.Flag, error) {
-	conn, err := s.instance()
+	ctx, cancel := s.evaluationContext(ctx)
+	defer cancel()
+
+	conn, err := s.instance(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	flag, err := conn.GetFlag(ctx, &flipt.GetFlagRequest{
-		Key:          flagKey,
-		NamespaceKey: namespaceKey,
+	var flag *flipt.Flag
+
+	err = s.retry(ctx, func() (err error) {
+		start := time.Now()
+		flag, err = conn.GetFlag(ctx, &flipt.GetFlagRequest{
+			Key:          flagKey,
+			NamespaceKey: namespaceKey,
+		})
+		record(ctx, s.address, start)
+
+		return err
 	})
 	if err != nil {
-		return nil, util.GRPCToOpenFeatureError(err)
+		s.log().Debug("flipt call failed", "method", "GetFlag", "namespace", namespaceKey, "flag", flagKey, "error", s.redact(err))
+		return nil, s.resolutionError(err, "")
 	}
 
+	checkEnum(ctx, "type", int32(flag.Type))
+
 	return flag, nil
 }
 
+// ListFlags returns all flags in the given namespace, following pagination.
+func (s *Service) ListFlags(ctx context.Context, namespaceKey string) ([]*flipt.Flag, error) {
+	conn, err := s.instance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listPages(ctx, s, func(ctx context.Context, pageToken string) ([]*flipt.Flag, string, error) {
+		list, err := conn.ListFlags(ctx, &flipt.ListFlagRequest{
+			NamespaceKey: namespaceKey,
+			PageToken:    pageToken,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		return list.Flags, list.NextPageToken, nil
+	}, "method", "ListFlags", "namespace", namespaceKey)
+}
+
+// ListRules returns all rules of the given namespace/flag key pair,
+// including their distributions, following pagination.
+func (s *Service) ListRules(ctx context.Context, namespaceKey, flagKey string) ([]*flipt.Rule, error) {
+	conn, err := s.instance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listPages(ctx, s, func(ctx context.Context, pageToken string) ([]*flipt.Rule, string, error) {
+		list, err := conn.ListRules(ctx, &flipt.ListRuleRequest{
+			NamespaceKey: namespaceKey,
+			FlagKey:      flagKey,
+			PageToken:    pageToken,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		return list.Rules, list.NextPageToken, nil
+	}, "method", "ListRules", "namespace", namespaceKey, "flag", flagKey)
+}
+
+// ListRollouts returns all rollouts of the given namespace/flag key pair,
+// following pagination.
+func (s *Service) ListRollouts(ctx context.Context, namespaceKey, flagKey string) ([]*flipt.Rollout, error) {
+	conn, err := s.instance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listPages(ctx, s, func(ctx context.Context, pageToken string) ([]*flipt.Rollout, string, error) {
+		list, err := conn.ListRollouts(ctx, &flipt.ListRolloutRequest{
+			NamespaceKey: namespaceKey,
+			FlagKey:      flagKey,
+			PageToken:    pageToken,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		return list.Rules, list.NextPageToken, nil
+	}, "method", "ListRollouts", "namespace", namespaceKey, "flag", flagKey)
+}
+
+// ListSegments returns all segments in the given namespace, including their
+// constraints, following pagination.
+func (s *Service) ListSegments(ctx context.Context, namespaceKey string) ([]*flipt.Segment, error) {
+	conn, err := s.instance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return listPages(ctx, s, func(ctx context.Context, pageToken string) ([]*flipt.Segment, string, error) {
+		list, err := conn.ListSegments(ctx, &flipt.ListSegmentRequest{
+			NamespaceKey: namespaceKey,
+			PageToken:    pageToken,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		return list.Segments, list.NextPageToken, nil
+	}, "method", "ListSegments", "namespace", namespaceKey)
+}
+
+// listPages calls list for every page of a listing, starting with an empty
+// page token and following the next page token it returns, and returns the
+// items of all pages. logArgs describe the listing when a call fails.
+func listPages[T any](ctx context.Context, s *Service, list func(ctx context.Context, pageToken string) ([]T, string, error), logArgs ...interface{}) ([]T, error) {
+	var (
+		items     []T
+		pageToken string
+	)
+
+	for {
+		var (
+			page []T
+			next string
+		)
+
+		callCtx, cancel := s.callContext(ctx)
+		err := s.retry(callCtx, func() (err error) {
+			start := time.Now()
+			page, next, err = list(callCtx, pageToken)
+			record(ctx, s.address, start)
+
+			return err
+		})
+		cancel()
+		if err != nil {
+			s.log().Debug("flipt call failed", append(logArgs, "error", s.redact(err))...)
+			return nil, s.resolutionError(err, "")
+		}
+
+		items = append(items, page...)
+
+		if next == "" {
+			return items, nil
+		}
+
+		pageToken = next
+	}
+}
+
 // Boolean evaluates a boolean type flag with the given context and namespace/flag key pair.
 func (s *Service) Boolean(ctx context.Context, namespaceKey, flagKey string, evalCtx map[string]interface{}) (*evaluation.BooleanEvaluationResponse, error) {
 	if evalCtx == nil {
 		return nil, of.NewInvalidContextResolutionError("evalCtx is nil")
 	}
 
-	ec := convertMapInterface(evalCtx)
+	ec := s.convertContext(evalCtx)
 
-	targetingKey := ec[of.TargetingKey]
-	if targetingKey == "" {
-		return nil, of.NewTargetingKeyMissingResolutionError("targetingKey is missing")
+	targetingKey, err := s.targetingKey(evalCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	conn, err := s.instance()
+	ctx, cancel := s.evaluationContext(ctx)
+	defer cancel()
+
+	conn, err := s.instance(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	ber, err := conn.Boolean(ctx, &evaluation.EvaluationRequest{FlagKey: flagKey, NamespaceKey: namespaceKey, EntityId: targetingKey, RequestId: ec[requestID], Context: ec})
+	id := requestIDFromContext(ctx, ec)
+	recordRequestID(ctx, id)
+
+	var ber *evaluation.BooleanEvaluationResponse
+
+	err = s.retry(ctx, func() (err error) {
+		start := time.Now()
+		ber, err = conn.Boolean(ctx, &evaluation.EvaluationRequest{FlagKey: flagKey, NamespaceKey: namespaceKey, EntityId: targetingKey, RequestId: id, Context: ec})
+		record(ctx, s.address, start)
+
+		return err
+	})
 	if err != nil {
-		return nil, util.GRPCToOpenFeatureError(err)
+		s.log().Debug("flipt call failed", "method", "Boolean", "namespace", namespaceKey, "flag", flagKey, "requestID", id, "error", s.redact(err))
+		return nil, s.resolutionError(err, id)
 	}
 
+	checkEnum(ctx, "reason", int32(ber.Reason))
+	recordResponse(ctx, ber.RequestId, ber.RequestDurationMillis, nil)
+
 	return ber, nil
 }
 
@@ -222,50 +824,318 @@ func (s *Service) Evaluate(ctx context.Context, namespaceKey, flagKey string, ev
 		return nil, of.NewInvalidContextResolutionError("evalCtx is nil")
 	}
 
-	ec := convertMapInterface(evalCtx)
+	ec := s.convertContext(evalCtx)
 
-	targetingKey := ec[of.TargetingKey]
-	if targetingKey == "" {
-		return nil, of.NewTargetingKeyMissingResolutionError("targetingKey is missing")
+	targetingKey, err := s.targetingKey(evalCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	conn, err := s.instance()
+	ctx, cancel := s.evaluationContext(ctx)
+	defer cancel()
+
+	conn, err := s.instance(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := conn.Variant(ctx, &evaluation.EvaluationRequest{FlagKey: flagKey, NamespaceKey: namespaceKey, EntityId: targetingKey, RequestId: ec[requestID], Context: ec})
+	id := requestIDFromContext(ctx, ec)
+	recordRequestID(ctx, id)
+
+	var resp *evaluation.VariantEvaluationResponse
+
+	err = s.retry(ctx, func() (err error) {
+		start := time.Now()
+		resp, err = conn.Variant(ctx, &evaluation.EvaluationRequest{FlagKey: flagKey, NamespaceKey: namespaceKey, EntityId: targetingKey, RequestId: id, Context: ec})
+		record(ctx, s.address, start)
+
+		return err
+	})
 	if err != nil {
-		return nil, util.GRPCToOpenFeatureError(err)
+		s.log().Debug("flipt call failed", "method", "Variant", "namespace", namespaceKey, "flag", flagKey, "requestID", id, "error", s.redact(err))
+		return nil, s.resolutionError(err, id)
 	}
 
+	checkEnum(ctx, "reason", int32(resp.Reason))
+	recordResponse(ctx, resp.RequestId, resp.RequestDurationMillis, resp.SegmentKeys)
+
 	return resp, nil
 }
 
+// evaluationContext returns ctx bounded by the evaluation timeout, if any,
+// or otherwise by the default deadline.
+func (s *Service) evaluationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.evaluationTimeout <= 0 {
+		return s.callContext(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.evaluationTimeout)
+}
+
+// callContext returns ctx bounded by the default deadline when it has no
+// deadline of its own.
+func (s *Service) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || s.defaultDeadline <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, s.defaultDeadline)
+}
+
+// Close closes the underlying gRPC connection and idle HTTP connections, if
+// any have been established. Calls made after Close return an error.
+func (s *Service) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+
+	// wait for a connection being established concurrently, instance
+	// refusing to establish one afterwards
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+
+	s.log().Debug("closing flipt connections", "address", s.redactedAddress())
+
+	if s.httpTransport != nil {
+		s.httpTransport.CloseIdleConnections()
+	}
+
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+// Check reports whether the remote Flipt instance is ready to serve
+// evaluations. gRPC connections are checked using the standard
+// grpc.health.v1 protocol and HTTP connections using the /health endpoint.
+func (s *Service) Check(ctx context.Context) error {
+	ctx, cancel := s.callContext(ctx)
+	defer cancel()
+
+	if _, err := s.instance(ctx); err != nil {
+		return err
+	}
+
+	if s.usesHTTP() {
+		return s.checkHTTP(ctx)
+	}
+
+	s.connMu.Lock()
+	conn := s.conn
+	s.connMu.Unlock()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return s.resolutionError(err, "")
+	}
+
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return of.NewProviderNotReadyResolutionError(fmt.Sprintf("flipt is %s", resp.Status))
+	}
+
+	return nil
+}
+
+// CheckAccess verifies that Flipt accepts the credentials of the service by
+// listing a single flag of namespaceKey, Flipt not verifying credentials on
+// the health checks made by Check. Credentials which are accepted but not allowed to
+// list flags, e.g. restricted to evaluations by an authorization policy, pass
+// the check.
+func (s *Service) CheckAccess(ctx context.Context, namespaceKey string) error {
+	ctx, cancel := s.callContext(ctx)
+	defer cancel()
+
+	conn, err := s.instance(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ListFlags(ctx, &flipt.ListFlagRequest{NamespaceKey: namespaceKey, Limit: 1})
+	if err == nil || status.Code(err) == codes.PermissionDenied {
+		return nil
+	}
+
+	s.log().Debug("flipt call failed", "method", "ListFlags", "namespace", namespaceKey, "error", s.redact(err))
+
+	return s.resolutionError(err, "")
+}
+
+func (s *Service) checkHTTP(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.address, "/")+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("checking health %w", err)
+	}
+
+	s.connMu.Lock()
+	client := s.hclient
+	s.connMu.Unlock()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// failed responses are turned into status errors by statusTransport
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			if st, ok := status.FromError(uerr.Err); ok {
+				return of.NewProviderNotReadyResolutionError("flipt health check returned " + st.Message())
+			}
+		}
+
+		return of.NewProviderNotReadyResolutionError(s.redact(err).Error())
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return of.NewProviderNotReadyResolutionError(fmt.Sprintf("flipt health check returned %d", resp.StatusCode))
+	}
+
+	return nil
+}
+
+func (s *Service) targetingKey(evalCtx map[string]interface{}) (string, error) {
+	fn := s.targetingKeyFunc
+	if fn == nil {
+		fn = defaultTargetingKey
+	}
+
+	targetingKey, err := fn(evalCtx)
+	if err != nil {
+		var rerr of.ResolutionError
+		if errors.As(err, &rerr) {
+			return "", rerr
+		}
+
+		return "", of.NewTargetingKeyMissingResolutionError(err.Error())
+	}
+
+	if targetingKey == "" && s.anonymous {
+		targetingKey = s.anonymousKey(evalCtx)
+	}
+
+	if targetingKey == "" {
+		return "", of.NewTargetingKeyMissingResolutionError("targetingKey is missing")
+	}
+
+	return targetingKey, nil
+}
+
+// anonymousKey derives an entity ID from the configured anonymous fields,
+// falling back to the service wide anonymous ID.
+func (s *Service) anonymousKey(evalCtx map[string]interface{}) string {
+	var (
+		h     = sha256.New()
+		found bool
+	)
+
+	for _, field := range s.anonymousFields {
+		v, ok := evalCtx[field]
+		if !ok || v == nil {
+			continue
+		}
+
+		found = true
+		fmt.Fprintf(h, "%s=%v;", field, v)
+	}
+
+	if !found {
+		return s.anonymousID
+	}
+
+	return formatUUID(h.Sum(nil))
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Errorf("generating anonymous id %w", err))
+	}
+
+	return formatUUID(b)
+}
+
+// formatUUID formats the first 16 bytes of b as a version 4 UUID.
+func formatUUID(b []byte) string {
+	u := make([]byte, 16)
+	copy(u, b)
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+func defaultTargetingKey(evalCtx of.FlattenedContext) (string, error) {
+	v, ok := evalCtx[of.TargetingKey]
+	if !ok || v == nil {
+		return "", nil
+	}
+
+	return fmt.Sprintf("%v", v), nil
+}
+
+// convertContext converts the evaluation context into the string map sent to
+// Flipt, applying the configured key mapping.
+func (s *Service) convertContext(evalCtx map[string]interface{}) map[string]string {
+	ec := convertMapInterface(evalCtx)
+
+	// remove all renamed attributes first so that mappings
+	// swapping two attributes do not clobber each other
+	for from := range s.contextKeyMap {
+		delete(ec, from)
+	}
+
+	for from, to := range s.contextKeyMap {
+		if v, ok := evalCtx[from]; ok {
+			ec[to] = convertValue(v)
+		}
+	}
+
+	return ec
+}
+
+// convertMapInterface converts the evaluation context into the string map
+// expected by Flipt. Strings are passed through as is, times are formatted
+// as RFC3339, fmt.Stringers use their String form and any other value is
+// encoded as JSON so that structured values (maps, slices) survive the
+// conversion.
 func convertMapInterface(m map[string]interface{}) map[string]string {
 	ee := make(map[string]string)
 	for k, v := range m {
-		ee[k] = fmt.Sprintf("%v", v)
+		ee[k] = convertValue(v)
 	}
 
 	return ee
 }
 
-func loadTLSCredentials(serverCertPath string) (credentials.TransportCredentials, error) {
-	pemServerCA, err := os.ReadFile(serverCertPath)
+func convertValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	b, err := json.Marshal(v)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load certificate: %w", err)
+		return fmt.Sprintf("%v", v)
 	}
 
-	certPool := x509.NewCertPool()
-	if !certPool.AppendCertsFromPEM(pemServerCA) {
-		return nil, fmt.Errorf("failed to add server CA's certificate")
+	return string(b)
+}
+
+func loadTLSCredentials(serverCertPath string) (credentials.TransportCredentials, error) {
+	certPool, err := loadCertPool(serverCertPath)
+	if err != nil {
+		return nil, err
 	}
 
-	config := &tls.Config{
+	return credentials.NewTLS(&tls.Config{
 		RootCAs:    certPool,
 		MinVersion: tls.VersionTLS12,
-	}
-
-	return credentials.NewTLS(config), nil
+	}), nil
 }
@@ -2,16 +2,26 @@ package transport
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	of "github.com/open-feature/go-sdk/pkg/openfeature"
 	"github.com/stretchr/testify/assert"
 	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	offlipt "go.flipt.io/flipt-openfeature-provider/pkg/service/flipt"
 	flipt "go.flipt.io/flipt/rpc/flipt"
 	"go.flipt.io/flipt/rpc/flipt/evaluation"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 )
 
@@ -204,6 +214,150 @@ func TestEvaluate_Boolean(t *testing.T) {
 	assert.False(t, actual.Enabled, "match value should be false")
 }
 
+func TestEvaluate_Timeout(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Variant(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, _ *evaluation.EvaluationRequest) (*evaluation.VariantEvaluationResponse, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+
+		<-ctx.Done()
+
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+
+	s := &Service{
+		client:            mockClient,
+		evaluationTimeout: 50 * time.Millisecond,
+	}
+
+	_, err := s.Evaluate(context.Background(), "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	assert.EqualError(t, err, of.NewGeneralResolutionError("timeout: "+context.DeadlineExceeded.Error()).Error())
+}
+
+func TestEvaluate_DefaultDeadline(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Boolean(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, _ *evaluation.EvaluationRequest) (*evaluation.BooleanEvaluationResponse, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+		return &evaluation.BooleanEvaluationResponse{}, nil
+	}).Once()
+
+	mockClient.EXPECT().Boolean(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, _ *evaluation.EvaluationRequest) (*evaluation.BooleanEvaluationResponse, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+
+		return &evaluation.BooleanEvaluationResponse{}, nil
+	}).Once()
+
+	s := &Service{
+		client:          mockClient,
+		defaultDeadline: time.Minute,
+	}
+
+	evalCtx := map[string]interface{}{of.TargetingKey: entityID}
+
+	_, err := s.Boolean(context.Background(), "foo-namespace", "foo", evalCtx)
+	require.NoError(t, err)
+
+	// deadlines set by the caller are preserved
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	_, err = s.Boolean(ctx, "foo-namespace", "foo", evalCtx)
+	require.NoError(t, err)
+}
+
+func TestListFlags(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().ListFlags(mock.Anything, &flipt.ListFlagRequest{NamespaceKey: "foo-namespace"}).Return(&flipt.FlagList{
+		Flags:         []*flipt.Flag{{Key: "foo"}},
+		NextPageToken: "next",
+	}, nil)
+	mockClient.EXPECT().ListFlags(mock.Anything, &flipt.ListFlagRequest{NamespaceKey: "foo-namespace", PageToken: "next"}).Return(&flipt.FlagList{
+		Flags: []*flipt.Flag{{Key: "bar"}},
+	}, nil)
+
+	s := &Service{
+		client: mockClient,
+	}
+
+	flags, err := s.ListFlags(context.Background(), "foo-namespace")
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "foo", flags[0].Key)
+	assert.Equal(t, "bar", flags[1].Key)
+}
+
+func TestEvaluate_CallInfo(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Variant(mock.Anything, mock.Anything).Return(&evaluation.VariantEvaluationResponse{
+		Match:                 true,
+		SegmentKeys:           []string{"beta"},
+		RequestId:             reqID,
+		RequestDurationMillis: 1.5,
+	}, nil)
+
+	s := &Service{
+		client:  mockClient,
+		address: "http://flipt:8080",
+	}
+
+	ctx, info := ContextWithCallInfo(context.Background())
+
+	_, err := s.Evaluate(ctx, "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, info.Attempts)
+	assert.Equal(t, "http://flipt:8080", info.Endpoint)
+	assert.Positive(t, info.Latency)
+	assert.Equal(t, reqID, info.RequestID)
+	assert.Equal(t, 1.5, info.ServerDurationMillis)
+	assert.Equal(t, []string{"beta"}, info.SegmentKeys)
+}
+
+func TestEvaluate_RequestID(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Boolean(mock.Anything, mock.MatchedBy(func(req *evaluation.EvaluationRequest) bool {
+		return req.RequestId == "from-caller"
+	})).Return(nil, status.Error(codes.Internal, "database is locked"))
+
+	s := &Service{client: mockClient}
+
+	ctx, info := ContextWithCallInfo(ContextWithRequestID(context.Background(), "from-caller"))
+
+	// the request ID set on the context takes precedence over the evaluation context
+	_, err := s.Boolean(ctx, "foo-namespace", "foo", map[string]interface{}{"requestID": reqID, of.TargetingKey: entityID})
+	assert.EqualError(t, err, of.NewGeneralResolutionError("database is locked (request ID: from-caller)").Error())
+	assert.Equal(t, "from-caller", info.RequestID)
+}
+
+func TestEvaluate_UnknownReason(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	mockClient.EXPECT().Variant(mock.Anything, mock.Anything).Return(&evaluation.VariantEvaluationResponse{
+		Match:  true,
+		Reason: evaluation.EvaluationReason(42),
+	}, nil)
+
+	s := &Service{client: mockClient}
+
+	ctx, info := ContextWithCallInfo(context.Background())
+
+	_, err := s.Evaluate(ctx, "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"reason": "42"}, info.UnknownEnums)
+}
+
 func TestEvaluateInvalidContext(t *testing.T) {
 	s := &Service{}
 
@@ -214,6 +368,261 @@ func TestEvaluateInvalidContext(t *testing.T) {
 	assert.EqualError(t, err, of.NewTargetingKeyMissingResolutionError("targetingKey is missing").Error())
 }
 
+func TestEvaluateTargetingKeyFunc(t *testing.T) {
+	tests := []struct {
+		name        string
+		fn          TargetingKeyFunc
+		expectedErr error
+	}{
+		{
+			name: "from attribute",
+			fn: func(evalCtx of.FlattenedContext) (string, error) {
+				return evalCtx["userID"].(string), nil
+			},
+		},
+		{
+			name: "empty key",
+			fn: func(evalCtx of.FlattenedContext) (string, error) {
+				return "", nil
+			},
+			expectedErr: of.NewTargetingKeyMissingResolutionError("targetingKey is missing"),
+		},
+		{
+			name: "error",
+			fn: func(evalCtx of.FlattenedContext) (string, error) {
+				return "", errors.New("userID is missing")
+			},
+			expectedErr: of.NewTargetingKeyMissingResolutionError("userID is missing"),
+		},
+		{
+			name: "resolution error",
+			fn: func(evalCtx of.FlattenedContext) (string, error) {
+				return "", of.NewInvalidContextResolutionError("userID is malformed")
+			},
+			expectedErr: of.NewInvalidContextResolutionError("userID is malformed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := offlipt.NewMockClient(t)
+
+			if tt.expectedErr == nil {
+				mockClient.EXPECT().Variant(mock.Anything, &evaluation.EvaluationRequest{
+					FlagKey:      "foo",
+					NamespaceKey: "foo-namespace",
+					EntityId:     entityID,
+					Context: map[string]string{
+						"userID": entityID,
+					},
+				}).Return(&evaluation.VariantEvaluationResponse{Match: true}, nil)
+			}
+
+			s := &Service{
+				client:           mockClient,
+				targetingKeyFunc: tt.fn,
+			}
+
+			_, err := s.Evaluate(context.Background(), "foo-namespace", "foo", map[string]interface{}{
+				"userID": entityID,
+			})
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEvaluateAnonymousTargetingKey(t *testing.T) {
+	s := New(WithAnonymousTargetingKey("sessionID", "ip"))
+
+	key, err := s.targetingKey(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, s.anonymousID, key)
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", key)
+
+	derived, err := s.targetingKey(map[string]interface{}{"sessionID": "abc"})
+	require.NoError(t, err)
+	assert.NotEqual(t, s.anonymousID, derived)
+
+	again, err := New(WithAnonymousTargetingKey("sessionID", "ip")).targetingKey(map[string]interface{}{"sessionID": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, derived, again, "derived key should be stable across services")
+
+	key, err = s.targetingKey(map[string]interface{}{of.TargetingKey: entityID, "sessionID": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, entityID, key)
+}
+
+func TestCheck_GRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	hs := health.NewServer()
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	s := New(WithAddress(fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)))
+
+	assert.NoError(t, s.Check(context.Background()))
+
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	err = s.Check(context.Background())
+	assert.EqualError(t, err, of.NewProviderNotReadyResolutionError("flipt is NOT_SERVING").Error())
+}
+
+func TestCheckAccess(t *testing.T) {
+	mockClient := offlipt.NewMockClient(t)
+
+	s := &Service{client: mockClient}
+
+	mockClient.EXPECT().ListFlags(mock.Anything, &flipt.ListFlagRequest{NamespaceKey: "foo-namespace", Limit: 1}).Return(&flipt.FlagList{}, nil).Once()
+	assert.NoError(t, s.CheckAccess(context.Background(), "foo-namespace"))
+
+	// accepted credentials which may not list flags pass the check
+	mockClient.EXPECT().ListFlags(mock.Anything, mock.Anything).Return(nil, status.Error(codes.PermissionDenied, "not allowed")).Once()
+	assert.NoError(t, s.CheckAccess(context.Background(), "foo-namespace"))
+
+	mockClient.EXPECT().ListFlags(mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unauthenticated, "token expired")).Once()
+
+	var unauthenticated *UnauthenticatedError
+	assert.ErrorAs(t, s.CheckAccess(context.Background(), "foo-namespace"), &unauthenticated)
+}
+
+func TestCheck_HTTP(t *testing.T) {
+	code := http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		// the health checks share the middleware of evaluations
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant"))
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithAddress(srv.URL), WithHeaders(map[string]string{"X-Tenant": "acme"}))
+
+	assert.NoError(t, s.Check(context.Background()))
+
+	code = http.StatusServiceUnavailable
+
+	err := s.Check(context.Background())
+	assert.EqualError(t, err, of.NewProviderNotReadyResolutionError("flipt health check returned 503 Service Unavailable").Error())
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "http", address: "http://localhost:8080"},
+		{name: "https", address: "https://flipt.example.com"},
+		{name: "unix", address: "unix:///path/to/socket"},
+		{name: "grpc", address: "localhost:9000"},
+		{name: "grpc scheme", address: "dns:///flipt:9000"},
+		{name: "empty", address: "", wantErr: true},
+		{name: "missing host", address: "http://", wantErr: true},
+		{name: "missing port", address: "localhost", wantErr: true},
+		{name: "malformed", address: "http://[::1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(WithAddress(tt.address)).Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := New(WithAddress(srv.URL))
+	require.NoError(t, s.Check(context.Background()))
+
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+
+	_, err := s.GetFlag(context.Background(), "foo-namespace", "foo")
+	assert.ErrorIs(t, err, errClosed)
+
+	assert.ErrorIs(t, s.Check(context.Background()), errClosed)
+}
+
+func TestClose_Unused(t *testing.T) {
+	s := New(WithAddress("localhost:9000"))
+
+	require.NoError(t, s.Close())
+
+	_, err := s.Boolean(context.Background(), "foo-namespace", "foo", map[string]interface{}{of.TargetingKey: entityID})
+	assert.ErrorIs(t, err, errClosed)
+}
+
+func TestConvertContext(t *testing.T) {
+	s := New(WithContextKeyMap(map[string]string{
+		"email":   "user_email",
+		"a":       "b",
+		"b":       "a",
+		"missing": "other",
+	}))
+
+	actual := s.convertContext(map[string]interface{}{
+		of.TargetingKey: entityID,
+		"email":         "foo@flipt.io",
+		"a":             "from-a",
+		"b":             "from-b",
+	})
+
+	assert.Equal(t, map[string]string{
+		of.TargetingKey: entityID,
+		"user_email":    "foo@flipt.io",
+		"a":             "from-b",
+		"b":             "from-a",
+	}, actual)
+}
+
+func TestConvertMapInterface(t *testing.T) {
+	ts := time.Date(2023, 11, 2, 10, 30, 0, 0, time.UTC)
+
+	actual := convertMapInterface(map[string]interface{}{
+		"string": "foo",
+		"int":    42,
+		"float":  1.5,
+		"bool":   true,
+		"nil":    nil,
+		"time":   ts,
+		"ip":     net.ParseIP("10.0.0.1"),
+		"slice":  []string{"a", "b"},
+		"map":    map[string]interface{}{"plan": "pro", "seats": 3},
+	})
+
+	assert.Equal(t, map[string]string{
+		"string": "foo",
+		"int":    "42",
+		"float":  "1.5",
+		"bool":   "true",
+		"nil":    "",
+		"time":   "2023-11-02T10:30:00Z",
+		"ip":     "10.0.0.1",
+		"slice":  `["a","b"]`,
+		"map":    `{"plan":"pro","seats":3}`,
+	}, actual)
+}
+
 func TestLoadTLSCredentials(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -239,3 +648,38 @@ func TestLoadTLSCredentials(t *testing.T) {
 		})
 	}
 }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithHTTPTransportWrapper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "00-trace-span-01", r.Header.Get("Traceparent"))
+		assert.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var wrapped int
+
+	s := New(WithHeaders(map[string]string{"X-Api-Key": "key"}), WithHTTPTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			wrapped++
+
+			req = req.Clone(req.Context())
+			req.Header.Set("Traceparent", "00-trace-span-01")
+
+			return next.RoundTrip(req)
+		})
+	}))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, wrapped)
+}
@@ -0,0 +1,241 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the credentials signing requests with SigV4.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSCredentialsFunc returns the credentials signing a request, allowing
+// temporary credentials to be rotated.
+type AWSCredentialsFunc func(ctx context.Context) (AWSCredentials, error)
+
+// EnvAWSCredentials returns the credentials set by the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+func EnvAWSCredentials(context.Context) (AWSCredentials, error) {
+	creds := AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return AWSCredentials{}, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+
+	return creds, nil
+}
+
+// WithAWSSigV4 signs HTTP requests with AWS Signature Version 4 for the
+// service in region, e.g. "execute-api" for Flipt fronted by API Gateway with
+// IAM authorization. Requests are signed with the credentials returned by
+// credentials, or EnvAWSCredentials when nil. As the signature is sent in the
+// authorization header, it replaces the credentials of the other
+// authentication options.
+func WithAWSSigV4(region, service string, credentials AWSCredentialsFunc) Option {
+	if credentials == nil {
+		credentials = EnvAWSCredentials
+	}
+
+	return func(s *Service) {
+		s.sigV4 = &sigV4Signer{region: region, service: service, credentials: credentials, now: time.Now}
+	}
+}
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	region      string
+	service     string
+	credentials AWSCredentialsFunc
+	now         func() time.Time
+}
+
+// sigV4Transport is an http.RoundTripper signing requests with SigV4.
+type sigV4Transport struct {
+	next   http.RoundTripper
+	signer *sigV4Signer
+}
+
+func (t sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	creds, err := t.signer.credentials(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("signing request: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	t.signer.sign(req, body, creds)
+
+	return t.next.RoundTrip(req)
+}
+
+// readBody returns the body of req, leaving it readable.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		defer rc.Close()
+
+		return io.ReadAll(rc)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// sign sets the SigV4 authorization headers of req, whose body is body.
+func (v *sigV4Signer) sign(req *http.Request, body []byte, creds AWSCredentials) {
+	now := v.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := strings.Join([]string{now.Format("20060102"), v.region, v.service, "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for k, vs := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.Join(vs, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + strings.Join(strings.Fields(headers[k]), " ") + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	payloadHash := sha256.Sum256(body)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{v.region, v.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalURI returns the path of u with each segment encoded twice, as
+// expected by services other than S3.
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query of u sorted by key and value.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+
+	pairs := make([]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			pairs = append(pairs, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes every byte of s but the unreserved characters
+// of RFC 3986.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the requests and signatures are from the AWS SigV4 test suite
+func TestSigV4Signer(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		signature string
+	}{
+		{
+			name:      "get vanilla",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/",
+			signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:      "get vanilla query order key case",
+			method:    http.MethodGet,
+			url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	signer := &sigV4Signer{
+		region:  "us-east-1",
+		service: "service",
+		now:     func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			require.NoError(t, err)
+
+			signer.sign(req, nil, creds)
+
+			assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+			assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+tt.signature, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestWithAWSSigV4(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/execute-api/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, Signature=")
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	s := New(WithAWSSigV4("eu-west-1", "execute-api", nil), WithJWTAuthentication("some-jwt"))
+
+	resp, err := s.httpClient().Post(srv.URL+"/evaluate/v1/boolean", "application/json", strings.NewReader(`{"flagKey":"flag"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestWithAWSSigV4_MissingCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	s := New(WithAWSSigV4("eu-west-1", "execute-api", nil))
+
+	_, err := s.httpClient().Get("http://localhost")
+	assert.ErrorContains(t, err, "signing request: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+
+	_, err = EnvAWSCredentials(context.Background())
+	assert.Error(t, err)
+}
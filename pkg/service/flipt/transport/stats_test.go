@@ -0,0 +1,218 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/stats"
+)
+
+type countingHandler struct {
+	rpcs atomic.Int32
+}
+
+func (h *countingHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	h.rpcs.Add(1)
+	return ctx
+}
+
+func (h *countingHandler) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (h *countingHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *countingHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func healthServer(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)
+}
+
+func TestWithGRPCStatsHandler(t *testing.T) {
+	handler := &countingHandler{}
+
+	s := New(WithAddress(healthServer(t)), WithGRPCStatsHandler(handler))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, int32(1), handler.rpcs.Load())
+
+	// the default interceptor is kept
+	assert.NotNil(t, s.otelInterceptor)
+}
+
+func TestWithOTelGRPC(t *testing.T) {
+	s := New(WithAddress(healthServer(t)), WithOTelGRPC(otelgrpc.WithTracerProvider(trace.NewNoopTracerProvider())))
+	t.Cleanup(func() { _ = s.Close() })
+
+	// the stats handler replaces the default interceptor
+	assert.Nil(t, s.otelInterceptor)
+	assert.Len(t, s.statsHandlers, 1)
+
+	require.NoError(t, s.Check(context.Background()))
+}
+
+func TestWithDialOptions(t *testing.T) {
+	var dials atomic.Int32
+
+	s := New(WithAddress(healthServer(t)), WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	})))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+	assert.Equal(t, int32(1), dials.Load())
+}
+
+func TestWithKeepalive(t *testing.T) {
+	s := New(WithAddress(healthServer(t)), WithKeepalive(time.Minute, 10*time.Second, true))
+	t.Cleanup(func() { _ = s.Close() })
+
+	assert.Equal(t, &keepalive.ClientParameters{Time: time.Minute, Timeout: 10 * time.Second, PermitWithoutStream: true}, s.keepalive)
+	require.NoError(t, s.Check(context.Background()))
+}
+
+func TestWithConnectTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	s := New(WithAddress(address), WithConnectTimeout(100*time.Millisecond))
+	t.Cleanup(func() { _ = s.Close() })
+
+	start := time.Now()
+	err = s.Check(context.Background())
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.EqualError(t, err, "connecting dialing "+address+" timed out after 100ms: flipt is unavailable")
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the failed connection is not kept, every call reporting the failure
+	assert.Nil(t, s.client)
+	assert.ErrorIs(t, s.Check(context.Background()), ErrUnavailable)
+
+	// the connection is established once flipt is reachable
+	lis, err = net.Listen("tcp", address)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	assert.Eventually(t, func() bool {
+		return s.Check(context.Background()) == nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWithMaxMessageSizes(t *testing.T) {
+	address := healthServer(t)
+
+	// the health response of a serving server takes 2 bytes
+	s := New(WithAddress(address), WithMaxMessageSizes(1, 0))
+	t.Cleanup(func() { _ = s.Close() })
+
+	assert.ErrorContains(t, s.Check(context.Background()), "grpc: received message larger than max (2 vs. 1)")
+
+	s = New(WithAddress(address), WithMaxMessageSizes(2, 0))
+	t.Cleanup(func() { _ = s.Close() })
+
+	assert.NoError(t, s.Check(context.Background()))
+}
+
+func TestWithRoundRobinBalancing(t *testing.T) {
+	var addresses []resolver.Address
+
+	counters := make([]*atomic.Int32, 2)
+	for i := range counters {
+		lis, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+
+		calls := &atomic.Int32{}
+		counters[i] = calls
+
+		srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls.Add(1)
+			return handler(ctx, req)
+		}))
+		healthpb.RegisterHealthServer(srv, health.NewServer())
+
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+
+		addresses = append(addresses, resolver.Address{Addr: lis.Addr().String()})
+	}
+
+	r := manual.NewBuilderWithScheme("flipt")
+	r.InitialState(resolver.State{Addresses: addresses})
+
+	s := New(WithAddress("flipt:///pods"), WithRoundRobinBalancing(), WithDialOptions(grpc.WithResolvers(r)))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+
+	// calls are spread across both servers once connected to each
+	assert.Eventually(t, func() bool {
+		require.NoError(t, s.Check(context.Background()))
+		return counters[0].Load() > 0 && counters[1].Load() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// plain targets are resolved using DNS
+	s = New(WithAddress(healthServer(t)), WithRoundRobinBalancing())
+	t.Cleanup(func() { _ = s.Close() })
+
+	assert.NoError(t, s.Check(context.Background()))
+}
+
+func TestConnect_ContextDone(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	s := New(WithAddress(address))
+	t.Cleanup(func() { _ = s.Close() })
+
+	// the first call is bounded by its context even without a connect timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = s.Check(ctx)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, New(WithAddress(address)).Check(ctx), ErrCanceled)
+}
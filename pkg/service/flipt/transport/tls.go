@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithClientCertificate authenticates connections to Flipt with the client
+// certificate and key read from the PEM files at certPath and keyPath (mutual
+// TLS), for both the gRPC and HTTPS transports. The files are reloaded when
+// modified, so that rotated certificates are used by new connections.
+func WithClientCertificate(certPath, keyPath string) Option {
+	return func(s *Service) {
+		s.clientCertificate = &clientCertificate{certPath: certPath, keyPath: keyPath}
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the gRPC and HTTPS transports,
+// e.g. to trust CA pools built from memory or to restrict cipher suites and
+// versions. The CA certificate set by WithCertificatePath and the client
+// certificate set by WithClientCertificate take precedence over the
+// corresponding fields of config.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(s *Service) {
+		s.tlsClientConfig = config
+	}
+}
+
+// WithInsecureSkipVerifyTLS disables the verification of the certificate
+// presented by Flipt over gRPC or HTTPS. This makes connections vulnerable to
+// interception and must only be used in lab environments, e.g. with
+// self-signed certificates.
+func WithInsecureSkipVerifyTLS() Option {
+	return func(s *Service) {
+		s.insecureSkipVerify = true
+	}
+}
+
+// WithTLSServerName sets the name against which the certificate presented by
+// Flipt is verified, and which is sent with SNI, independently of the address
+// connected to, e.g. when connecting by IP address or through a TCP load
+// balancer whose hostname does not match the certificate.
+func WithTLSServerName(serverName string) Option {
+	return func(s *Service) {
+		s.tlsServerName = serverName
+	}
+}
+
+// usesTLS reports whether connections to Flipt are configured to use TLS.
+func (s *Service) usesTLS() bool {
+	return s.certificatePath != "" || s.clientCertificate != nil || s.tlsClientConfig != nil || s.insecureSkipVerify || s.tlsServerName != ""
+}
+
+// tlsConfig returns the TLS configuration of connections to Flipt, based on
+// the configuration set by WithTLSConfig if any, trusting the CA certificate
+// at the certificate path if any, and presenting the client certificate if
+// any.
+func (s *Service) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.tlsClientConfig != nil {
+		config = s.tlsClientConfig.Clone()
+	}
+
+	if s.certificatePath != "" {
+		certPool, err := loadCertPool(s.certificatePath)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = certPool
+	}
+
+	if s.tlsServerName != "" {
+		config.ServerName = s.tlsServerName
+	}
+
+	if s.insecureSkipVerify {
+		s.log().Error("UNSAFE: TLS certificate verification is disabled, connections to flipt can be intercepted", "address", s.address)
+
+		config.InsecureSkipVerify = true //nolint:gosec // explicitly requested with WithInsecureSkipVerifyTLS
+	}
+
+	if s.clientCertificate != nil {
+		config.Certificates = nil
+		config.GetClientCertificate = s.clientCertificate.get
+	}
+
+	return config, nil
+}
+
+// loadCertPool returns a pool of the CA certificates at path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pemServerCA, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(pemServerCA) {
+		return nil, fmt.Errorf("failed to add server CA's certificate")
+	}
+
+	return certPool, nil
+}
+
+// clientCertificate is a client certificate reloaded when its files are
+// modified.
+type clientCertificate struct {
+	certPath string
+	keyPath  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// get returns the client certificate, reloading it when its files have
+// changed. While the files cannot be loaded, e.g. in the middle of their
+// rotation, the previously loaded certificate is returned.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certMod, keyMod, err := c.modTimes()
+	if err == nil && c.cert != nil && certMod.Equal(c.certMod) && keyMod.Equal(c.keyMod) {
+		return c.cert, nil
+	}
+
+	if err == nil {
+		var cert tls.Certificate
+
+		if cert, err = tls.LoadX509KeyPair(c.certPath, c.keyPath); err == nil {
+			c.cert, c.certMod, c.keyMod = &cert, certMod, keyMod
+
+			return c.cert, nil
+		}
+	}
+
+	if c.cert != nil {
+		return c.cert, nil
+	}
+
+	return nil, fmt.Errorf("failed to load client certificate: %w", err)
+}
+
+func (c *clientCertificate) modTimes() (time.Time, time.Time, error) {
+	cert, err := os.Stat(c.certPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	key, err := os.Stat(c.keyPath)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return cert.ModTime(), key.ModTime(), nil
+}
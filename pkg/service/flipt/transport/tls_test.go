@@ -0,0 +1,266 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// writeClientCertificate writes a self-signed client certificate with the
+// given common name to dir, returning its certificate and key paths.
+func writeClientCertificate(t *testing.T, dir, commonName string) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return cert, certPath, keyPath
+}
+
+func TestClientCertificate_HTTPS(t *testing.T) {
+	dir := t.TempDir()
+
+	first, certPath, keyPath := writeClientCertificate(t, dir, "client-1")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(first)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Client", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	caPath := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	s := New(WithAddress(srv.URL), WithCertificatePath(caPath), WithClientCertificate(certPath, keyPath))
+	require.NoError(t, s.Validate())
+
+	client := s.httpClient()
+
+	get := func() string {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.Header.Get("X-Client")
+	}
+
+	assert.Equal(t, "client-1", get())
+
+	// rotated certificates are used by new connections
+	second, _, _ := writeClientCertificate(t, dir, "client-2")
+	clientCAs.AddCert(second)
+
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certPath, future, future))
+	require.NoError(t, os.Chtimes(keyPath, future, future))
+
+	s.httpTransport.CloseIdleConnections()
+
+	assert.Equal(t, "client-2", get())
+}
+
+func TestClientCertificate_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	s := New(WithAddress("localhost:9000"), WithClientCertificate(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")))
+	assert.ErrorContains(t, s.Validate(), "invalid client certificate")
+
+	c := &clientCertificate{certPath: filepath.Join(dir, "client.crt"), keyPath: filepath.Join(dir, "client.key")}
+
+	_, err := c.get(nil)
+	assert.ErrorContains(t, err, "failed to load client certificate")
+}
+
+func TestTLSConfig_HTTPS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srv.Certificate())
+
+	config := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS13}
+
+	s := New(WithAddress(srv.URL), WithTLSConfig(config))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+
+	// without the CA pool the server is not trusted
+	resp, err = New(WithAddress(srv.URL)).httpClient().Get(srv.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	assert.Error(t, err)
+}
+
+func TestTLSConfig_Precedence(t *testing.T) {
+	dir := t.TempDir()
+
+	// the self-signed client certificate doubles as the trusted CA
+	_, certPath, keyPath := writeClientCertificate(t, dir, "client")
+
+	base := &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		RootCAs:      x509.NewCertPool(),
+		Certificates: []tls.Certificate{{}},
+	}
+
+	s := New(WithTLSConfig(base), WithCertificatePath(certPath), WithClientCertificate(certPath, keyPath))
+	assert.True(t, s.usesTLS())
+
+	config, err := s.tlsConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.NotSame(t, base.RootCAs, config.RootCAs)
+	assert.Empty(t, config.Certificates)
+	assert.NotNil(t, config.GetClientCertificate)
+	assert.Nil(t, base.GetClientCertificate, "base configuration should not be modified")
+
+	assert.False(t, New().usesTLS())
+}
+
+func TestInsecureSkipVerifyTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+
+	s := New(WithAddress(srv.URL), WithInsecureSkipVerifyTLS(), WithLogger(logging.Std(log.New(&buf, "", 0), false)))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, buf.String(), "TLS certificate verification is disabled")
+}
+
+// serverCertificate returns a self-signed server certificate for dnsName,
+// and the path of its PEM encoding.
+func serverCertificate(t *testing.T, dnsName string) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: dnsName},
+		DNSNames:              []string{dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, path
+}
+
+func TestTLSServerName_HTTPS(t *testing.T) {
+	cert, caPath := serverCertificate(t, "flipt.example.com")
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	s := New(WithAddress(srv.URL), WithCertificatePath(caPath), WithTLSServerName("flipt.example.com"))
+
+	resp, err := s.httpClient().Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the certificate does not match the IP address of the server
+	resp, err = New(WithAddress(srv.URL), WithCertificatePath(caPath)).httpClient().Get(srv.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	assert.Error(t, err)
+}
+
+func TestTLSServerName_GRPC(t *testing.T) {
+	cert, caPath := serverCertificate(t, "flipt.example.com")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	s := New(WithAddress(lis.Addr().String()), WithCertificatePath(caPath), WithTLSServerName("flipt.example.com"))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+}
@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenProvider provides the bearer token authenticating requests to Flipt.
+// It is consulted for every request, so that short-lived tokens can be
+// rotated without recreating the Service.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// WithTokenProvider authenticates requests with the bearer token returned by
+// tp. It takes precedence over WithClientTokenProvider, and replaces any
+// WithJWTAuthentication applied before it.
+func WithTokenProvider(tp TokenProvider) Option {
+	return func(s *Service) {
+		s.useTokenProvider("Bearer", tp)
+	}
+}
+
+// useTokenProvider authorizes requests with the tokens of tp, using the given
+// authentication scheme. Token providers caching their tokens, such as
+// RefreshingTokenProvider, are invalidated when Flipt rejects a token, which
+// is then exchanged again once.
+func (s *Service) useTokenProvider(scheme string, tp TokenProvider) {
+	s.authorization = tokenAuthorization(scheme, tp)
+	s.invalidateToken = nil
+
+	if i, ok := tp.(interface{ Invalidate() }); ok {
+		s.invalidateToken = i.Invalidate
+	}
+}
+
+// tokenAuthorization returns the authorization of requests with the tokens
+// of tp, using the given authentication scheme.
+func tokenAuthorization(scheme string, tp TokenProvider) authorization {
+	return func(ctx context.Context) (string, error) {
+		token, err := tp.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		return scheme + " " + token, nil
+	}
+}
+
+// TokenFetchFunc fetches a token along with the time at which it expires.
+// A zero expiry means the token does not expire.
+type TokenFetchFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// RefreshingTokenProvider is a TokenProvider caching the token fetched by a
+// TokenFetchFunc until shortly before it expires.
+type RefreshingTokenProvider struct {
+	fetch  TokenFetchFunc
+	leeway time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewRefreshingTokenProvider returns a TokenProvider refreshing the token
+// fetched by fetch once it is within leeway of its expiry. While the cached
+// token has not yet expired, it is still returned if refreshing it fails.
+func NewRefreshingTokenProvider(fetch TokenFetchFunc, leeway time.Duration) *RefreshingTokenProvider {
+	return &RefreshingTokenProvider{
+		fetch:  fetch,
+		leeway: leeway,
+		now:    time.Now,
+	}
+}
+
+// Token returns the cached token, fetching a new one when none is cached or
+// it is about to expire.
+func (p *RefreshingTokenProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.token != "" && (p.expiry.IsZero() || now.Before(p.expiry.Add(-p.leeway))) {
+		return p.token, nil
+	}
+
+	token, expiry, err := p.fetch(ctx)
+	if err != nil {
+		if p.token != "" && now.Before(p.expiry) {
+			return p.token, nil
+		}
+
+		return "", err
+	}
+
+	p.token, p.expiry = token, expiry
+
+	return token, nil
+}
+
+// Invalidate discards the cached token, so that the next call to Token
+// fetches a new one. The Service calls it when Flipt rejects the token.
+func (p *RefreshingTokenProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token, p.expiry = "", time.Time{}
+}
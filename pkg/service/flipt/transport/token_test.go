@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRefreshingTokenProvider(t *testing.T) {
+	var (
+		now      = time.Unix(1700000000, 0)
+		fetches  int
+		fetchErr error
+	)
+
+	p := NewRefreshingTokenProvider(func(context.Context) (string, time.Time, error) {
+		fetches++
+		if fetchErr != nil {
+			return "", time.Time{}, fetchErr
+		}
+
+		return fmt.Sprintf("token-%d", fetches), now.Add(time.Minute), nil
+	}, 10*time.Second)
+	p.now = func() time.Time { return now }
+
+	token, err := p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// cached until within the leeway of its expiry
+	now = now.Add(49 * time.Second)
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(time.Second)
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// the unexpired token is served when refreshing fails
+	fetchErr = errors.New("issuer unavailable")
+	now = now.Add(55 * time.Second)
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	now = now.Add(5 * time.Second)
+	_, err = p.Token(context.Background())
+	assert.ErrorIs(t, err, fetchErr)
+
+	fetchErr = nil
+	p.Invalidate()
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-5", token)
+}
+
+func TestRefreshingTokenProvider_NoExpiry(t *testing.T) {
+	var fetches int
+
+	p := NewRefreshingTokenProvider(func(context.Context) (string, time.Time, error) {
+		fetches++
+		return "token", time.Time{}, nil
+	}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		token, err := p.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+
+	assert.Equal(t, 1, fetches)
+}
+
+type staticTokenProvider string
+
+func (s staticTokenProvider) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+func TestWithTokenProvider(t *testing.T) {
+	s := New(WithTokenProvider(staticTokenProvider("some-token")))
+
+	value, err := s.authorization(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer some-token", value)
+}
+
+// rotatingTokenProvider returns a RefreshingTokenProvider fetching the tokens
+// "token-1", "token-2" and so on, which do not expire.
+func rotatingTokenProvider(fetches *int) *RefreshingTokenProvider {
+	return NewRefreshingTokenProvider(func(context.Context) (string, time.Time, error) {
+		*fetches++
+		return fmt.Sprintf("token-%d", *fetches), time.Time{}, nil
+	}, time.Minute)
+}
+
+func TestWithTokenProvider_Rejected_GRPC(t *testing.T) {
+	var fetches, calls int
+
+	s := New(WithTokenProvider(rotatingTokenProvider(&fetches)))
+
+	// the token revoked by Flipt is exchanged again once
+	err := s.authorizationInterceptor(context.Background(), "/flipt.Flipt/GetFlag", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if md.Get("authorization")[0] != "Bearer token-2" {
+			return status.Error(codes.Unauthenticated, "token revoked")
+		}
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+	assert.Equal(t, 2, calls)
+
+	// tokens rejected again are not exchanged indefinitely
+	calls = 0
+
+	err = s.authorizationInterceptor(context.Background(), "/flipt.Flipt/GetFlag", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unauthenticated, "token revoked")
+	})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, 2, calls)
+}
+
+func TestWithTokenProvider_Rejected_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"flagKey":"flag"}`, string(body))
+
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var fetches int
+
+	s := New(WithTokenProvider(rotatingTokenProvider(&fetches)))
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"flagKey":"flag"}`))
+	require.NoError(t, err)
+
+	resp, err := s.httpClient().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, fetches)
+}
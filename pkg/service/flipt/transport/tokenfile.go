@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenFileCheckInterval is how often a token file is checked for changes.
+const tokenFileCheckInterval = time.Second
+
+// WithClientTokenFile authenticates requests with the client token read from
+// the file at path. The file is checked for changes at most once a second and
+// reloaded when modified, so that tokens rotated by sidecars such as Vault
+// agent are picked up without recreating the Service.
+func WithClientTokenFile(path string) Option {
+	return func(s *Service) {
+		s.useTokenProvider("Bearer", newFileTokenProvider(path))
+	}
+}
+
+// fileTokenProvider is a TokenProvider reading the token from a file.
+type fileTokenProvider struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+	checked time.Time
+}
+
+func newFileTokenProvider(path string) *fileTokenProvider {
+	return &fileTokenProvider{path: path, now: time.Now}
+}
+
+// Token returns the token read from the file, reloading it when the file has
+// changed since it was last read. While the file is missing or empty, e.g. in
+// the middle of its rotation, the previous token is returned.
+func (p *fileTokenProvider) Token(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.token != "" && now.Sub(p.checked) < tokenFileCheckInterval {
+		return p.token, nil
+	}
+
+	p.checked = now
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return p.cached(fmt.Errorf("reading client token: %w", err))
+	}
+
+	if p.token != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.token, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return p.cached(fmt.Errorf("reading client token: %w", err))
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return p.cached(errors.New("reading client token: file is empty"))
+	}
+
+	p.token, p.modTime, p.size = token, info.ModTime(), info.Size()
+
+	return token, nil
+}
+
+// cached returns the previously read token if any, otherwise err.
+func (p *fileTokenProvider) cached(err error) (string, error) {
+	if p.token != "" {
+		return p.token, nil
+	}
+
+	return "", err
+}
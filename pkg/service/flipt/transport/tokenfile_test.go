@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	now := time.Unix(1700000000, 0)
+
+	p := newFileTokenProvider(path)
+	p.now = func() time.Time { return now }
+
+	_, err := p.Token(context.Background())
+	assert.ErrorContains(t, err, "reading client token")
+
+	require.NoError(t, os.WriteFile(path, []byte("token-1\n"), 0o600))
+
+	token, err := p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	require.NoError(t, os.WriteFile(path, []byte("token-22"), 0o600))
+
+	// the file is not checked again within the check interval
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	now = now.Add(tokenFileCheckInterval)
+
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-22", token)
+
+	// the previous token is served while the file is being rotated
+	require.NoError(t, os.Remove(path))
+	now = now.Add(tokenFileCheckInterval)
+
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-22", token)
+}
+
+func TestWithClientTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("some-token"), 0o600))
+
+	s := New(WithClientTokenFile(path))
+
+	value, err := s.authorization(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer some-token", value)
+}
@@ -0,0 +1,149 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.flipt.io/flipt-openfeature-provider/pkg/service/flipt/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxWireLogBody is the number of bytes of request and response bodies
+// logged by wire logging.
+const maxWireLogBody = 1 << 10
+
+// WireLogging toggles, at runtime, the logging of the requests sent to Flipt
+// and of their responses. The zero value is disabled.
+type WireLogging struct {
+	enabled atomic.Bool
+}
+
+// SetEnabled enables or disables wire logging.
+func (w *WireLogging) SetEnabled(enabled bool) {
+	w.enabled.Store(enabled)
+}
+
+// Enabled reports whether wire logging is enabled.
+func (w *WireLogging) Enabled() bool {
+	return w != nil && w.enabled.Load()
+}
+
+// WithWireLogging logs every request sent to Flipt and its response (method,
+// URL, status, duration and bodies truncated to 1KiB, with secrets redacted)
+// at debug level whenever wire is enabled. The evaluation contexts of logged
+// requests are redacted using the function set by WithContextRedaction.
+func WithWireLogging(wire *WireLogging) Option {
+	return func(s *Service) {
+		s.wireLogging = wire
+	}
+}
+
+// wireLogInterceptor logs gRPC calls when wire logging is enabled.
+func (s *Service) wireLogInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !s.wireLogging.Enabled() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	kv := []interface{}{
+		"method", method,
+		"target", s.redactedAddress(),
+		"status", status.Code(err).String(),
+		"duration", time.Since(start),
+		"request", s.wireBody(s.redactRequestBody(protoBody(req))),
+	}
+
+	if err != nil {
+		kv = append(kv, "error", s.redact(err))
+	} else {
+		kv = append(kv, "response", s.wireBody(protoBody(reply)))
+	}
+
+	s.log().Debug("flipt grpc call", kv...)
+
+	return err
+}
+
+// protoBody returns the JSON representation of msg.
+func protoBody(msg interface{}) []byte {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	body, _ := protojson.Marshal(m)
+
+	return body
+}
+
+// wireBody returns body with secrets redacted, then truncated. Redacting the
+// whole body first keeps secrets crossing the truncation from being logged
+// in part.
+func (s *Service) wireBody(body []byte) string {
+	redacted := util.Redact(string(body), s.redactionPatterns...)
+	if len(redacted) > maxWireLogBody {
+		return redacted[:maxWireLogBody] + "...(truncated)"
+	}
+
+	return redacted
+}
+
+// wireLogTransport logs HTTP requests and responses when wire logging is
+// enabled.
+type wireLogTransport struct {
+	next http.RoundTripper
+	s    *Service
+}
+
+func (t wireLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.s.wireLogging.Enabled() {
+		return t.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			// read in full, to be redacted before it is truncated
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	kv := []interface{}{
+		"method", req.Method,
+		"url", util.Redact(req.URL.String(), t.s.redactionPatterns...),
+		"duration", time.Since(start),
+		"request", t.s.wireBody(t.s.redactRequestBody(reqBody)),
+	}
+
+	if err != nil {
+		t.s.log().Debug("flipt http request", append(kv, "error", t.s.redact(err))...)
+		return nil, err
+	}
+
+	// read in full, to be redacted before it is truncated, and put back in
+	// front of anything left unread after a failed read
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(respBody), resp.Body), Closer: resp.Body}
+
+	t.s.log().Debug("flipt http request", append(kv, "status", resp.StatusCode, "response", t.s.wireBody(respBody))...)
+
+	return resp, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
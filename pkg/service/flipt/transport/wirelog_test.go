@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	of "github.com/open-feature/go-sdk/pkg/openfeature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.flipt.io/flipt-openfeature-provider/pkg/logging"
+)
+
+func TestWithWireLogging_HTTP(t *testing.T) {
+	response := `{"clientToken":"s3cret","flags":[` + strings.Repeat(`{"key":"flag"},`, 100) + `{"key":"last"}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"flagKey":"color","password":"hunter2"}`, string(body))
+
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		buf  bytes.Buffer
+		wire WireLogging
+		s    = New(WithWireLogging(&wire), WithLogger(logging.Std(log.New(&buf, "", 0), true)))
+	)
+
+	post := func() {
+		t.Helper()
+
+		resp, err := s.httpClient().Post(srv.URL+"/evaluate?access_token=abc", "application/json", strings.NewReader(`{"flagKey":"color","password":"hunter2"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// the logged body is still returned in full
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, response, string(body))
+	}
+
+	post()
+	assert.Empty(t, buf.String())
+
+	wire.SetEnabled(true)
+	post()
+
+	logged := buf.String()
+	assert.Contains(t, logged, "DEBUG flipt http request method=POST url="+srv.URL+"/evaluate?access_token=[REDACTED]")
+	assert.Contains(t, logged, `request={"flagKey":"color","password":"[REDACTED]"}`)
+	assert.Contains(t, logged, `status=200`)
+	assert.Contains(t, logged, `response={"clientToken":"[REDACTED]","flags":[{"key":"flag"}`)
+	assert.Contains(t, logged, "...(truncated)")
+	assert.NotContains(t, logged, "s3cret")
+	assert.NotContains(t, logged, "hunter2")
+
+	buf.Reset()
+	wire.SetEnabled(false)
+	post()
+	assert.Empty(t, buf.String())
+}
+
+func TestWireBody(t *testing.T) {
+	s := New(WithRedactionPatterns(regexp.MustCompile(`s3cret-[0-9]+`)))
+
+	// the secret crosses the truncation of the logged body
+	logged := s.wireBody([]byte(strings.Repeat(" ", maxWireLogBody-4) + "s3cret-123456789"))
+	assert.NotContains(t, logged, "s3cr")
+	assert.True(t, strings.HasSuffix(logged, "...(truncated)"))
+}
+
+func TestWithWireLogging_ContextRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		buf  bytes.Buffer
+		wire WireLogging
+	)
+
+	wire.SetEnabled(true)
+
+	s := New(WithWireLogging(&wire), WithLogger(logging.Std(log.New(&buf, "", 0), true)), WithContextRedaction(func(key string, value interface{}) interface{} {
+		if key == "email" || key == of.TargetingKey {
+			return "[HIDDEN]"
+		}
+
+		return value
+	}))
+
+	post := func(body string) {
+		t.Helper()
+
+		resp, err := s.httpClient().Post(srv.URL+"/evaluate", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	post(`{"requests":[{"flagKey":"color","entityId":"user-1","context":{"email":"foo@flipt.io","plan":"pro"}}]}`)
+	post(`not json user-1`)
+
+	logged := buf.String()
+	assert.Contains(t, logged, `request={"requests":[{"context":{"email":"[HIDDEN]","plan":"pro"},"entityId":"[HIDDEN]","flagKey":"color"}]}`)
+	assert.Contains(t, logged, `request=(omitted)`)
+	assert.NotContains(t, logged, "foo@flipt.io")
+	assert.NotContains(t, logged, "user-1")
+}
+
+func TestWithWireLogging_GRPC(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		wire WireLogging
+	)
+
+	wire.SetEnabled(true)
+
+	s := New(WithAddress(healthServer(t)), WithWireLogging(&wire), WithLogger(logging.Std(log.New(&buf, "", 0), true)))
+	t.Cleanup(func() { _ = s.Close() })
+
+	require.NoError(t, s.Check(context.Background()))
+
+	logged := buf.String()
+	assert.Contains(t, logged, "DEBUG flipt grpc call method=/grpc.health.v1.Health/Check")
+	assert.Contains(t, logged, "status=OK")
+	assert.Contains(t, logged, `response={"status":"SERVING"}`)
+}
@@ -7,6 +7,10 @@ import (
 	of "github.com/open-feature/go-sdk/pkg/openfeature"
 )
 
+// CanceledPrefix prefixes the messages of the resolution errors of calls
+// canceled by their caller, which OpenFeature has no code for.
+const CanceledPrefix = "canceled: "
+
 func GRPCToOpenFeatureError(err error) of.ResolutionError {
 	s, ok := status.FromError(err)
 	if !ok {
@@ -20,6 +24,11 @@ func GRPCToOpenFeatureError(err error) of.ResolutionError {
 		return of.NewInvalidContextResolutionError(s.Message())
 	case codes.Unavailable:
 		return of.NewProviderNotReadyResolutionError(s.Message())
+	case codes.DeadlineExceeded:
+		// OpenFeature has no code dedicated to timeouts
+		return of.NewGeneralResolutionError("timeout: " + s.Message())
+	case codes.Canceled:
+		return of.NewGeneralResolutionError(CanceledPrefix + s.Message())
 	}
 
 	return of.NewGeneralResolutionError(s.Message())
@@ -30,6 +30,16 @@ func TestGRPCToOpenFeatureError(t *testing.T) {
 			grpcStatus:  status.New(codes.Unavailable, "unavailable"),
 			expectedErr: of.NewProviderNotReadyResolutionError("unavailable"),
 		},
+		{
+			name:        "deadline exceeded",
+			grpcStatus:  status.New(codes.DeadlineExceeded, "context deadline exceeded"),
+			expectedErr: of.NewGeneralResolutionError("timeout: context deadline exceeded"),
+		},
+		{
+			name:        "canceled",
+			grpcStatus:  status.New(codes.Canceled, "context canceled"),
+			expectedErr: of.NewGeneralResolutionError("canceled: context canceled"),
+		},
 		{
 			name:        "unknown",
 			grpcStatus:  status.New(codes.Unknown, "unknown"),